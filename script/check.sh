@@ -0,0 +1,22 @@
+#!/usr/bin/env bash
+# Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+#
+# Check clinical-trial eligibility criteria for contradictory and redundant criteria.
+# To also check for concepts that should not be required together, run with
+# -nel <nel output file>.
+#
+# ./script/check.sh
+
+set -eu
+
+CMD="src/cmd/check/main.go"
+CONFIG="src/resources/config/cfg.conf"
+INPUT="data/input/clinical_trials.csv"
+OUTPUT="data/output/consistency_findings.tsv"
+
+if ! go run "$CMD" -conf "$CONFIG" -i "$INPUT" -o "$OUTPUT" -logtostderr
+then
+  rm -f "$OUTPUT"
+  echo "Consistency check failed."
+  exit 1
+fi
@@ -0,0 +1,22 @@
+#!/usr/bin/env bash
+# Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+#
+# Evaluate the CFG parser against gold-annotated eligibility criteria.
+# To evaluate NEL output instead, run with -mode nel -i <nel output file>
+# and a gold file of criteria annotated with concepts.
+#
+# ./script/eval.sh
+
+set -eu
+
+CMD="src/cmd/eval/main.go"
+CONFIG="src/resources/config/cfg.conf"
+GOLD="data/gold/criteria.jsonl"
+OUTPUT="data/output/cfg_evaluation.tsv"
+
+if ! go run "$CMD" -conf "$CONFIG" -gold "$GOLD" -o "$OUTPUT" -logtostderr
+then
+  rm -f "$OUTPUT"
+  echo "Evaluation failed."
+  exit 1
+fi
@@ -2,11 +2,14 @@
 # Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
 #
 # Parse clinical-trial eligibility criteria with IE.
+# NER runs the caffe2 model by default. With 'dictionary', NER looks up
+# vocabulary synonyms in Go instead, so that no model is needed.
 #
-# ./script/ie_parse.sh
+# ./script/ie_parse.sh [model|dictionary]
 
 set -eu
 
+NER_MODE="${1:-model}"
 NER_MODEL="bin/ner.c2"
 
 CLINICAL_TRIAL_FILE="data/input/clinical_trials.csv"
@@ -16,6 +19,7 @@ PARSED_FILE="data/output/ie_parsed_clinical_trials.tsv"
 
 EXTRACT_CMD="src/cmd/extract/main.go"
 NER_CMD="src/ie/ner.py"
+NER_DICTIONARY_CMD="src/cmd/ner/main.go"
 NEL_CMD="src/cmd/nel/main.go"
 NEL_CONFIG="src/resources/config/nel.conf"
 
@@ -29,12 +33,27 @@ then
 fi
 
 echo "Run NER on extracted criteria..."
-export PYTHONPATH="$(pwd)/src"
-if ! python "$NER_CMD" -m "$NER_MODEL" -i "$EXTRACTED_FILE" -o "$NER_FILE"
-then
-  echo "NER failed."
-  exit 1
-fi
+case "$NER_MODE" in
+  model)
+    export PYTHONPATH="$(pwd)/src"
+    if ! python "$NER_CMD" -m "$NER_MODEL" -i "$EXTRACTED_FILE" -o "$NER_FILE"
+    then
+      echo "NER failed."
+      exit 1
+    fi
+    ;;
+  dictionary)
+    if ! go run "$NER_DICTIONARY_CMD" -conf "$NEL_CONFIG" -i "$EXTRACTED_FILE" -o "$NER_FILE" -logtostderr
+    then
+      echo "NER failed."
+      exit 1
+    fi
+    ;;
+  *)
+    echo "Unknown NER mode: $NER_MODE"
+    exit 1
+    ;;
+esac
 
 echo "Run NEL to map NER terms to MESH concepts..."
 if ! go run "$NEL_CMD" -conf "$NEL_CONFIG" -i "$NER_FILE" -o "$PARSED_FILE" -logtostderr
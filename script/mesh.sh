@@ -1,8 +1,8 @@
 #!/usr/bin/env bash
 # Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
 #
-# Download the MeSH descriptors to data/mesh. The first argument is
-# the optional latest production year with the default value "2021".
+# Download the MeSH descriptors and supplementary concept records to data/mesh.
+# The first argument is the optional latest production year with the default value "2021".
 #
 # ./script/mesh.sh [<year>]
 
@@ -10,9 +10,16 @@ set -eu
 
 PRODUCTION_YEAR=${1:-"2021"}
 DESCRIPTOR=desc${PRODUCTION_YEAR}.xml
+SUPPLEMENTARY=supp${PRODUCTION_YEAR}.xml
 
 if ! curl ftp://nlmpubs.nlm.nih.gov/online/mesh/MESH_FILES/xmlmesh/"$DESCRIPTOR" -o data/mesh/descriptor.xml
 then
   echo "MeSH descriptor download failed; the latest production year may be old: $PRODUCTION_YEAR"
   exit 1
-fi
\ No newline at end of file
+fi
+
+if ! curl ftp://nlmpubs.nlm.nih.gov/online/mesh/MESH_FILES/xmlmesh/"$SUPPLEMENTARY" -o data/mesh/supplementary.xml
+then
+  echo "MeSH supplementary concept record download failed; the latest production year may be old: $PRODUCTION_YEAR"
+  exit 1
+fi
@@ -0,0 +1,22 @@
+#!/usr/bin/env bash
+# Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+#
+# Estimate CFG rule weights from a treebank of hand-parsed eligibility criteria.
+# The treebank is small, so review the output weights by hand as priors before
+# copying them to src/resources/grammar/criterion.txt.
+#
+# ./script/pcfg_estimate.sh
+
+set -eu
+
+CMD="src/cmd/pcfg/main.go"
+CONFIG="src/resources/config/cfg.conf"
+INPUT="data/treebank/criteria.txt"
+OUTPUT="data/output/criterion_grammar.txt"
+
+if ! go run "$CMD" -conf "$CONFIG" -i "$INPUT" -o "$OUTPUT" -smoothing 1 -logtostderr
+then
+  rm -f "$OUTPUT"
+  echo "PCFG estimation failed."
+  exit 1
+fi
@@ -0,0 +1,14 @@
+#!/usr/bin/env bash
+# Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+#
+# Serve criteria extraction, CFG parsing, and concept matching over HTTP.
+# Uncomment vocabulary_file in the config to enable concept matching.
+#
+# ./script/serve.sh
+
+set -eu
+
+CMD="src/cmd/serve/main.go"
+CONFIG="src/resources/config/serve.conf"
+
+go run "$CMD" -conf "$CONFIG" -addr ":8080" -logtostderr
@@ -3,20 +3,23 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
-	"io"
 	"os"
-	"strings"
+	"runtime"
+	"strconv"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/conf"
-	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/fio"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/text"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/timer"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/fhir"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/omop"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies"
 
 	"github.com/golang/glog"
 )
@@ -32,17 +35,20 @@ func main() {
 	if err := p.Initialize(); err != nil {
 		glog.Fatal(err)
 	}
-	if err := p.Ingest(); err != nil {
+	if p.parameters.Exists("explain") {
+		p.Explain()
+		p.Close()
+		return
+	}
+	if err := p.Parse(); err != nil {
 		glog.Fatal(err)
 	}
-	p.Parse()
 	p.Close()
 }
 
 // Parser defines the struct for processing eligibility criteria.
 type Parser struct {
 	parameters conf.Config
-	registry   studies.Studies
 	clock      timer.Timer
 }
 
@@ -54,20 +60,44 @@ func NewParser() *Parser {
 // LoadParameters loads parameters from command line and a config file.
 func (p *Parser) LoadParameters() error {
 	configFname := flag.String("conf", "", "Config file")
-	inputFname := flag.String("i", "", "Input file")
+	inputFname := flag.String("i", "", "Input file, directory, or zip archive")
+	inputFormat := flag.String("input_format", studies.CSV, "Input format: csv, json, xml, or aact")
+	studyType := flag.String("aact_study_type", "Interventional", "Study type of the AACT studies")
+	overallStatus := flag.String("aact_status", "Recruiting", "Overall status of the AACT studies")
+	conditions := flag.String("aact_conditions", "", "Pattern of the lowercase conditions of the AACT studies")
+	excludeConditions := flag.Bool("aact_exclude_conditions", false, "Exclude the AACT studies whose conditions match the pattern")
 	outputFname := flag.String("o", "", "Output file")
+	format := flag.String("format", "tsv", "Output format: tsv with a line per relation, jsonl with a line per study, fhir with a FHIR bundle per study, or omop with an OMOP CDM cohort query per study")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
+	explain := flag.String("explain", "", "Criterion to explain instead of parsing the input file")
 
 	flag.Parse()
-	if len(*configFname) == 0 || len(*inputFname) == 0 || len(*outputFname) == 0 {
-		return fmt.Errorf("usage: %s -conf <config file> -i <input file> -o <output name>", os.Args[0])
+	if *format != "tsv" && *format != "jsonl" && *format != "fhir" && *format != "omop" {
+		return fmt.Errorf("unknown output format: %q", *format)
+	}
+	if *workers < 1 {
+		return fmt.Errorf("workers must be positive: %d", *workers)
+	}
+	if len(*configFname) == 0 || (len(*explain) == 0 && (len(*inputFname) == 0 || len(*outputFname) == 0)) {
+		return fmt.Errorf("usage: %s -conf <config file> -i <input file> -o <output name> [-input_format <csv|json|xml|aact>] [-format <tsv|jsonl|fhir|omop>] [-workers <n>] | -explain <criterion>", os.Args[0])
 	}
 
 	parameters, err := conf.Load(*configFname)
 	if err != nil {
 		return err
 	}
+	if len(*explain) > 0 {
+		parameters.Put("explain", *explain)
+	}
 	parameters.Put("input_file", *inputFname)
+	parameters.Put("input_format", *inputFormat)
+	parameters.Put("aact_study_type", *studyType)
+	parameters.Put("aact_status", *overallStatus)
+	parameters.Put("aact_conditions", *conditions)
+	parameters.Put("aact_exclude_conditions", strconv.FormatBool(*excludeConditions))
 	parameters.Put("output_file", *outputFname)
+	parameters.Put("format", *format)
+	parameters.Put("workers", strconv.Itoa(*workers))
 	p.parameters = parameters
 
 	return nil
@@ -87,73 +117,154 @@ func (p *Parser) Initialize() error {
 	if err != nil {
 		return err
 	}
-	units.Set(unitDictionary)
-
-	return nil
-}
-
-// Ingest ingests eligibility criteria from a file.
-func (p *Parser) Ingest() error {
-	fname := p.parameters.Get("input_file")
-	f, err := os.Open(fname)
-	if err != nil {
-		return err
+	if p.parameters.Exists("conversion_file") {
+		fname = p.parameters.GetResourcePath("conversion_file")
+		if err := unitDictionary.LoadConversions(fname); err != nil {
+			return err
+		}
 	}
-	defer f.Close()
-
-	registry := studies.New()
-	r := csv.NewReader(f)
-	r.Comment = rune(param.Comment)
+	units.Set(unitDictionary)
 
-	for {
-		line, err := r.Read()
-		if err == io.EOF {
-			break
-		}
+	if p.parameters.Exists("grammar_file") {
+		fname = p.parameters.GetResourcePath("grammar_file")
+		grammar, err := parser.LoadCFGrammar(fname)
 		if err != nil {
 			return err
 		}
-		if len(line) < 5 {
-			return fmt.Errorf("too few columns, at least 4 needed: %v", line)
-		}
-		nctID := line[0]
-		title := line[1]
-		// Skip line[2]: has_us_facility
-		conditions := strings.Split(line[3], param.FieldSep)
-		eligibilityCriteria := line[4]
-
-		study := studies.NewStudy(nctID, title, conditions, eligibilityCriteria)
-		registry.Add(study)
+		parser.Set(parser.NewInterpreterWithGrammar(grammar))
 	}
-	glog.Infof("Ingested studies: %d\n", registry.Len())
-	p.registry = registry
 
 	return nil
 }
 
-// Parse parses the ingested eligibility criteria and writes the results to a file.
-func (p *Parser) Parse() {
+// Parse streams the eligibility criteria from the input, parses them, and writes the results
+// to a file. The studies are parsed concurrently and written in the input order, one study at a time.
+func (p *Parser) Parse() error {
+	reader, err := p.openInput()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
 	header := "#nct_id\teligibility_type\tvariable_type\tcriterion_index\tcriterion\tquestion\trelation\n"
+	studyCnt := 0
 	criteriaCnt := 0
 	parsedCriteriaCnt := 0
 	relationCnt := 0
-	fname := p.parameters.Get("output_file")
-	writer := fio.Writer(fname)
+	writer := fio.Writer(p.parameters.Get("output_file"))
 	defer writer.Close()
-	writer.WriteString(header)
-	for _, study := range p.registry {
-		writer.WriteString(study.Parse().Relations())
+	format := p.parameters.Get("format")
+	if format == "tsv" {
+		writer.WriteString(header)
+	}
+	var exporter *fhir.Exporter
+	var validator *fhir.Validator
+	var generator *omop.Generator
+	switch format {
+	case "fhir":
+		exporter = fhir.NewExporter()
+		if p.parameters.Exists("vocabulary_file") {
+			matcher, err := p.loadConceptMatcher()
+			if err != nil {
+				return err
+			}
+			exporter.SetConceptMatcher(matcher)
+		}
+		if validator, err = fhir.NewValidator(); err != nil {
+			return err
+		}
+	case "omop":
+		concepts, err := omop.LoadConcepts(p.parameters.GetResourcePath("omop_concept_file"))
+		if err != nil {
+			return err
+		}
+		generator = omop.NewGenerator(concepts)
+	}
+
+	// The FHIR bundles and OMOP queries are generated concurrently with parsing.
+	parse := func(study *studies.Study) interface{} {
+		study.Parse()
+		switch {
+		case exporter != nil:
+			bundle := exporter.Bundle(study).JSON()
+			if err := validator.Validate([]byte(bundle)); err != nil {
+				glog.Warningf("Invalid FHIR bundle of %s, skipped:\n%v\n", study.NCT(), err)
+				return nil
+			}
+			return bundle
+		case generator != nil:
+			return generator.Cohort(study).SQL()
+		default:
+			return nil
+		}
+	}
+	write := func(study *studies.Study, result interface{}) error {
+		studyCnt++
 		criteriaCnt += study.CriteriaCount()
 		parsedCriteriaCnt += study.ParsedCriteriaCount()
 		relationCnt += study.RelationCount()
-
+		switch format {
+		case "jsonl":
+			return study.WriteJSON(writer)
+		case "fhir":
+			if bundle, ok := result.(string); ok {
+				_, err := writer.WriteString(bundle + "\n")
+				return err
+			}
+			return nil
+		case "omop":
+			_, err := writer.WriteString(result.(string) + "\n")
+			return err
+		default:
+			return study.WriteRelations(writer)
+		}
 	}
+	if err := studies.Stream(reader, p.parameters.GetInt("workers"), parse, write); err != nil {
+		return err
+	}
+
 	ratio := 0.0
 	if criteriaCnt > 0 {
 		ratio = 100 * float64(relationCnt) / float64(criteriaCnt)
 	}
 	glog.Infof("Ingested studies: %d, Extracted criteria: %d, Parsed criteria: %d, Relations: %d, Relations per criteria: %.1f%%\n",
-		p.registry.Len(), criteriaCnt, parsedCriteriaCnt, relationCnt, ratio)
+		studyCnt, criteriaCnt, parsedCriteriaCnt, relationCnt, ratio)
+	return nil
+}
+
+// loadConceptMatcher loads the vocabulary that codes the conditions and criteria of the FHIR bundles.
+func (p *Parser) loadConceptMatcher() (fhir.ConceptMatcher, error) {
+	vocabulary, err := vocabularies.Load(p.parameters, p.parameters.GetDataPath)
+	if err != nil {
+		return nil, err
+	}
+	vocabulary.Info()
+	system := fhir.MeSHSystem
+	if vocabularies.ParseSource(p.parameters.Get("vocabulary_source")) == vocabularies.UMLS {
+		system = fhir.UMLSSystem
+	}
+	return fhir.NewVocabularyMatcher(vocabulary, system, p.parameters.GetFloat64("match_margin")), nil
+}
+
+// Explain writes the intermediate results of interpreting the criterion to stdout.
+func (p *Parser) Explain() {
+	criterion := text.ToLowerSameWidth(p.parameters.Get("explain"))
+	fmt.Print(parser.Get().Explain(criterion))
+}
+
+// openInput opens the input file, directory, or zip archive for reading studies.
+// AACT flat files are joined and filtered in memory.
+func (p *Parser) openInput() (studies.ReadCloser, error) {
+	filter, err := studies.NewAACTFilter(
+		p.parameters.Get("aact_study_type"),
+		p.parameters.Get("aact_status"),
+		p.parameters.Get("aact_conditions"),
+		p.parameters.GetBool("aact_exclude_conditions"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return studies.OpenWithFilter(p.parameters.Get("input_file"), p.parameters.Get("input_format"), filter)
 }
 
 // Close closes the parser.
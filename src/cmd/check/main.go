@@ -0,0 +1,232 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/conf"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/fio"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/timer"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/assertion"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/consistency"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/eligibility"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+
+	"github.com/golang/glog"
+)
+
+// main checks the eligibility criteria of clinical studies for contradictory and redundant criteria.
+// The CFG relations of each study are intersected per variable, and the NEL output, if given,
+// is checked for concepts that should not be required together. The output is a file of the
+// findings with a line per involved criterion.
+func main() {
+	c := NewChecker()
+	if err := c.LoadParameters(); err != nil {
+		glog.Fatal(err)
+	}
+	if err := c.Initialize(); err != nil {
+		glog.Fatal(err)
+	}
+	if err := c.Check(); err != nil {
+		glog.Fatal(err)
+	}
+	c.Close()
+}
+
+// Checker defines the struct for checking the consistency of eligibility criteria.
+type Checker struct {
+	parameters conf.Config
+	checker    *consistency.Checker
+	clock      timer.Timer
+}
+
+// NewChecker creates a new checker.
+func NewChecker() *Checker {
+	return &Checker{clock: timer.New()}
+}
+
+// LoadParameters loads parameters from command line and a config file.
+func (c *Checker) LoadParameters() error {
+	configFname := flag.String("conf", "", "Config file")
+	inputFname := flag.String("i", "", "Input file, directory, or zip archive")
+	inputFormat := flag.String("input_format", studies.CSV, "Input format: csv, json, or xml")
+	nelFname := flag.String("nel", "", "NEL output file of the studies to check for conflicting concepts")
+	outputFname := flag.String("o", "", "Output file of the findings")
+	minScore := flag.Float64("min_score", 0, "Minimum score of the parsed relations, exclusive")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
+
+	flag.Parse()
+	if *workers < 1 {
+		return fmt.Errorf("workers must be positive: %d", *workers)
+	}
+	if len(*configFname) == 0 || len(*inputFname) == 0 || len(*outputFname) == 0 {
+		return fmt.Errorf("usage: %s -conf <config file> -i <input file> -o <output file> [-input_format <csv|json|xml>] [-nel <nel output file>] [-min_score <score>] [-workers <n>]", os.Args[0])
+	}
+
+	parameters, err := conf.Load(*configFname)
+	if err != nil {
+		return err
+	}
+	parameters.Put("input_file", *inputFname)
+	parameters.Put("input_format", *inputFormat)
+	if len(*nelFname) > 0 {
+		parameters.Put("nel_file", *nelFname)
+	}
+	parameters.Put("output_file", *outputFname)
+	parameters.Put("min_score", strconv.FormatFloat(*minScore, 'f', -1, 64))
+	parameters.Put("workers", strconv.Itoa(*workers))
+	c.parameters = parameters
+
+	return nil
+}
+
+// Initialize initializes the checker by loading the resource data of the parser.
+func (c *Checker) Initialize() error {
+	fname := c.parameters.GetResourcePath("variable_file")
+	variableDictionary, err := variables.Load(fname)
+	if err != nil {
+		return err
+	}
+	variables.Set(variableDictionary)
+
+	fname = c.parameters.GetResourcePath("unit_file")
+	unitDictionary, err := units.Load(fname)
+	if err != nil {
+		return err
+	}
+	if c.parameters.Exists("conversion_file") {
+		fname = c.parameters.GetResourcePath("conversion_file")
+		if err := unitDictionary.LoadConversions(fname); err != nil {
+			return err
+		}
+	}
+	units.Set(unitDictionary)
+
+	if c.parameters.Exists("grammar_file") {
+		fname = c.parameters.GetResourcePath("grammar_file")
+		grammar, err := parser.LoadCFGrammar(fname)
+		if err != nil {
+			return err
+		}
+		parser.Set(parser.NewInterpreterWithGrammar(grammar))
+	}
+
+	c.checker = consistency.NewChecker().SetMinScore(c.parameters.GetFloat64("min_score"))
+	if c.parameters.Exists("concept_conflicts") {
+		conflicts, err := consistency.ParseConflicts(c.parameters.Get("concept_conflicts"))
+		if err != nil {
+			return err
+		}
+		c.checker.SetConflicts(conflicts)
+	}
+
+	return nil
+}
+
+// Check parses the studies, checks their criteria, and writes the findings to a file.
+// The studies are parsed and checked concurrently, and written in the input order.
+func (c *Checker) Check() error {
+	concepts := make(map[string][]consistency.Concept)
+	if c.parameters.Exists("nel_file") {
+		var err error
+		if concepts, err = readConcepts(c.parameters.Get("nel_file")); err != nil {
+			return err
+		}
+	}
+
+	reader, err := studies.Open(c.parameters.Get("input_file"), c.parameters.Get("input_format"))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	writer := fio.Writer(c.parameters.Get("output_file"))
+	defer writer.Close()
+
+	header := "#nct_id\tfinding_id\tfinding\tvariable\tunit\tmerged\teligibility_type\tcriterion_index\tcriterion\tdomain\n"
+	writer.WriteString(header)
+
+	studyCnt := 0
+	counts := make(map[consistency.Kind]int)
+	findingCnt := 0
+	check := func(study *studies.Study) interface{} {
+		findings := c.checker.Check(study.Parse())
+		return append(findings, c.checker.CheckConcepts(study.NCT(), concepts[study.NCT()])...)
+	}
+	write := func(study *studies.Study, result interface{}) error {
+		studyCnt++
+		findings := result.(consistency.Findings)
+		for _, f := range findings {
+			counts[f.Kind]++
+		}
+		if err := findings.Write(writer, findingCnt); err != nil {
+			return err
+		}
+		findingCnt += len(findings)
+		return nil
+	}
+	if err := studies.Stream(reader, c.parameters.GetInt("workers"), check, write); err != nil {
+		return err
+	}
+
+	glog.Infof("Checked studies: %d, Findings: %d, Contradictions: %d, Infeasible: %d, Duplicates: %d, Redundant: %d, Conflicts: %d\n",
+		studyCnt, findingCnt, counts[consistency.Contradiction], counts[consistency.Infeasible], counts[consistency.Duplicate],
+		counts[consistency.Redundant], counts[consistency.Conflict])
+	return nil
+}
+
+// readConcepts reads the NER terms of the NEL output per trial.
+func readConcepts(fname string) (map[string][]consistency.Concept, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	concepts := make(map[string][]consistency.Concept)
+	scanner := bufio.NewScanner(file)
+	lineCnt := 0
+	termCnt := 0
+	for scanner.Scan() {
+		lineCnt++
+		line := scanner.Text()
+		if len(line) == 0 || line[0] == param.Comment {
+			continue
+		}
+		values := strings.Split(line, "\t")
+		if len(values) < 12 {
+			return nil, fmt.Errorf("%s: line %d: too few columns", fname, lineCnt)
+		}
+		concepts[values[0]] = append(concepts[values[0]], consistency.Concept{
+			Type:      eligibility.ParseType(values[1]),
+			Criterion: values[2],
+			Label:     values[3],
+			Term:      values[4],
+			Polarity:  assertion.Polarity(values[11]),
+		})
+		termCnt++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	glog.Infof("Number of NEL terms loaded: %d\n", termCnt)
+
+	return concepts, nil
+}
+
+// Close closes the checker.
+func (c *Checker) Close() {
+	glog.Info(c.clock.Elapsed())
+	glog.Flush()
+}
@@ -0,0 +1,187 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/conf"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/fio"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/timer"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/eval"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+
+	"github.com/golang/glog"
+)
+
+// main evaluates the CFG parser or the NEL output against gold-annotated criteria.
+// The output is a report of precision, recall, and F1 overall and per group,
+// and of the number of errors per error class.
+func main() {
+	e := NewEvaluator()
+	if err := e.LoadParameters(); err != nil {
+		glog.Fatal(err)
+	}
+	if err := e.Initialize(); err != nil {
+		glog.Fatal(err)
+	}
+	if err := e.Evaluate(); err != nil {
+		glog.Fatal(err)
+	}
+	e.Close()
+}
+
+// Evaluator defines the struct for evaluating parsed or linked criteria.
+type Evaluator struct {
+	parameters conf.Config
+	clock      timer.Timer
+}
+
+// NewEvaluator creates a new evaluator.
+func NewEvaluator() *Evaluator {
+	return &Evaluator{clock: timer.New()}
+}
+
+// LoadParameters loads parameters from command line and a config file.
+func (e *Evaluator) LoadParameters() error {
+	configFname := flag.String("conf", "", "Config file")
+	goldFname := flag.String("gold", "", "Gold file of annotated criteria in the JSON lines format")
+	mode := flag.String("mode", "relations", "Evaluation mode: relations to parse the gold criteria, or nel to read linked concepts")
+	inputFname := flag.String("i", "", "NEL output file for the nel mode")
+	outputFname := flag.String("o", "", "Output file of the report")
+	minScore := flag.Float64("min_score", 0, "Minimum score of the parsed relations, exclusive")
+
+	flag.Parse()
+	if *mode != "relations" && *mode != "nel" {
+		return fmt.Errorf("unknown evaluation mode: %q", *mode)
+	}
+	if len(*configFname) == 0 || len(*goldFname) == 0 || len(*outputFname) == 0 || (*mode == "nel" && len(*inputFname) == 0) {
+		return fmt.Errorf("usage: %s -conf <config file> -gold <gold file> -o <output file> [-mode relations] [-min_score <score>] | -mode nel -i <nel output file>", os.Args[0])
+	}
+
+	parameters, err := conf.Load(*configFname)
+	if err != nil {
+		return err
+	}
+	parameters.Put("gold_file", *goldFname)
+	parameters.Put("mode", *mode)
+	parameters.Put("input_file", *inputFname)
+	parameters.Put("output_file", *outputFname)
+	parameters.Put("min_score", strconv.FormatFloat(*minScore, 'f', -1, 64))
+	e.parameters = parameters
+
+	return nil
+}
+
+// Initialize initializes the evaluator by loading the resource data of the parser.
+func (e *Evaluator) Initialize() error {
+	if e.parameters.Get("mode") != "relations" {
+		return nil
+	}
+	fname := e.parameters.GetResourcePath("variable_file")
+	variableDictionary, err := variables.Load(fname)
+	if err != nil {
+		return err
+	}
+	variables.Set(variableDictionary)
+
+	fname = e.parameters.GetResourcePath("unit_file")
+	unitDictionary, err := units.Load(fname)
+	if err != nil {
+		return err
+	}
+	if e.parameters.Exists("conversion_file") {
+		fname = e.parameters.GetResourcePath("conversion_file")
+		if err := unitDictionary.LoadConversions(fname); err != nil {
+			return err
+		}
+	}
+	units.Set(unitDictionary)
+
+	if e.parameters.Exists("grammar_file") {
+		fname = e.parameters.GetResourcePath("grammar_file")
+		grammar, err := parser.LoadCFGrammar(fname)
+		if err != nil {
+			return err
+		}
+		parser.Set(parser.NewInterpreterWithGrammar(grammar))
+	}
+
+	return nil
+}
+
+// Evaluate evaluates the criteria in the gold file and writes the report to the output file.
+func (e *Evaluator) Evaluate() error {
+	var report *eval.Report
+	var err error
+	if e.parameters.Get("mode") == "nel" {
+		report, err = e.evaluateConcepts()
+	} else {
+		report, err = e.evaluateRelations()
+	}
+	if err != nil {
+		return err
+	}
+
+	writer := fio.Writer(e.parameters.Get("output_file"))
+	defer writer.Close()
+	if err := report.Write(writer); err != nil {
+		return err
+	}
+
+	o := report.Overall
+	glog.Infof("Evaluated criteria: %d, Precision: %.3f, Recall: %.3f, F1: %.3f\n", report.Criteria, o.Precision(), o.Recall(), o.F1())
+	return nil
+}
+
+// evaluateRelations parses the gold criteria and compares the parsed relations to the gold relations.
+func (e *Evaluator) evaluateRelations() (*eval.Report, error) {
+	fname := e.parameters.Get("gold_file")
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gold, err := eval.ReadGoldCriteria(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	return eval.EvaluateRelations(gold, e.parameters.GetFloat64("min_score")), nil
+}
+
+// evaluateConcepts compares the concepts linked by the NEL matcher to the gold concepts.
+func (e *Evaluator) evaluateConcepts() (*eval.Report, error) {
+	fname := e.parameters.Get("gold_file")
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gold, err := eval.ReadGoldConcepts(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+
+	fname = e.parameters.Get("input_file")
+	input, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+	linked, err := eval.ReadLinkedConcepts(input)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	return eval.EvaluateConcepts(gold, linked), nil
+}
+
+// Close closes the evaluator.
+func (e *Evaluator) Close() {
+	glog.Info(e.clock.Elapsed())
+	glog.Flush()
+}
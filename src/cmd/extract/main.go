@@ -3,15 +3,13 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
-	"io"
 	"os"
-	"strings"
+	"runtime"
+	"strconv"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/conf"
-	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/fio"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/timer"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
@@ -26,9 +24,6 @@ func main() {
 	if err := p.LoadParameters(); err != nil {
 		glog.Fatal(err)
 	}
-	if err := p.Ingest(); err != nil {
-		glog.Fatal(err)
-	}
 	if err := p.Extract(); err != nil {
 		glog.Fatal(err)
 	}
@@ -38,7 +33,6 @@ func main() {
 // Extractor defines the struct for extracting inclusion and exclusion criteria.
 type Extractor struct {
 	parameters conf.Config
-	registry   studies.Studies
 	clock      timer.Timer
 }
 
@@ -49,88 +43,102 @@ func NewExtractor() *Extractor {
 
 // LoadParameters loads parameters from command line.
 func (p *Extractor) LoadParameters() error {
-	inputFname := flag.String("i", "", "Input file")
+	inputFname := flag.String("i", "", "Input file, directory, or zip archive")
+	inputFormat := flag.String("input_format", studies.CSV, "Input format: csv, json, xml, or aact")
+	studyType := flag.String("aact_study_type", "Interventional", "Study type of the AACT studies")
+	overallStatus := flag.String("aact_status", "Recruiting", "Overall status of the AACT studies")
+	conditions := flag.String("aact_conditions", "", "Pattern of the lowercase conditions of the AACT studies")
+	excludeConditions := flag.Bool("aact_exclude_conditions", false, "Exclude the AACT studies whose conditions match the pattern")
 	outputFname := flag.String("o", "", "Output file")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
 
 	flag.Parse()
+	if *workers < 1 {
+		return fmt.Errorf("workers must be positive: %d", *workers)
+	}
 	if len(*inputFname) == 0 || len(*outputFname) == 0 {
-		return fmt.Errorf("usage: %s -conf <config file> -i <input file> -o <output name>", os.Args[0])
+		return fmt.Errorf("usage: %s -conf <config file> -i <input file> -o <output name> [-input_format <csv|json|xml|aact>] [-workers <n>]", os.Args[0])
 	}
 
 	parameters := conf.New()
 	parameters.Put("input_file", *inputFname)
+	parameters.Put("input_format", *inputFormat)
+	parameters.Put("aact_study_type", *studyType)
+	parameters.Put("aact_status", *overallStatus)
+	parameters.Put("aact_conditions", *conditions)
+	parameters.Put("aact_exclude_conditions", strconv.FormatBool(*excludeConditions))
 	parameters.Put("output_file", *outputFname)
+	parameters.Put("workers", strconv.Itoa(*workers))
 	p.parameters = parameters
 
 	return nil
 }
 
-// Ingest ingests eligibility criteria from a file.
-func (p *Extractor) Ingest() error {
-	fname := p.parameters.Get("input_file")
-	f, err := os.Open(fname)
+// extraction defines the inclusion and exclusion criteria of a study.
+type extraction struct {
+	inclusions []string
+	exclusions []string
+}
+
+// Extract streams the eligibility criteria from the input, extracts inclusion and exclusion
+// criteria, and writes them to a file. The criteria are extracted concurrently and written
+// in the input order, one study at a time.
+func (p *Extractor) Extract() error {
+	reader, err := p.openInput()
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	registry := studies.New()
-	r := csv.NewReader(f)
-	r.Comment = rune(param.Comment)
+	defer reader.Close()
 
-	for {
-		line, err := r.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-		if len(line) < 5 {
-			return fmt.Errorf("too few columns, at least 4 needed: %v", line)
-		}
-		nctID := line[0]
-		title := line[1]
-		// Skip line[2]: has_us_facility
-		conditions := strings.Split(line[3], param.FieldSep)
-		eligibilityCriteria := line[4]
-
-		study := studies.NewStudy(nctID, title, conditions, eligibilityCriteria)
-		registry.Add(study)
-	}
-	glog.Infof("Ingested studies: %d\n", registry.Len())
-	p.registry = registry
-
-	return nil
-}
-
-// Extract extracts inclusion and exclusion criteria and writes them to a file.
-func (p *Extractor) Extract() error {
 	header := "#nct_id\teligibility_type\tcriterion\n"
-	fname := p.parameters.Get("output_file")
-	writer := fio.Writer(fname)
+	writer := fio.Writer(p.parameters.Get("output_file"))
 	defer writer.Close()
+	writer.WriteString(header)
 
+	studyCnt := 0
 	criteriaCnt := 0
-	writer.WriteString(header)
-	for _, study := range p.registry {
+	extract := func(study *studies.Study) interface{} {
 		inclusions, exclusions := study.Criteria()
-		for _, criterion := range inclusions {
+		return extraction{inclusions: inclusions, exclusions: exclusions}
+	}
+	write := func(study *studies.Study, result interface{}) error {
+		e := result.(extraction)
+		for _, criterion := range e.inclusions {
 			if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\n", study.NCT(), "inclusion", criterion); err != nil {
 				return err
 			}
 		}
-		for _, criterion := range exclusions {
+		for _, criterion := range e.exclusions {
 			if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\n", study.NCT(), "exclusion", criterion); err != nil {
 				return err
 			}
 		}
-		criteriaCnt += len(inclusions) + len(exclusions)
+		studyCnt++
+		criteriaCnt += len(e.inclusions) + len(e.exclusions)
+		return nil
 	}
-	glog.Infof("Ingested studies: %d, Extracted criteria: %d\n", p.registry.Len(), criteriaCnt)
+	if err := studies.Stream(reader, p.parameters.GetInt("workers"), extract, write); err != nil {
+		return err
+	}
+	glog.Infof("Ingested studies: %d, Extracted criteria: %d\n", studyCnt, criteriaCnt)
 	return nil
 }
 
+// openInput opens the input file, directory, or zip archive for reading studies.
+// AACT flat files are joined and filtered in memory.
+func (p *Extractor) openInput() (studies.ReadCloser, error) {
+	filter, err := studies.NewAACTFilter(
+		p.parameters.Get("aact_study_type"),
+		p.parameters.Get("aact_status"),
+		p.parameters.Get("aact_conditions"),
+		p.parameters.GetBool("aact_exclude_conditions"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return studies.OpenWithFilter(p.parameters.Get("input_file"), p.parameters.Get("input_format"), filter)
+}
+
 // Close closes the parser.
 func (p *Extractor) Close() {
 	glog.Info(p.clock.Elapsed())
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
@@ -17,10 +19,15 @@ import (
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/fio"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/slice"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/timer"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/assertion"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/eligibility"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/embedding"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/mesh"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
-	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/umls"
 
 	"github.com/golang/glog"
 )
@@ -31,15 +38,25 @@ var (
 )
 
 // main matches (grounds) extracted (input) terms to vocabulary concepts.
-// Matching results are written to a file. This version does not use clustering.
+// Matching results are written to a file. If word embeddings are set, the terms
+// that are not matched are clustered and matched by cluster.
 func main() {
 	m := NewMatcher()
 	if err := m.LoadParameters(); err != nil {
 		glog.Fatal(err)
 	}
+	if err := m.LoadUnits(); err != nil {
+		glog.Fatal(err)
+	}
+	if err := m.LoadAssertions(); err != nil {
+		glog.Fatal(err)
+	}
 	if err := m.LoadVocabulary(); err != nil {
 		glog.Fatal(err)
 	}
+	if err := m.LoadEmbeddings(); err != nil {
+		glog.Fatal(err)
+	}
 	if err := m.Match(); err != nil {
 		glog.Fatal(err)
 	}
@@ -92,6 +109,7 @@ type Matcher struct {
 	parameters conf.Config
 	vocabulary *taxonomy.Taxonomy
 	normalize  taxonomy.Normalizer
+	embeddings *embedding.Embeddings
 	clock      timer.Timer
 }
 
@@ -135,33 +153,55 @@ func (m *Matcher) LoadParameters() error {
 	return nil
 }
 
-func (m *Matcher) LoadVocabulary() error {
-	vocabularyFname := m.parameters.Get("vocabulary_file")
-	var customFnames []string
-	if m.parameters.Exists("custom_vocabulary_file") {
-		path := m.parameters.Get("custom_vocabulary_file")
-		customFnames = fio.ReadFnames(path)
+// LoadUnits loads the unit catalog for extracting the time windows of criteria.
+// The default catalog is used if the unit file is not set.
+func (m *Matcher) LoadUnits() error {
+	if !m.parameters.Exists("unit_file") {
+		return nil
 	}
+	fname := m.parameters.GetResourcePath("unit_file")
+	unitDictionary, err := units.Load(fname)
+	if err != nil {
+		return err
+	}
+	if m.parameters.Exists("conversion_file") {
+		fname = m.parameters.GetResourcePath("conversion_file")
+		if err := unitDictionary.LoadConversions(fname); err != nil {
+			return err
+		}
+	}
+	units.Set(unitDictionary)
+	return nil
+}
 
-	source := vocabularies.ParseSource(m.parameters.Get("vocabulary_source"))
-	var vocabulary *taxonomy.Taxonomy
-	switch source {
-	case vocabularies.MESH:
-		glog.Info("Loading MeSH ...")
-		vocabulary = mesh.Load(vocabularyFname, customFnames...)
-	case vocabularies.UMLS:
-		glog.Info("Loading UMLS ...")
-		vocabulary = umls.Load(vocabularyFname)
-	default:
-		return fmt.Errorf("unknown vocabulary source")
+// LoadAssertions loads the trigger lexicon for detecting the assertion status of the slots,
+// such as negated or historical. The default lexicon is used if the assertion file is not set.
+func (m *Matcher) LoadAssertions() error {
+	scope := assertion.DefaultScope
+	if m.parameters.Exists("assertion_scope") {
+		scope = m.parameters.GetInt("assertion_scope")
+	}
+	lexicon := assertion.DefaultLexicon()
+	if m.parameters.Exists("assertion_file") {
+		var err error
+		if lexicon, err = assertion.LoadLexicon(m.parameters.GetResourcePath("assertion_file")); err != nil {
+			return err
+		}
 	}
+	assertion.Set(assertion.NewDetector(lexicon, scope))
+	return nil
+}
 
-	rows := m.parameters.GetInt("lsh_rows")
-	bands := m.parameters.GetInt("lsh_rows")
+// LoadVocabulary loads the vocabulary, normalizes its synonyms, and indexes it for matching.
+// If snapshot_file is set, the indexed vocabulary is loaded from the snapshot when it is up to date.
+func (m *Matcher) LoadVocabulary() error {
+	vocabulary, err := vocabularies.Load(m.parameters, m.parameters.Get)
+	if err != nil {
+		return err
+	}
 
 	m.normalize = mesh.Normalize
-	vocabulary.Normalize(m.normalize)
-	vocabulary.SetHashIndex(rows, bands)
+	vocabulary.SetTreeIndex()
 	vocabulary.Info()
 
 	m.vocabulary = vocabulary
@@ -169,6 +209,26 @@ func (m *Matcher) LoadVocabulary() error {
 	return nil
 }
 
+// LoadEmbeddings loads the word vectors for clustering the terms that are not matched.
+// Clustering is disabled if the embedding file is not set.
+func (m *Matcher) LoadEmbeddings() error {
+	if !m.parameters.Exists("embedding_file") {
+		return nil
+	}
+	embeddings, err := embedding.Load(m.parameters.Get("embedding_file"))
+	if err != nil {
+		return err
+	}
+	if m.parameters.Exists("embedding_frequency_file") {
+		fname := m.parameters.Get("embedding_frequency_file")
+		if err := embeddings.LoadFrequencies(fname, m.parameters.GetFloat64("embedding_weight")); err != nil {
+			return err
+		}
+	}
+	m.embeddings = embeddings
+	return nil
+}
+
 // getNERSlots gets the extracted terms from a string.
 func getNERSlots(termStr string, nerThreshold float64, validLabels set.Set) Slots {
 	var data map[string]interface{}
@@ -205,6 +265,41 @@ func getNERSlots(termStr string, nerThreshold float64, validLabels set.Set) Slot
 	return slots
 }
 
+// getTemporals gets the time windows of the criterion, such as 'within the past 6 months',
+// as a json array. The windows apply to the concepts matched to the criterion.
+// It returns an empty string if the criterion has no time windows.
+func getTemporals(criterion string) string {
+	ts := parser.Get().Temporals(strings.ToLower(criterion))
+	if len(ts) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(ts)
+	if err != nil {
+		glog.Warningf("Failed to marshal time windows: %v\n", err)
+		return ""
+	}
+	return string(b)
+}
+
+// record defines an input line with the NER slots of a criterion.
+type record struct {
+	nctID           string
+	eligibilityType string
+	criterion       string
+	temporal        string
+	slots           Slots
+}
+
+// row defines an output line with the concepts matched to a slot, if any.
+type row struct {
+	key      string
+	concepts []string
+	line     string
+}
+
+// Match matches the NER slots to vocabulary concepts and writes the results to a file.
+// If word embeddings are set, the subterms that are not matched are clustered, and
+// the members of a cluster are matched to the consensus concept of the cluster.
 func (m *Matcher) Match() error {
 	nerThreshold := m.parameters.GetFloat64("ner_threshold")
 	validLabels := set.New(m.parameters.GetSlice("valid_labels", ",")...)
@@ -217,9 +312,17 @@ func (m *Matcher) Match() error {
 	slotCnt := 0
 	matchedSlotCnt := 0
 
+	// Categories of the concepts that cancer and gender terms can be matched to,
+	// such as MeSH tree number prefixes or UMLS semantic types and groups
 	defaultCategories := set.New()
 	cancerCategories := set.New("C")
+	if m.parameters.Exists("cancer_categories") {
+		cancerCategories = set.New(m.parameters.GetSlice("cancer_categories", ",")...)
+	}
 	personCategories := set.New("M")
+	if m.parameters.Exists("person_categories") {
+		personCategories = set.New(m.parameters.GetSlice("person_categories", ",")...)
+	}
 
 	fname := m.parameters.Get("input_file")
 	file, err := os.Open(fname)
@@ -231,15 +334,10 @@ func (m *Matcher) Match() error {
 	scanner := bufio.NewScanner(file)
 	lineCnt := 0
 
-	outputFname := m.parameters.Get("output_file")
-	writer := fio.Writer(outputFname)
-	defer writer.Close()
-
-	header := "#nct_id\teligibility_type\tcriterion\tlabel\tterm\tner_score\tconcepts\ttree_numbers\tnel_score\n"
-	writer.WriteString(header)
-
 	glog.Infof("Matching NER terms ...")
 
+	var records []record
+	unmatchedCnt := make(map[string]int)
 	for scanner.Scan() {
 		lineCnt++
 		line := scanner.Text()
@@ -249,17 +347,15 @@ func (m *Matcher) Match() error {
 
 		// Extract NER terms
 		values := strings.Split(line, "\t")
-		nctID := values[0]
-		eligibilityType := values[1]
-		criterion := values[2]
-		termStr := values[3]
-		slots := getNERSlots(termStr, nerThreshold, validLabels)
-		slotCnt += slots.Size()
+		r := record{nctID: values[0], eligibilityType: values[1], criterion: values[2]}
+		r.slots = getNERSlots(values[3], nerThreshold, validLabels)
+		r.temporal = getTemporals(r.criterion)
+		slotCnt += r.slots.Size()
+		records = append(records, r)
 
 		// Match NER terms to concepts
-		for _, slot := range slots {
-			subterms := slot.SubTerms()
-			for _, subterm := range subterms {
+		for _, slot := range r.slots {
+			for _, subterm := range slot.SubTerms() {
 				if _, ok := matchedSlots[subterm]; !ok {
 					var validCategories set.Set
 					switch slot.label {
@@ -272,35 +368,86 @@ func (m *Matcher) Match() error {
 					}
 					matchedSlots[subterm] = m.vocabulary.Match(subterm, matchMargin, validCategories)
 				}
+				if matchedSlots[subterm].MaxValue() < matchThreshold {
+					unmatchedCnt[subterm]++
+				}
 			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	clusters, clusterMatches := m.cluster(unmatchedCnt, matchedSlots)
+
+	outputFname := m.parameters.Get("output_file")
+	writer := fio.Writer(outputFname)
+	defer writer.Close()
+
+	var rows []row
+	for _, r := range records {
+		for _, slot := range r.slots {
+			status := assertion.Get().Detect(r.criterion, slot.term)
+			polarity := status.Polarity(eligibility.ParseType(r.eligibilityType))
+			key := r.nctID + "\t" + string(polarity)
+			subterms := slot.SubTerms()
 
 			slot.Normalize(m.normalize)
 			hasMatch := false
+			clusterID, clusterTopic := "", ""
 
 			for _, subterm := range subterms {
 				matchedConcepts := matchedSlots[subterm]
-				if matchedConcepts.MaxValue() >= matchThreshold {
+				matched := matchedConcepts.MaxValue() >= matchThreshold
+				id, topic := "", ""
+				if c, ok := clusters[subterm]; ok {
+					id, topic = strconv.Itoa(c.ClusterID), c.ClusterTopic
+					clusterID, clusterTopic = id, topic
+					if cm, ok := clusterMatches[c.ClusterID]; ok {
+						matchedConcepts = cm
+						matched = true
+					}
+				}
+				if matched {
 					hasMatch = true
 					conceptSet.Add(matchedConcepts.Keys()...)
 					concepts := strings.Join(matchedConcepts.Keys(), "|")
 					nelScore := matchedConcepts.MaxValue()
 					treeNumbers := strings.Join(matchedConcepts.TreeNumbers(), "|")
-					if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%.3f\n", nctID, eligibilityType, criterion, slot.String(), concepts, treeNumbers, nelScore); err != nil {
-						return err
-					}
+					conceptIDs := strings.Join(matchedConcepts.IDs(), "|")
+					line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%.3f\t%s\t%s\t%s\t%s\t%s\t%s\n", r.nctID, r.eligibilityType, r.criterion, slot.String(), concepts, treeNumbers, nelScore, r.temporal, status, polarity, id, topic, conceptIDs)
+					rows = append(rows, row{key: key, concepts: matchedConcepts.Keys(), line: line})
 				}
 			}
 
 			if hasMatch {
 				matchedSlotCnt++
 			} else {
-				if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", nctID, eligibilityType, criterion, slot.String()); err != nil {
-					return err
-				}
+				line := fmt.Sprintf("%s\t%s\t%s\t%s\t\t\t\t%s\t%s\t%s\t%s\t%s\t\n", r.nctID, r.eligibilityType, r.criterion, slot.String(), r.temporal, status, polarity, clusterID, clusterTopic)
+				rows = append(rows, row{key: key, line: line})
 			}
 		}
 	}
 
+	removed := make([]bool, len(rows))
+	if m.parameters.Exists("remove_general_concepts") && m.parameters.GetBool("remove_general_concepts") {
+		removed = m.removeGeneral(rows)
+	}
+
+	// The polarity is required or forbidden by the criterion, or other for hypothetical and family history terms,
+	// which are not asserted of the patient.
+	header := "#nct_id\teligibility_type\tcriterion\tlabel\tterm\tner_score\tconcepts\ttree_numbers\tnel_score\ttemporal\tassertion\tpolarity\tcluster_id\tcluster_topic\tconcept_ids\n"
+	writer.WriteString(header)
+
+	for i, r := range rows {
+		if removed[i] {
+			continue
+		}
+		if _, err := writer.WriteString(r.line); err != nil {
+			return err
+		}
+	}
+
 	glog.Infof("Lines read: %d, Slots: %d, Unique slots: %d\n", lineCnt, slotCnt, len(matchedSlots))
 	glog.Infof("%d slots matched to %d concepts\n", matchedSlotCnt, conceptSet.Size())
 	glog.Infof("%d slots not matched\n", slotCnt-matchedSlotCnt)
@@ -308,6 +455,81 @@ func (m *Matcher) Match() error {
 	return nil
 }
 
+// cluster clusters the unmatched subterms, most frequent first, if word embeddings are set.
+// It returns the clustered subterms and the consensus matches of the clusters that have
+// at least min_cluster_size members and a consensus score of at least cluster_match_threshold.
+func (m *Matcher) cluster(unmatchedCnt map[string]int, matchedSlots map[string]taxonomy.Terms) (map[string]*criteria.Criterion, map[int]taxonomy.Terms) {
+	clusters := make(map[string]*criteria.Criterion)
+	clusterMatches := make(map[int]taxonomy.Terms)
+	if m.embeddings == nil || len(unmatchedCnt) == 0 {
+		return clusters, clusterMatches
+	}
+
+	subterms := make([]string, 0, len(unmatchedCnt))
+	for subterm := range unmatchedCnt {
+		subterms = append(subterms, subterm)
+	}
+	sort.Slice(subterms, func(i, j int) bool {
+		if unmatchedCnt[subterms[i]] != unmatchedCnt[subterms[j]] {
+			return unmatchedCnt[subterms[i]] > unmatchedCnt[subterms[j]]
+		}
+		return subterms[i] < subterms[j]
+	})
+	cs := criteria.NewCriteria()
+	for _, subterm := range subterms {
+		cs = append(cs, criteria.NewCriterion(subterm, 0, nil))
+	}
+	clusterCnt := m.embeddings.Cluster(cs, m.parameters.GetFloat64("cluster_threshold"))
+
+	members := make(map[int][]taxonomy.Terms)
+	for _, c := range cs {
+		if c.ClusterID > 0 {
+			clusters[c.String()] = c
+			members[c.ClusterID] = append(members[c.ClusterID], matchedSlots[c.String()])
+		}
+	}
+	minSize := m.parameters.GetInt("min_cluster_size")
+	threshold := m.parameters.GetFloat64("cluster_match_threshold")
+	for id, matches := range members {
+		if len(matches) < minSize {
+			continue
+		}
+		if consensus := taxonomy.Consensus(matches); consensus.MaxValue() >= threshold {
+			clusterMatches[id] = consensus
+		}
+	}
+	glog.Infof("%d unmatched subterms in %d clusters, %d clusters matched\n", len(clusters), clusterCnt, len(clusterMatches))
+
+	return clusters, clusterMatches
+}
+
+// removeGeneral marks the rows whose concepts are generalizations of the concepts of another row
+// with the same trial and polarity, such as 'Neoplasms' when 'Brain Neoplasms' is also required.
+// The generalizations are found by the tree numbers of the vocabulary hierarchy.
+func (m *Matcher) removeGeneral(rows []row) []bool {
+	groups := make(map[string][]int)
+	for i, r := range rows {
+		if len(r.concepts) > 0 {
+			groups[r.key] = append(groups[r.key], i)
+		}
+	}
+	removed := make([]bool, len(rows))
+	removedCnt := 0
+	for _, group := range groups {
+		for _, i := range group {
+			for _, j := range group {
+				if i != j && m.vocabulary.Generalizes(rows[i].concepts, rows[j].concepts) {
+					removed[i] = true
+					removedCnt++
+					break
+				}
+			}
+		}
+	}
+	glog.Infof("%d generalized concepts removed\n", removedCnt)
+	return removed
+}
+
 // Close closes the matcher.
 func (m *Matcher) Close() {
 	glog.Info(m.clock.Elapsed())
@@ -0,0 +1,142 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/conf"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/fio"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/timer"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/ner"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies"
+
+	"github.com/golang/glog"
+)
+
+// main extracts medical terms from criteria by looking up vocabulary synonyms.
+// It is a dictionary-based alternative to the NER model of src/ie/ner.py:
+// the output has the same slot JSON column, so it can be input to cmd/nel.
+func main() {
+	s := NewSpotter()
+	if err := s.LoadParameters(); err != nil {
+		glog.Fatal(err)
+	}
+	if err := s.LoadVocabulary(); err != nil {
+		glog.Fatal(err)
+	}
+	if err := s.Spot(); err != nil {
+		glog.Fatal(err)
+	}
+	s.Close()
+}
+
+// Spotter defines the struct that finds vocabulary terms in criteria.
+type Spotter struct {
+	parameters conf.Config
+	spotter    *ner.Spotter
+	clock      timer.Timer
+}
+
+// NewSpotter creates a new spotter.
+func NewSpotter() *Spotter {
+	return &Spotter{clock: timer.New()}
+}
+
+// LoadParameters loads parameters from command line and a config file.
+func (s *Spotter) LoadParameters() error {
+	configFname := flag.String("conf", "", "Config file")
+	inputFname := flag.String("i", "", "Input file of extracted criteria")
+	outputFname := flag.String("o", "", "Output file")
+
+	flag.Parse()
+	if len(*configFname) == 0 || len(*inputFname) == 0 || len(*outputFname) == 0 {
+		return fmt.Errorf("usage: %s -conf <config file> -i <input file> -o <output file>", os.Args[0])
+	}
+
+	parameters, err := conf.Load(*configFname)
+	if err != nil {
+		return err
+	}
+	parameters.Put("input_file", *inputFname)
+	parameters.Put("output_file", *outputFname)
+	s.parameters = parameters
+
+	return nil
+}
+
+// LoadVocabulary loads the vocabulary and creates the dictionary of its synonyms.
+func (s *Spotter) LoadVocabulary() error {
+	labels, err := ner.ParseLabels(s.parameters.Get("ner_labels"))
+	if err != nil {
+		return err
+	}
+
+	vocabulary, err := vocabularies.Build(s.parameters, s.parameters.Get)
+	if err != nil {
+		return err
+	}
+
+	s.spotter = ner.NewSpotter(vocabulary, labels, s.parameters.GetInt("ner_min_length"), s.parameters.GetFloat64("ner_score"))
+	glog.Infof("Dictionary phrases: %d\n", s.spotter.Size())
+
+	return nil
+}
+
+// Spot reads the criteria from the output of cmd/extract and writes them with the slots found.
+func (s *Spotter) Spot() error {
+	fname := s.parameters.Get("input_file")
+	file, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := fio.Writer(s.parameters.Get("output_file"))
+	defer writer.Close()
+
+	header := "#nct_id\teligibility_type\tcriterion\tdetected_slots\n"
+	writer.WriteString(header)
+
+	glog.Infof("Spotting terms ...")
+
+	scanner := bufio.NewScanner(file)
+	lineCnt := 0
+	criteriaCnt := 0
+	mentionCnt := 0
+	for scanner.Scan() {
+		lineCnt++
+		line := scanner.Text()
+		if len(line) == 0 || line[0] == param.Comment {
+			continue
+		}
+		values := strings.Split(line, "\t")
+		if len(values) != 3 {
+			glog.Warningf("%s: bad row: line %d: %q\n", fname, lineCnt, line)
+			continue
+		}
+		mentions := s.spotter.Spot(values[2])
+		criteriaCnt++
+		mentionCnt += len(mentions)
+		if _, err := fmt.Fprintf(writer, "%s\t%s\n", line, mentions.Slots()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	glog.Infof("Criteria: %d, Slots: %d\n", criteriaCnt, mentionCnt)
+	return nil
+}
+
+// Close closes the spotter.
+func (s *Spotter) Close() {
+	glog.Info(s.clock.Elapsed())
+	glog.Flush()
+}
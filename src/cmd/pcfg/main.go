@@ -0,0 +1,116 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/conf"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/fio"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/timer"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser/production"
+
+	"github.com/golang/glog"
+)
+
+// main estimates the probabilities of the CFG production rules from a treebank
+// of hand-parsed eligibility criteria. The output is a grammar file with rule weights
+// that can be set as the grammar_file of the CFG parser.
+func main() {
+	e := NewEstimator()
+	if err := e.LoadParameters(); err != nil {
+		glog.Fatal(err)
+	}
+	if err := e.Initialize(); err != nil {
+		glog.Fatal(err)
+	}
+	if err := e.Estimate(); err != nil {
+		glog.Fatal(err)
+	}
+	e.Close()
+}
+
+// Estimator defines the struct for estimating the rule probabilities.
+type Estimator struct {
+	parameters conf.Config
+	rules      *parser.Rules
+	clock      timer.Timer
+}
+
+// NewEstimator creates a new estimator of rule probabilities.
+func NewEstimator() *Estimator {
+	return &Estimator{clock: timer.New()}
+}
+
+// LoadParameters loads parameters from command line and a config file.
+func (e *Estimator) LoadParameters() error {
+	configFname := flag.String("conf", "", "Config file")
+	inputFname := flag.String("i", "", "Treebank file")
+	outputFname := flag.String("o", "", "Output grammar file")
+	smoothing := flag.Float64("smoothing", 1, "Add-λ smoothing of rule counts")
+
+	flag.Parse()
+	if len(*configFname) == 0 || len(*inputFname) == 0 || len(*outputFname) == 0 {
+		return fmt.Errorf("usage: %s -conf <config file> -i <treebank file> -o <output grammar file> [-smoothing <λ>]", os.Args[0])
+	}
+
+	parameters, err := conf.Load(*configFname)
+	if err != nil {
+		return err
+	}
+	parameters.Put("input_file", *inputFname)
+	parameters.Put("output_file", *outputFname)
+	parameters.Put("smoothing", fmt.Sprintf("%g", *smoothing))
+	e.parameters = parameters
+
+	return nil
+}
+
+// Initialize initializes the estimator by loading the base grammar. The rules of the base
+// grammar define the rules that the treebank trees may use.
+func (e *Estimator) Initialize() error {
+	if !e.parameters.Exists("grammar_file") {
+		e.rules = parser.LoadRules(production.CriterionRules)
+		return nil
+	}
+	fname := e.parameters.GetResourcePath("grammar_file")
+	grammar, err := parser.LoadCFGrammar(fname)
+	if err != nil {
+		return err
+	}
+	e.rules = grammar.Rules()
+	return nil
+}
+
+// Estimate estimates the rule probabilities from the treebank and writes the grammar to a file.
+func (e *Estimator) Estimate() error {
+	fname := e.parameters.Get("input_file")
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	smoothing := e.parameters.GetFloat64("smoothing")
+	rules, err := e.rules.Estimate(f, smoothing)
+	if err != nil {
+		return fmt.Errorf("%s:\n%v", fname, err)
+	}
+
+	writer := fio.Writer(e.parameters.Get("output_file"))
+	defer writer.Close()
+	fmt.Fprintf(writer, "# Rule probabilities estimated from %s with add-%g smoothing.\n\n", fname, smoothing)
+	writer.WriteString(rules.String())
+	glog.Infof("Estimated rule probabilities from %s\n", fname)
+
+	return nil
+}
+
+// Close closes the estimator.
+func (e *Estimator) Close() {
+	glog.Info(e.clock.Elapsed())
+	glog.Flush()
+}
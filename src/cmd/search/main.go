@@ -11,11 +11,8 @@ import (
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/conf"
-	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/fio"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies"
-	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/mesh"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
-	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/umls"
 
 	"github.com/golang/glog"
 )
@@ -65,33 +62,12 @@ func (m *Matcher) LoadParameters() error {
 	return nil
 }
 
-// LoadVocabulary loads a vocabulary from a file.
+// LoadVocabulary loads a vocabulary from a file, or from the snapshot_file if it is up to date.
 func (m *Matcher) LoadVocabulary() error {
-	vocabularyFname := m.parameters.GetDataPath("vocabulary_file")
-	var customFnames []string
-	if m.parameters.Exists("custom_vocabulary_file") {
-		path := m.parameters.GetDataPath("custom_vocabulary_file")
-		customFnames = fio.ReadFnames(path)
-	}
-
-	source := vocabularies.ParseSource(m.parameters.Get("vocabulary_source"))
-	var vocabulary *taxonomy.Taxonomy
-	switch source {
-	case vocabularies.MESH:
-		glog.Info("Loading MeSH ...")
-		vocabulary = mesh.Load(vocabularyFname, customFnames...)
-	case vocabularies.UMLS:
-		glog.Info("Loading UMLS ...")
-		vocabulary = umls.Load(vocabularyFname)
-	default:
-		return fmt.Errorf("unknown vocabulary source")
+	vocabulary, err := vocabularies.Load(m.parameters, m.parameters.GetDataPath)
+	if err != nil {
+		return err
 	}
-
-	rows := m.parameters.GetInt("lsh_rows")
-	bands := m.parameters.GetInt("lsh_rows")
-
-	vocabulary.Normalize(mesh.Normalize)
-	vocabulary.SetHashIndex(rows, bands)
 	vocabulary.Info()
 
 	m.vocabulary = vocabulary
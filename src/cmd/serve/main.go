@@ -0,0 +1,406 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/conf"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/timer"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
+
+	"github.com/golang/glog"
+)
+
+// maxRequestSize is the maximum size of a request body in bytes.
+const maxRequestSize = 1 << 20
+
+// main runs an HTTP server that extracts eligibility criteria, parses them to relations
+// with the CFG parser, and matches terms to vocabulary concepts. The catalogs and the
+// vocabulary are loaded once at startup, after which the server reports ready.
+func main() {
+	s := NewServer()
+	if err := s.LoadParameters(); err != nil {
+		glog.Fatal(err)
+	}
+	if err := s.Serve(); err != nil {
+		glog.Fatal(err)
+	}
+	s.Close()
+}
+
+// Server defines the struct for serving the parser over HTTP.
+type Server struct {
+	parameters  conf.Config
+	vocabulary  *taxonomy.Taxonomy
+	matchMargin float64 // Default margin of the match endpoint
+	ready       int32   // Set to 1 when the resources are loaded
+	clock       timer.Timer
+}
+
+// NewServer creates a new server.
+func NewServer() *Server {
+	return &Server{clock: timer.New()}
+}
+
+// LoadParameters loads parameters from command line and a config file.
+func (s *Server) LoadParameters() error {
+	configFname := flag.String("conf", "", "Config file")
+	addr := flag.String("addr", ":8080", "Address to listen on")
+	timeout := flag.Duration("timeout", 10*time.Second, "Timeout of a request")
+
+	flag.Parse()
+	if len(*configFname) == 0 {
+		return fmt.Errorf("usage: %s -conf <config file> [-addr <host:port>] [-timeout <duration>]", os.Args[0])
+	}
+	if *timeout <= 0 {
+		return fmt.Errorf("timeout must be positive: %v", *timeout)
+	}
+
+	parameters, err := conf.Load(*configFname)
+	if err != nil {
+		return err
+	}
+	parameters.Put("addr", *addr)
+	parameters.Put("timeout", timeout.String())
+	s.parameters = parameters
+
+	return nil
+}
+
+// Initialize loads the variable and unit catalogs, the grammar, and, if configured, the vocabulary.
+func (s *Server) Initialize() error {
+	fname := s.parameters.GetResourcePath("variable_file")
+	variableDictionary, err := variables.Load(fname)
+	if err != nil {
+		return err
+	}
+	variables.Set(variableDictionary)
+
+	fname = s.parameters.GetResourcePath("unit_file")
+	unitDictionary, err := units.Load(fname)
+	if err != nil {
+		return err
+	}
+	if s.parameters.Exists("conversion_file") {
+		fname = s.parameters.GetResourcePath("conversion_file")
+		if err := unitDictionary.LoadConversions(fname); err != nil {
+			return err
+		}
+	}
+	units.Set(unitDictionary)
+
+	if s.parameters.Exists("grammar_file") {
+		fname = s.parameters.GetResourcePath("grammar_file")
+		grammar, err := parser.LoadCFGrammar(fname)
+		if err != nil {
+			return err
+		}
+		parser.Set(parser.NewInterpreterWithGrammar(grammar))
+	}
+
+	if s.parameters.Exists("vocabulary_file") {
+		return s.loadVocabulary()
+	}
+	glog.Info("No vocabulary_file set, concept matching is disabled")
+	return nil
+}
+
+// loadVocabulary loads the vocabulary and indexes it for matching, or loads it from the snapshot_file if it is up to date.
+func (s *Server) loadVocabulary() error {
+	vocabulary, err := vocabularies.Load(s.parameters, s.parameters.GetDataPath)
+	if err != nil {
+		return err
+	}
+	vocabulary.Info()
+
+	s.vocabulary = vocabulary
+	s.matchMargin = s.parameters.GetFloat64("match_margin")
+	return nil
+}
+
+// Serve loads the resources in the background and serves requests until the server
+// receives SIGINT or SIGTERM, after which it finishes the pending requests.
+func (s *Server) Serve() error {
+	timeout, err := time.ParseDuration(s.parameters.Get("timeout"))
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Addr:              s.parameters.Get("addr"),
+		Handler:           s.Handler(timeout),
+		ReadHeaderTimeout: timeout,
+		ReadTimeout:       timeout,
+		WriteTimeout:      2 * timeout,
+	}
+
+	go func() {
+		if err := s.Initialize(); err != nil {
+			glog.Fatal(err)
+		}
+		atomic.StoreInt32(&s.ready, 1)
+		glog.Infof("Ready to serve on %s\n", server.Addr)
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		ctx, cancel := context.WithTimeout(context.Background(), 2*timeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			glog.Warningf("Failed to shut down: %v\n", err)
+		}
+	}()
+
+	glog.Infof("Listening on %s\n", server.Addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	<-done
+	return nil
+}
+
+// Close closes the server.
+func (s *Server) Close() {
+	glog.Info(s.clock.Elapsed())
+	glog.Flush()
+}
+
+// Handler returns the handler of the HTTP endpoints. The API endpoints respond with
+// 503 Service Unavailable until the resources are loaded or if a request takes longer
+// than the timeout.
+func (s *Server) Handler(timeout time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.health)
+	mux.HandleFunc("/readyz", s.readiness)
+	mux.Handle("/criteria", s.api(s.criteria, timeout))
+	mux.Handle("/parse", s.api(s.parse, timeout))
+	mux.Handle("/match", s.api(s.match, timeout))
+	return mux
+}
+
+// api wraps the handler of a POST endpoint with the readiness check, the request size limit, and the timeout.
+func (s *Server) api(h http.HandlerFunc, timeout time.Duration) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed: %s", r.Method)
+			return
+		}
+		if atomic.LoadInt32(&s.ready) == 0 {
+			writeError(w, http.StatusServiceUnavailable, "server is loading resources")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+		h(w, r)
+	}
+	th := http.TimeoutHandler(http.HandlerFunc(f), timeout, `{"error":"request timed out"}`)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The timeout handler writes its body without the headers set by the wrapped handler.
+		w.Header().Set("Content-Type", "application/json")
+		th.ServeHTTP(w, r)
+	})
+}
+
+// health responds OK while the server is running.
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readiness responds OK when the resources are loaded and the server accepts API requests.
+func (s *Server) readiness(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&s.ready) == 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "loading"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// criteriaRequest defines the request of the criteria endpoint.
+type criteriaRequest struct {
+	EligibilityCriteria string `json:"eligibility_criteria"`
+}
+
+// criteriaResponse defines the response of the criteria endpoint.
+type criteriaResponse struct {
+	Inclusion []string `json:"inclusion"`
+	Exclusion []string `json:"exclusion"`
+}
+
+// criteria splits the eligibility criteria text to inclusion and exclusion criteria.
+func (s *Server) criteria(w http.ResponseWriter, r *http.Request) {
+	var req criteriaRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if len(req.EligibilityCriteria) == 0 {
+		writeError(w, http.StatusBadRequest, "missing eligibility_criteria")
+		return
+	}
+	inclusions, exclusions := studies.NewStudy("", "", nil, req.EligibilityCriteria).Criteria()
+	res := criteriaResponse{Inclusion: inclusions, Exclusion: exclusions}
+	if res.Inclusion == nil {
+		res.Inclusion = []string{}
+	}
+	if res.Exclusion == nil {
+		res.Exclusion = []string{}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// parseRequest defines the request of the parse endpoint. Either a single criterion
+// or the eligibility criteria of a study is parsed.
+type parseRequest struct {
+	Criterion           string   `json:"criterion"`
+	Exclusion           bool     `json:"exclusion"`
+	NCT                 string   `json:"nct_id"`
+	Title               string   `json:"title"`
+	Conditions          []string `json:"conditions"`
+	EligibilityCriteria string   `json:"eligibility_criteria"`
+}
+
+// parseResponse defines the response of the parse endpoint for a single criterion.
+type parseResponse struct {
+	Criteria criteria.Criteria `json:"criteria"`
+}
+
+// parse parses a criterion or the eligibility criteria of a study to relations. The response to
+// a study is in the jsonl format of cmd/cfg.
+func (s *Server) parse(w http.ResponseWriter, r *http.Request) {
+	var req parseRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	switch {
+	case len(req.Criterion) > 0 && len(req.EligibilityCriteria) > 0:
+		writeError(w, http.StatusBadRequest, "set either criterion or eligibility_criteria, not both")
+	case len(req.Criterion) > 0:
+		writeJSON(w, http.StatusOK, parseResponse{Criteria: studies.ParseCriterion(req.Criterion, req.Exclusion)})
+	case len(req.EligibilityCriteria) > 0:
+		study := studies.NewStudy(req.NCT, req.Title, req.Conditions, req.EligibilityCriteria).Parse()
+		w.Header().Set("Content-Type", "application/json")
+		if err := study.WriteJSON(w); err != nil {
+			glog.Warningf("Failed to write response: %v\n", err)
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "missing criterion or eligibility_criteria")
+	}
+}
+
+// matchRequest defines the request of the match endpoint. Matched concepts are filtered by
+// the categories, such as 'C' for MeSH diseases, if any. Concepts whose score is within
+// the margin of the best score are returned; the margin defaults to match_margin of the config.
+type matchRequest struct {
+	Terms      []string `json:"terms"`
+	Categories []string `json:"categories"`
+	Margin     *float64 `json:"margin"`
+}
+
+// concept defines a matched vocabulary concept.
+type concept struct {
+	Name        string   `json:"name"`
+	Score       float64  `json:"score"`
+	Categories  []string `json:"categories"`
+	TreeNumbers []string `json:"tree_numbers"`
+}
+
+// termMatch defines the concepts matched to a term.
+type termMatch struct {
+	Term       string    `json:"term"`
+	Normalized string    `json:"normalized"`
+	Concepts   []concept `json:"concepts"`
+}
+
+// matchResponse defines the response of the match endpoint.
+type matchResponse struct {
+	Matches []termMatch `json:"matches"`
+}
+
+// match matches terms to vocabulary concepts.
+func (s *Server) match(w http.ResponseWriter, r *http.Request) {
+	if s.vocabulary == nil {
+		writeError(w, http.StatusServiceUnavailable, "no vocabulary is loaded")
+		return
+	}
+	var req matchRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if len(req.Terms) == 0 {
+		writeError(w, http.StatusBadRequest, "missing terms")
+		return
+	}
+	margin := s.matchMargin
+	if req.Margin != nil {
+		margin = *req.Margin
+	}
+	if margin < 0 {
+		writeError(w, http.StatusBadRequest, "negative margin: %v", margin)
+		return
+	}
+	filter := set.New(req.Categories...)
+
+	res := matchResponse{Matches: make([]termMatch, len(req.Terms))}
+	for i, term := range req.Terms {
+		terms := s.vocabulary.Match(term, margin, filter)
+		m := termMatch{Term: term, Normalized: terms.Normalized(), Concepts: []concept{}}
+		for _, t := range terms {
+			if t.Value == 0 {
+				continue
+			}
+			m.Concepts = append(m.Concepts, concept{
+				Name:        t.Key,
+				Score:       t.Value,
+				Categories:  t.Categories.Slice(),
+				TreeNumbers: t.TreeNumbers.Slice(),
+			})
+		}
+		res.Matches[i] = m
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// readJSON decodes the request body to v. It responds with 400 Bad Request
+// and returns false if the body is not valid JSON.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request: %v", err)
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as the JSON response with the status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		glog.Warningf("Failed to write response: %v\n", err)
+	}
+}
+
+// writeError writes the error message as the JSON response with the status code.
+func writeError(w http.ResponseWriter, status int, format string, a ...interface{}) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, a...)})
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/mesh"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func post(h http.Handler, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	return w
+}
+
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestReadiness(t *testing.T) {
+	a := assert.New(t)
+
+	s := NewServer()
+	h := s.Handler(time.Second)
+	a.Equal(http.StatusOK, get(h, "/healthz").Code)
+	a.Equal(http.StatusServiceUnavailable, get(h, "/readyz").Code)
+	a.Equal(http.StatusServiceUnavailable, post(h, "/parse", `{"criterion":"BMI < 30"}`).Code)
+
+	atomic.StoreInt32(&s.ready, 1)
+	a.Equal(http.StatusOK, get(h, "/readyz").Code)
+	a.Equal(http.StatusMethodNotAllowed, get(h, "/parse").Code)
+	a.Equal(http.StatusServiceUnavailable, post(h, "/match", `{"terms":["diabetes"]}`).Code)
+}
+
+func TestEndpoints(t *testing.T) {
+	a := assert.New(t)
+
+	s := NewServer()
+	atomic.StoreInt32(&s.ready, 1)
+	h := s.Handler(time.Second)
+
+	eligibility := `Inclusion Criteria:\n\n            BMI ≥ 25 kg/m2.\n\n            Exclusion Criteria:\n\n            Weigh more than 180 pounds.`
+	w := post(h, "/criteria", `{"eligibility_criteria":"`+eligibility+`"}`)
+	a.Equal(http.StatusOK, w.Code)
+	a.JSONEq(`{"inclusion":["BMI ≥ 25 kg/m2"],"exclusion":["Weigh more than 180 pounds"]}`, w.Body.String())
+
+	w = post(h, "/parse", `{"criterion":"BMI < 30 or HbA1c > 7%","exclusion":true}`)
+	a.Equal(http.StatusOK, w.Code)
+	var res struct {
+		Criteria []struct {
+			Text      string `json:"text"`
+			Relations []struct {
+				Name  string `json:"name"`
+				Lower *struct {
+					Value string `json:"value"`
+				} `json:"lower"`
+			} `json:"relations"`
+		} `json:"criteria"`
+	}
+	a.NoError(json.Unmarshal(w.Body.Bytes(), &res))
+	a.Len(res.Criteria, 2)
+	a.Equal("bmi", res.Criteria[0].Relations[0].Name)
+	a.Equal("30", res.Criteria[0].Relations[0].Lower.Value)
+
+	w = post(h, "/parse", `{"nct_id":"NCT00000001","title":"Better Health","eligibility_criteria":"`+eligibility+`"}`)
+	a.Equal(http.StatusOK, w.Code)
+	a.Contains(w.Body.String(), `"nct_id":"NCT00000001"`)
+	a.Contains(w.Body.String(), `"name":"bmi"`)
+
+	a.Equal(http.StatusBadRequest, post(h, "/parse", `{}`).Code)
+	a.Equal(http.StatusBadRequest, post(h, "/parse", `{"criterion":"BMI < 30","eligibility_criteria":"BMI < 30"}`).Code)
+	a.Equal(http.StatusBadRequest, post(h, "/parse", `{"text":"BMI < 30"}`).Code)
+	a.Equal(http.StatusBadRequest, post(h, "/criteria", `not json`).Code)
+}
+
+func TestMatch(t *testing.T) {
+	a := assert.New(t)
+
+	descriptor := taxonomy.NewNode("Diabetes Mellitus, Type 2")
+	c := taxonomy.NewNode("Diabetes Mellitus, Type 2")
+	c.AddSynonym("type 2 diabetes mellitus", "diabetes mellitus type 2")
+	c.AddTreeNumber("C18.452.394.750.149", "C19.246.300")
+	descriptor.AddChild(c)
+	vocabulary := taxonomy.New(taxonomy.NewNode("root"))
+	vocabulary.AddNode(descriptor)
+	vocabulary.Normalize(mesh.Normalize)
+	vocabulary.SetBaseIndex()
+
+	s := NewServer()
+	s.vocabulary = vocabulary
+	s.matchMargin = 0.02
+	atomic.StoreInt32(&s.ready, 1)
+	h := s.Handler(time.Second)
+
+	w := post(h, "/match", `{"terms":["type 2 diabetes mellitus","fracture"],"categories":["C"]}`)
+	a.Equal(http.StatusOK, w.Code)
+	var res matchResponse
+	a.NoError(json.Unmarshal(w.Body.Bytes(), &res))
+	a.Len(res.Matches, 2)
+	a.Len(res.Matches[0].Concepts, 1)
+	a.Equal("Diabetes Mellitus, Type 2", res.Matches[0].Concepts[0].Name)
+	a.Equal([]string{"C"}, res.Matches[0].Concepts[0].Categories)
+	a.Empty(res.Matches[1].Concepts)
+
+	a.Equal(http.StatusBadRequest, post(h, "/match", `{"terms":[]}`).Code)
+	a.Equal(http.StatusBadRequest, post(h, "/match", `{"terms":["fracture"],"margin":-1}`).Code)
+}
+
+func TestTimeout(t *testing.T) {
+	a := assert.New(t)
+
+	s := NewServer()
+	atomic.StoreInt32(&s.ready, 1)
+	h := s.api(func(w http.ResponseWriter, r *http.Request) { time.Sleep(100 * time.Millisecond) }, time.Millisecond)
+
+	w := post(h, "/parse", `{}`)
+	a.Equal(http.StatusServiceUnavailable, w.Code)
+	a.Equal("application/json", w.Header().Get("Content-Type"))
+	a.JSONEq(`{"error":"request timed out"}`, w.Body.String())
+}
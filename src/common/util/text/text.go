@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/slice"
 
@@ -60,6 +61,17 @@ func NormalizeText(s string) string {
 	return norm
 }
 
+// ToLowerSameWidth maps the string to lower case. Runes whose lower case has a different
+// byte width are left unchanged so that byte offsets in the result refer to the input string.
+func ToLowerSameWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		if l := unicode.ToLower(r); utf8.RuneLen(l) == utf8.RuneLen(r) {
+			return l
+		}
+		return r
+	}, s)
+}
+
 func SplitWhitespace(s string) []string {
 	return reWhitespace.Split(strings.TrimSpace(s), -1)
 }
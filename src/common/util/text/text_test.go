@@ -53,3 +53,12 @@ func TestCustomizeSlash(t *testing.T) {
 	output := CustomizeSlash(input)
 	a.Equal(expected, output)
 }
+
+func TestToLowerSameWidth(t *testing.T) {
+	a := assert.New(t)
+	input := "BMI ≥ 25 KG/M² K"
+	expected := "bmi ≥ 25 kg/m² K"
+	output := ToLowerSameWidth(input)
+	a.Equal(expected, output)
+	a.Equal(len(input), len(output))
+}
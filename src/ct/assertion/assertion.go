@@ -0,0 +1,210 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package assertion
+
+import (
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/eligibility"
+)
+
+// DefaultScope is the maximum number of tokens that a trigger scope extends.
+const DefaultScope = 8
+
+var detector *Detector
+
+func init() {
+	detector = NewDetector(DefaultLexicon(), DefaultScope)
+}
+
+// Set sets the detector to assign assertion statuses to terms.
+// It must be called before the detector is used by concurrent goroutines.
+func Set(d *Detector) {
+	detector = d
+}
+
+// Get gets the detector to assign assertion statuses to terms.
+func Get() *Detector {
+	return detector
+}
+
+// Polarity defines whether a concept is required or forbidden by a criterion.
+type Polarity string
+
+const (
+	Required  Polarity = "required"
+	Forbidden Polarity = "forbidden"
+	Other     Polarity = "other" // The concept is not asserted of the patient
+)
+
+// Assertion defines the assertion status of a term in a criterion.
+// A term without any status is present, that is, asserted of the patient.
+type Assertion struct {
+	Negated      bool
+	Historical   bool
+	Hypothetical bool
+	Family       bool
+}
+
+// Present returns true if the term is asserted without qualifiers.
+func (a Assertion) Present() bool {
+	return !a.Negated && !a.Historical && !a.Hypothetical && !a.Family
+}
+
+// Polarity returns the polarity of the term in a criterion of the eligibility type.
+// A hypothetical term or a term of a relative, such as 'family history of breast cancer',
+// is not asserted of the patient and has the polarity other. A negated term of an inclusion
+// criterion or a non-negated term of an exclusion criterion is forbidden, and otherwise
+// the term is required.
+func (a Assertion) Polarity(t eligibility.Type) Polarity {
+	if a.Hypothetical || a.Family {
+		return Other
+	}
+	if a.Negated != (t == eligibility.Exclusion) {
+		return Forbidden
+	}
+	return Required
+}
+
+// String returns the statuses of the assertion joined by '|', or 'present' if there are none.
+func (a Assertion) String() string {
+	if a.Present() {
+		return "present"
+	}
+	var s []string
+	if a.Negated {
+		s = append(s, "negated")
+	}
+	if a.Historical {
+		s = append(s, "historical")
+	}
+	if a.Hypothetical {
+		s = append(s, "hypothetical")
+	}
+	if a.Family {
+		s = append(s, "family")
+	}
+	return strings.Join(s, "|")
+}
+
+// Detector assigns assertion statuses to terms of criteria with trigger phrases in the manner of
+// NegEx and ConText. A trigger applies to the terms in its scope, which extends forward or backward
+// from the trigger until a termination phrase, clause punctuation, or the maximum number of tokens.
+type Detector struct {
+	lexicon *Lexicon
+	scope   int
+}
+
+// NewDetector creates a new detector with the lexicon and the maximum scope in tokens.
+func NewDetector(lexicon *Lexicon, scope int) *Detector {
+	return &Detector{lexicon: lexicon, scope: scope}
+}
+
+// span defines the scope of a trigger as token offsets.
+type span struct {
+	category   Category
+	begin, end int
+}
+
+// Detect returns the assertion of the term in the criterion. The term is in the scope of a trigger
+// if it starts in the scope of a pre-trigger or ends in the scope of a post-trigger. The term is
+// located by its tokens, and it is present if it is not found.
+func (d *Detector) Detect(criterion, term string) Assertion {
+	tokens, joined := tokenizeCompounds(criterion)
+	begin, end := find(tokens, tokenize(term))
+	if begin < 0 {
+		return Assertion{}
+	}
+	var a Assertion
+	for _, s := range d.scopes(tokens, joined) {
+		if s.category.forward() && (begin < s.begin || begin >= s.end) {
+			continue
+		}
+		if !s.category.forward() && (end <= s.begin || end > s.end) {
+			continue
+		}
+		switch s.category {
+		case PreNegation, PostNegation:
+			a.Negated = true
+		case Historical:
+			a.Historical = true
+		case Hypothetical:
+			a.Hypothetical = true
+		case FamilyHistory:
+			a.Family = true
+		}
+	}
+	return a
+}
+
+// scopes returns the scopes of the triggers in the tokens. Pseudo-triggers consume their tokens
+// so that the triggers they contain are not matched. Hyphenated compounds, such as 'non-small',
+// are words and do not contain triggers.
+func (d *Detector) scopes(tokens []string, joined []bool) []span {
+	var triggers []span
+	for i := 0; i < len(tokens); {
+		if t := d.lexicon.match(tokens, joined, i); t != nil {
+			triggers = append(triggers, span{category: t.Category, begin: i, end: i + len(t.Tokens)})
+			i += len(t.Tokens)
+		} else {
+			i++
+		}
+	}
+
+	// A scope ends at the next boundary: clause punctuation, a termination, or a trigger of the same category.
+	boundary := func(i int, category Category) bool {
+		if isPunctuation(tokens[i]) {
+			return true
+		}
+		for _, t := range triggers {
+			if t.begin == i && (t.category == Termination || t.category == category) {
+				return true
+			}
+		}
+		return false
+	}
+	var scopes []span
+	for _, t := range triggers {
+		switch {
+		case t.category == PseudoNegation || t.category == Termination:
+			continue
+		case t.category.forward():
+			end := t.end
+			for end < len(tokens) && end-t.end < d.scope && !boundary(end, t.category) {
+				end++
+			}
+			scopes = append(scopes, span{category: t.category, begin: t.end, end: end})
+		default:
+			begin := t.begin
+			for begin > 0 && t.begin-begin < d.scope && !boundary(begin-1, t.category) && !inTrigger(triggers, begin-1) {
+				begin--
+			}
+			scopes = append(scopes, span{category: t.category, begin: begin, end: t.begin})
+		}
+	}
+	return scopes
+}
+
+// inTrigger returns true if the token i is inside a post-trigger, a pseudo-trigger, or a termination.
+func inTrigger(triggers []span, i int) bool {
+	for _, t := range triggers {
+		if t.begin <= i && i < t.end && !t.category.forward() {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the token offsets of the first occurrence of the term tokens,
+// or -1 if the term is not found.
+func find(tokens, term []string) (int, int) {
+	if len(term) == 0 {
+		return -1, -1
+	}
+	for i := 0; i+len(term) <= len(tokens); i++ {
+		if equal(tokens[i:i+len(term)], term) {
+			return i, i + len(term)
+		}
+	}
+	return -1, -1
+}
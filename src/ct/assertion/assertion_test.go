@@ -0,0 +1,93 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package assertion
+
+import (
+	"os"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/eligibility"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	a := assert.New(t)
+
+	d := Get()
+	tests := []struct {
+		criterion string
+		term      string
+		expected  string
+	}{
+		{"Type 2 diabetes mellitus", "type 2 diabetes mellitus", "present"},
+		{"No history of heart disease", "heart disease", "negated|historical"},
+		{"Without uncontrolled hypertension, stroke, or peripheral vascular disease", "peripheral vascular disease", "negated"},
+		{"HIV negative", "hiv", "negated"},
+		{"Hepatitis B ruled out at screening", "hepatitis b", "negated"},
+		{"Prior myocardial infarction", "myocardial infarction", "historical"},
+		{"Family history of breast cancer", "breast cancer", "family"},
+		{"Planned surgery during the study", "surgery", "hypothetical"},
+		{"No change in asthma medication", "asthma medication", "present"},
+		{"Any cancer, including but not limited to melanoma", "melanoma", "present"},
+		{"No other malignancy but basal cell carcinoma", "basal cell carcinoma", "present"},
+		{"No diabetes. Hypertension is allowed", "hypertension", "present"},
+		{"No diabetes", "asthma", "present"},
+		{"Not pregnant or breastfeeding", "breastfeeding", "negated"},
+		{"Non-Hodgkin lymphoma", "lymphoma", "present"},
+		{"Stage IV non-small cell lung cancer", "lung cancer", "present"},
+		{"Disease-free for at least 5 years", "disease", "present"},
+		{"Gram-negative bacteremia", "bacteremia", "present"},
+		{"Non smoker", "smoker", "negated"},
+		{"No non-hodgkin lymphoma", "lymphoma", "negated"},
+	}
+	for _, test := range tests {
+		a.Equal(test.expected, d.Detect(test.criterion, test.term).String(), test.criterion)
+	}
+}
+
+func TestScope(t *testing.T) {
+	a := assert.New(t)
+
+	d := NewDetector(DefaultLexicon(), 2)
+	a.True(d.Detect("no heart failure", "heart failure").Negated)
+	a.True(d.Detect("no congestive heart failure", "heart failure").Negated)
+	a.False(d.Detect("no acute congestive heart failure", "heart failure").Negated)
+	a.False(d.Detect("hypertension or diabetes negative", "hypertension").Negated)
+	a.True(d.Detect("hypertension or diabetes negative", "diabetes").Negated)
+}
+
+func TestPolarity(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(Required, Assertion{}.Polarity(eligibility.Inclusion))
+	a.Equal(Forbidden, Assertion{}.Polarity(eligibility.Exclusion))
+	a.Equal(Forbidden, Assertion{Negated: true}.Polarity(eligibility.Inclusion))
+	a.Equal(Required, Assertion{Negated: true, Historical: true}.Polarity(eligibility.Exclusion))
+	a.Equal(Other, Assertion{Family: true}.Polarity(eligibility.Exclusion))
+	a.Equal(Other, Assertion{Hypothetical: true}.Polarity(eligibility.Inclusion))
+	a.Equal(Other, Assertion{Negated: true, Family: true}.Polarity(eligibility.Inclusion))
+}
+
+func TestLoadLexicon(t *testing.T) {
+	a := assert.New(t)
+
+	f, err := os.CreateTemp(t.TempDir(), "triggers")
+	a.NoError(err)
+	f.WriteString("#trigger,category\nabsence of,pre_negation\nexcept,termination\n")
+	f.Close()
+
+	lexicon, err := LoadLexicon(f.Name())
+	a.NoError(err)
+	a.Equal(2, lexicon.Size())
+	d := NewDetector(lexicon, DefaultScope)
+	a.True(d.Detect("absence of fever", "fever").Negated)
+	a.False(d.Detect("no fever", "fever").Negated)
+
+	f, err = os.CreateTemp(t.TempDir(), "triggers")
+	a.NoError(err)
+	f.WriteString("absence of,negation\n")
+	f.Close()
+	_, err = LoadLexicon(f.Name())
+	a.Error(err)
+}
@@ -0,0 +1,221 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package assertion
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
+
+	"github.com/golang/glog"
+)
+
+var reToken = regexp.MustCompile(`[\p{L}\p{N}]+|[.;:!?]`)
+
+// Category defines the kind of a trigger phrase.
+type Category string
+
+const (
+	PreNegation    Category = "pre_negation"    // Negates the terms that follow, such as 'no' or 'without'
+	PostNegation   Category = "post_negation"   // Negates the terms that precede, such as 'negative' or 'ruled out'
+	PseudoNegation Category = "pseudo_negation" // Looks like a trigger but is not one, such as 'no change' or 'not limited to'
+	Historical     Category = "historical"      // Marks the terms that follow as past, such as 'history of'
+	Hypothetical   Category = "hypothetical"    // Marks the terms that follow as conditional or planned, such as 'if' or 'planned'
+	FamilyHistory  Category = "family_history"  // Marks the terms that follow as experienced by a relative, such as 'family history of'
+	Termination    Category = "termination"     // Ends the scope of the triggers, such as 'but' or 'except'
+)
+
+// ParseCategory converts a string to a trigger category. It returns false if the category is unknown.
+func ParseCategory(s string) (Category, bool) {
+	c := Category(strings.ToLower(strings.TrimSpace(s)))
+	switch c {
+	case PreNegation, PostNegation, PseudoNegation, Historical, Hypothetical, FamilyHistory, Termination:
+		return c, true
+	default:
+		return "", false
+	}
+}
+
+// forward returns true if the scope of the category follows the trigger.
+func (c Category) forward() bool {
+	switch c {
+	case PreNegation, Historical, Hypothetical, FamilyHistory:
+		return true
+	default:
+		return false
+	}
+}
+
+// tokenize splits the lowercase text to words and sentence punctuation.
+func tokenize(s string) []string {
+	tokens, _ := tokenizeCompounds(s)
+	return tokens
+}
+
+// tokenizeCompounds splits the lowercase text to words and sentence punctuation, and returns
+// for each token whether it is joined to the next token by a hyphen, as in 'non-hodgkin'.
+func tokenizeCompounds(s string) ([]string, []bool) {
+	s = strings.ToLower(s)
+	locs := reToken.FindAllStringIndex(s, -1)
+	tokens := make([]string, len(locs))
+	joined := make([]bool, len(locs))
+	for i, loc := range locs {
+		tokens[i] = s[loc[0]:loc[1]]
+		if i+1 < len(locs) && locs[i+1][0] == loc[1]+1 && s[loc[1]] == '-' {
+			joined[i] = true
+		}
+	}
+	return tokens, joined
+}
+
+// isPunctuation returns true if the token ends a clause.
+func isPunctuation(token string) bool {
+	switch token {
+	case ".", ";", ":", "!", "?":
+		return true
+	default:
+		return false
+	}
+}
+
+// Trigger defines a trigger phrase of a category.
+type Trigger struct {
+	Tokens   []string
+	Category Category
+}
+
+// Lexicon defines trigger phrases indexed by their first token.
+type Lexicon struct {
+	triggers map[string][]*Trigger
+	size     int
+}
+
+// NewLexicon creates a new empty lexicon.
+func NewLexicon() *Lexicon {
+	return &Lexicon{triggers: make(map[string][]*Trigger)}
+}
+
+// Size returns the number of triggers in the lexicon.
+func (l *Lexicon) Size() int {
+	return l.size
+}
+
+// Add adds the trigger phrase of the category to the lexicon.
+// Longer phrases are matched before the shorter ones that they start with.
+func (l *Lexicon) Add(phrase string, category Category) error {
+	tokens := tokenize(phrase)
+	if len(tokens) == 0 {
+		return fmt.Errorf("empty trigger phrase: %q", phrase)
+	}
+	t := &Trigger{Tokens: tokens, Category: category}
+	ts := l.triggers[tokens[0]]
+	i := 0
+	for i < len(ts) && len(ts[i].Tokens) >= len(tokens) {
+		i++
+	}
+	ts = append(ts, nil)
+	copy(ts[i+1:], ts[i:])
+	ts[i] = t
+	l.triggers[tokens[0]] = ts
+	l.size++
+	return nil
+}
+
+// match returns the longest trigger that starts at the token i, or nil if none is found.
+// A trigger must not be hyphenated to the surrounding tokens, so 'non' does not match
+// in 'non-hodgkin' and 'free' does not match in 'disease-free'.
+func (l *Lexicon) match(tokens []string, joined []bool, i int) *Trigger {
+	if i > 0 && joined[i-1] {
+		return nil
+	}
+	for _, t := range l.triggers[tokens[i]] {
+		j := i + len(t.Tokens)
+		if j <= len(tokens) && !joined[j-1] && equal(t.Tokens, tokens[i:j]) {
+			return t
+		}
+	}
+	return nil
+}
+
+// equal returns true if the token slices are equal.
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadLexicon loads trigger phrases from a file with the columns trigger and category.
+func LoadLexicon(fname string) (*Lexicon, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lexicon := NewLexicon()
+	r := csv.NewReader(f)
+	r.Comment = rune(param.Comment)
+
+	for {
+		line, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fname, err)
+		}
+		if len(line) < 2 {
+			return nil, fmt.Errorf("%s: too few columns, at least 2 needed: %v", fname, line)
+		}
+		category, ok := ParseCategory(line[1])
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown trigger category: %v", fname, line)
+		}
+		if err := lexicon.Add(line[0], category); err != nil {
+			return nil, fmt.Errorf("%s: %v", fname, err)
+		}
+	}
+	glog.Infof("Number of assertion triggers loaded: %d\n", lexicon.Size())
+
+	return lexicon, nil
+}
+
+// DefaultLexicon defines the NegEx and ConText trigger phrases that are common in eligibility criteria.
+// A custom lexicon can be loaded with LoadLexicon.
+func DefaultLexicon() *Lexicon {
+	lexicon := NewLexicon()
+	add := func(category Category, phrases ...string) {
+		for _, p := range phrases {
+			if err := lexicon.Add(p, category); err != nil {
+				glog.Fatal(err)
+			}
+		}
+	}
+	add(PreNegation, "no", "not", "without", "denies", "denied", "never", "non", "none", "absence of",
+		"free of", "free from", "lack of", "negative for", "no evidence of", "no sign of", "no signs of",
+		"rule out", "cannot", "neither", "nor")
+	add(PostNegation, "negative", "free", "ruled out", "is absent", "are absent", "not present",
+		"not detected", "undetectable", "unlikely")
+	add(PseudoNegation, "no increase", "no change", "no significant change", "no further", "not only",
+		"not necessarily", "not limited to", "but not limited to", "not rule out", "not ruled out",
+		"not been ruled out", "without difficulty", "gram negative", "history and physical", "social history")
+	add(Historical, "history of", "previous", "previously", "prior", "past", "former", "h o", "hx of")
+	add(Hypothetical, "if", "in case of", "in the event of", "should", "planned", "plan to", "plans to",
+		"planning to", "scheduled for", "intend to", "intends to", "expected to")
+	add(FamilyHistory, "family history of", "family history", "first degree relative", "first degree relatives",
+		"relative with", "relatives with", "mother", "father", "sibling", "siblings", "brother", "sister")
+	add(Termination, "but", "however", "except", "although", "though", "yet", "apart from", "aside from",
+		"other than", "which", "who")
+	return lexicon
+}
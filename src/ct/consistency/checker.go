@@ -0,0 +1,312 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package consistency
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/assertion"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/eligibility"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+)
+
+// Kind defines the kind of a finding.
+type Kind string
+
+const (
+	// Contradiction is the kind of two criteria that no value satisfies together
+	Contradiction Kind = "contradiction"
+	// Infeasible is the kind of criteria that no value satisfies together, although each pair does
+	Infeasible Kind = "infeasible"
+	// Duplicate is the kind of criteria that are satisfied by the same values
+	Duplicate Kind = "duplicate"
+	// Redundant is the kind of a criterion that is implied by another criterion
+	Redundant Kind = "redundant"
+	// Conflict is the kind of concepts that should not be required together
+	Conflict Kind = "conflict"
+)
+
+// Involved defines a criterion involved in a finding. The criterion id (cid) is the same as
+// in the output of Study.Relations, or -1 if the criterion is not from a parsed study.
+type Involved struct {
+	CID       int
+	Type      eligibility.Type
+	Criterion string
+	Domain    string
+}
+
+// Finding defines an inconsistency or redundancy among the criteria of a study.
+// Merged is the domain that satisfies all the involved criteria. The first criterion
+// of a redundant finding implies the second one, which can be dropped.
+type Finding struct {
+	NCT      string
+	Kind     Kind
+	Variable string
+	Unit     string
+	Merged   string
+	Criteria []Involved
+}
+
+// Findings defines a slice of findings.
+type Findings []*Finding
+
+// Write writes the findings to w, one involved criterion per line. The findings are numbered from
+// the offset id, so the criteria of the same finding have the same finding id.
+func (fs Findings) Write(w io.Writer, id int) error {
+	for i, f := range fs {
+		for _, c := range f.Criteria {
+			cid := ""
+			if c.CID >= 0 {
+				cid = fmt.Sprint(c.CID)
+			}
+			if _, err := fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				f.NCT, id+i, f.Kind, f.Variable, f.Unit, f.Merged, c.Type, cid, c.Criterion, c.Domain); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Checker checks the parsed criteria of studies for contradictory and redundant criteria.
+type Checker struct {
+	minScore  float64     // Relations with a score not above minScore are ignored
+	conflicts [][2]string // Pairs of NER labels that should not be required together
+}
+
+// NewChecker creates a new checker.
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// SetMinScore sets the minimum score of relations to be checked.
+func (c *Checker) SetMinScore(minScore float64) *Checker {
+	c.minScore = minScore
+	return c
+}
+
+// SetConflicts sets the pairs of NER labels that should not be required together.
+func (c *Checker) SetConflicts(conflicts [][2]string) *Checker {
+	c.conflicts = conflicts
+	return c
+}
+
+// ParseConflicts parses the pairs of conflicting NER labels, such as
+// 'word_scores:pregnancy|word_scores:contraception_consent', separated by commas.
+func ParseConflicts(s string) ([][2]string, error) {
+	var conflicts [][2]string
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		labels := strings.Split(pair, "|")
+		if len(labels) != 2 {
+			return nil, fmt.Errorf("bad conflict: %q", pair)
+		}
+		conflicts = append(conflicts, [2]string{strings.TrimSpace(labels[0]), strings.TrimSpace(labels[1])})
+	}
+	return conflicts, nil
+}
+
+// entry defines a criterion whose relations constrain a single variable.
+type entry struct {
+	involved Involved
+	variable string
+	unit     string
+	domain   Domain
+}
+
+// Check checks the criteria of the parsed study s. Exclusion criteria are already negated
+// by Study.Parse, so all criteria must be met. The relations of a criterion are alternatives,
+// so only criteria whose relations constrain the same variable, with the same unit and
+// time window, are compared: their domains are intersected per variable. The limits are
+// converted to the default unit of the variable, or to the first unit of the variable in
+// the study if it has no default unit, so that 'age ≥ 18 years' and 'age < 200 months' are compared.
+func (c *Checker) Check(s *studies.Study) Findings {
+	unitNames := make(map[variables.ID]string)
+	for _, cs := range []criteria.Criteria{s.InclusionCriteria(), s.ExclusionCriteria()} {
+		for _, r := range cs.Relations() {
+			if _, ok := unitNames[r.ID]; ok || len(r.Unit) == 0 {
+				continue
+			}
+			unitNames[r.ID] = r.Unit
+			if v := variables.Get().Variable(r.ID); v != nil && len(v.UnitName) > 0 {
+				unitNames[r.ID] = v.UnitName
+			}
+		}
+	}
+
+	var keys []string
+	groups := make(map[string][]entry)
+	cid := 0
+	add := func(t eligibility.Type, cs criteria.Criteria) {
+		for _, cr := range cs {
+			if key, e, ok := c.entry(cr, unitNames); ok {
+				e.involved = Involved{CID: cid, Type: t, Criterion: cr.String(), Domain: e.domain.String()}
+				if _, ok := groups[key]; !ok {
+					keys = append(keys, key)
+				}
+				groups[key] = append(groups[key], e)
+			}
+			cid++
+		}
+	}
+	add(eligibility.Inclusion, s.InclusionCriteria())
+	add(eligibility.Exclusion, s.ExclusionCriteria())
+
+	var findings Findings
+	for _, key := range keys {
+		findings = append(findings, check(groups[key])...)
+	}
+	for _, f := range findings {
+		f.NCT = s.NCT()
+	}
+	return findings
+}
+
+// entry returns the domain of the criterion if its relations constrain a single variable.
+// The limits are converted to the units of the variables. The key identifies the variable,
+// unit, and time window.
+func (c *Checker) entry(cr *criteria.Criterion, unitNames map[variables.ID]string) (string, entry, bool) {
+	var e entry
+	key := ""
+	found := false
+	for _, r := range cr.Relations() {
+		if !r.Valid() || r.Score <= c.minScore {
+			continue
+		}
+		r = convert(r, unitNames[r.ID])
+		d, ok := NewDomain(r)
+		if !ok {
+			return "", e, false
+		}
+		k := fmt.Sprintf("%s\t%s", r.ID, r.Unit)
+		if r.Temporal != nil {
+			k += "\t" + r.Temporal.String()
+		}
+		switch {
+		case !found:
+			key, e = k, entry{variable: r.Name, unit: r.Unit, domain: d}
+			found = true
+		case k != key:
+			return "", e, false
+		default:
+			e.domain = e.domain.union(d)
+		}
+	}
+	return key, e, found
+}
+
+// convert returns a copy of the relation with its limits converted to the unit by Relation.Convert,
+// or the relation itself if it is in the unit or the conversion is not known.
+func convert(r *relation.Relation, unit string) *relation.Relation {
+	converted := *r
+	if len(unit) == 0 || !converted.Convert(variables.NewVariable(r.ID, r.VariableType, r.Name, "", nil, nil, unit)) {
+		return r
+	}
+	return &converted
+}
+
+// check checks the criteria of the same variable pairwise for contradictions, duplicates,
+// and redundancies, and then all together for an empty domain.
+func check(es []entry) Findings {
+	var findings Findings
+	finding := func(kind Kind, merged Domain, indices ...int) {
+		f := &Finding{Kind: kind, Variable: es[indices[0]].variable, Unit: es[indices[0]].unit, Merged: merged.String()}
+		for _, i := range indices {
+			f.Criteria = append(f.Criteria, es[i].involved)
+		}
+		findings = append(findings, f)
+	}
+
+	duplicate := make([]bool, len(es))
+	contradiction := false
+	for i := range es {
+		if es[i].domain.Empty() {
+			finding(Infeasible, es[i].domain, i)
+			contradiction = true
+		}
+	}
+	for i := range es {
+		if duplicate[i] || es[i].domain.Empty() {
+			continue
+		}
+		for j := i + 1; j < len(es); j++ {
+			if duplicate[j] || es[j].domain.Empty() {
+				continue
+			}
+			di, dj := es[i].domain, es[j].domain
+			merged := di.Intersect(dj)
+			switch {
+			case merged.Empty():
+				finding(Contradiction, merged, i, j)
+				contradiction = true
+			case di.Equal(dj):
+				finding(Duplicate, merged, i, j)
+				duplicate[j] = true
+			case di.Subset(dj):
+				finding(Redundant, merged, i, j)
+			case dj.Subset(di):
+				finding(Redundant, merged, j, i)
+			}
+		}
+	}
+	if contradiction || len(es) < 3 {
+		return findings
+	}
+
+	merged := es[0].domain
+	indices := []int{0}
+	for i := 1; i < len(es); i++ {
+		if !duplicate[i] {
+			merged = merged.Intersect(es[i].domain)
+			indices = append(indices, i)
+		}
+	}
+	if merged.Empty() {
+		finding(Infeasible, merged, indices...)
+	}
+	return findings
+}
+
+// Concept defines a NER term of a criterion that is linked to a concept, as in the output of cmd/nel.
+type Concept struct {
+	Type      eligibility.Type
+	Criterion string
+	Label     string
+	Term      string
+	Polarity  assertion.Polarity
+}
+
+// CheckConcepts checks the concepts of the study for pairs of NER labels that should not be
+// required together, such as pregnancy and contraception consent.
+func (c *Checker) CheckConcepts(nct string, concepts []Concept) Findings {
+	var findings Findings
+	for _, pair := range c.conflicts {
+		var first, second []Involved
+		for _, cp := range concepts {
+			if cp.Polarity != assertion.Required {
+				continue
+			}
+			involved := Involved{CID: -1, Type: cp.Type, Criterion: cp.Criterion, Domain: cp.Term}
+			switch cp.Label {
+			case pair[0]:
+				first = append(first, involved)
+			case pair[1]:
+				second = append(second, involved)
+			}
+		}
+		if len(first) > 0 && len(second) > 0 {
+			f := &Finding{NCT: nct, Kind: Conflict, Variable: pair[0] + "|" + pair[1]}
+			f.Criteria = append(append(f.Criteria, first...), second...)
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,148 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package consistency
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/assertion"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/eligibility"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const eligibilityCriteria = `Inclusion Criteria:
+
+            Age ≥ 18 years.
+
+            NYHA Class of I or II.
+
+            NYHA Class of I or II.
+
+            BMI ≥ 25 kg/m2.
+
+            Exclusion Criteria:
+
+            Age > 16 years.
+
+            BMI < 20 kg/m2.`
+
+func TestDomain(t *testing.T) {
+	a := assert.New(t)
+
+	d, ok := NewDomain(relation.Parse(`{"id":"200","name":"age","lower":{"incl":true,"value":"18"},"upper":{"incl":true,"value":"65"},"variableType":"numerical"}`))
+	a.True(ok)
+	a.Equal("[18, 65]", d.String())
+
+	negated, ok := NewDomain(relation.Parse(`{"id":"200","name":"age","lower":{"incl":false,"value":"65"},"upper":{"incl":false,"value":"18"},"variableType":"numerical"}`))
+	a.True(ok)
+	a.Equal("(-inf, 18) ∪ (65, inf)", negated.String())
+	a.Equal("[18, 18]", d.Intersect(negated.union(Domain{numerical: true, intervals: Intervals{{Lower: 10, Upper: 18, UpperIncl: true}}})).String())
+	a.True(d.Intersect(negated).Empty())
+
+	lower := Domain{numerical: true, intervals: Intervals{{Lower: 16, Upper: math.Inf(1)}}}
+	a.True(d.Subset(lower))
+	a.False(lower.Subset(d))
+
+	_, ok = NewDomain(relation.Parse(`{"id":"411","name":"ast","upper":{"incl":true,"value":"2.5","reference":"uln"},"variableType":"numerical"}`))
+	a.False(ok)
+
+	ordinal, ok := NewDomain(relation.Parse(`{"id":"102","name":"nyha","value":["1","2"],"variableType":"ordinal"}`))
+	a.True(ok)
+	a.Equal("{1, 2}", ordinal.String())
+	other, _ := NewDomain(relation.Parse(`{"id":"102","name":"nyha","value":["3","4"],"variableType":"ordinal"}`))
+	a.True(ordinal.Intersect(other).Empty())
+}
+
+func TestCheck(t *testing.T) {
+	a := assert.New(t)
+
+	study := studies.NewStudy("NCT00000000", "Consistency", nil, eligibilityCriteria).Parse()
+	findings := NewChecker().Check(study)
+	a.Len(findings, 3)
+
+	a.Equal(Contradiction, findings[0].Kind)
+	a.Equal("age", findings[0].Variable)
+	a.Equal("∅", findings[0].Merged)
+	a.Equal([]int{0, 4}, []int{findings[0].Criteria[0].CID, findings[0].Criteria[1].CID})
+	a.Equal(eligibility.Exclusion, findings[0].Criteria[1].Type)
+
+	a.Equal(Duplicate, findings[1].Kind)
+	a.Equal("nyha", findings[1].Variable)
+	a.Equal("{1, 2}", findings[1].Merged)
+	a.Equal([]int{1, 2}, []int{findings[1].Criteria[0].CID, findings[1].Criteria[1].CID})
+
+	a.Equal(Redundant, findings[2].Kind)
+	a.Equal("bmi", findings[2].Variable)
+	a.Equal("[25, inf)", findings[2].Merged)
+	a.Equal([]int{3, 5}, []int{findings[2].Criteria[0].CID, findings[2].Criteria[1].CID})
+
+	var b strings.Builder
+	a.NoError(findings[:1].Write(&b, 1))
+	a.Equal("NCT00000000\t1\tcontradiction\tage\tyear\t∅\tinclusion\t0\tAge ≥ 18 years\t[18, inf)\n"+
+		"NCT00000000\t1\tcontradiction\tage\tyear\t∅\texclusion\t4\tAge > 16 years\t(-inf, 16]\n", b.String())
+}
+
+func TestInfeasible(t *testing.T) {
+	a := assert.New(t)
+
+	input := `Inclusion Criteria:
+
+            NYHA Class of I or II.
+
+            NYHA Class of II or III.
+
+            NYHA Class of I or III.`
+
+	study := studies.NewStudy("NCT00000001", "Consistency", nil, input).Parse()
+	findings := NewChecker().Check(study)
+	a.Len(findings, 1)
+	a.Equal(Infeasible, findings[0].Kind)
+	a.Len(findings[0].Criteria, 3)
+}
+
+func TestCheckUnits(t *testing.T) {
+	a := assert.New(t)
+
+	input := `Inclusion Criteria:
+
+            Age ≥ 18 years.
+
+            Exclusion Criteria:
+
+            Age > 200 months.`
+
+	study := studies.NewStudy("NCT00000003", "Consistency", nil, input).Parse()
+	findings := NewChecker().Check(study)
+	a.Len(findings, 1)
+	a.Equal(Contradiction, findings[0].Kind)
+	a.Equal("year", findings[0].Unit)
+	a.Equal("(-inf, 16.6667]", findings[0].Criteria[1].Domain)
+}
+
+func TestCheckConcepts(t *testing.T) {
+	a := assert.New(t)
+
+	conflicts, err := ParseConflicts("word_scores:pregnancy|word_scores:contraception_consent")
+	a.NoError(err)
+	_, err = ParseConflicts("word_scores:pregnancy")
+	a.Error(err)
+
+	concepts := []Concept{
+		{Type: eligibility.Inclusion, Criterion: "pregnant women", Label: "word_scores:pregnancy", Term: "pregnant", Polarity: assertion.Required},
+		{Type: eligibility.Inclusion, Criterion: "willing to use contraception", Label: "word_scores:contraception_consent", Term: "contraception", Polarity: assertion.Required},
+	}
+	checker := NewChecker().SetConflicts(conflicts)
+	findings := checker.CheckConcepts("NCT00000002", concepts)
+	a.Len(findings, 1)
+	a.Equal(Conflict, findings[0].Kind)
+	a.Len(findings[0].Criteria, 2)
+	a.Equal(-1, findings[0].Criteria[0].CID)
+
+	concepts[0].Polarity = assertion.Forbidden
+	a.Empty(checker.CheckConcepts("NCT00000002", concepts))
+}
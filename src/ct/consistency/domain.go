@@ -0,0 +1,256 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package consistency
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+)
+
+// Interval defines an interval of numbers with inclusive or exclusive bounds.
+// Unbounded intervals have infinite bounds.
+type Interval struct {
+	Lower     float64
+	Upper     float64
+	LowerIncl bool
+	UpperIncl bool
+}
+
+// Intervals defines a union of intervals. Normalized intervals are sorted and disjoint.
+type Intervals []Interval
+
+// empty returns true if the interval contains no number.
+func (a Interval) empty() bool {
+	return a.Lower > a.Upper || (a.Lower == a.Upper && !(a.LowerIncl && a.UpperIncl))
+}
+
+// intersect returns the intersection of the intervals a and b.
+func (a Interval) intersect(b Interval) Interval {
+	c := a
+	switch {
+	case b.Lower > c.Lower:
+		c.Lower, c.LowerIncl = b.Lower, b.LowerIncl
+	case b.Lower == c.Lower:
+		c.LowerIncl = c.LowerIncl && b.LowerIncl
+	}
+	switch {
+	case b.Upper < c.Upper:
+		c.Upper, c.UpperIncl = b.Upper, b.UpperIncl
+	case b.Upper == c.Upper:
+		c.UpperIncl = c.UpperIncl && b.UpperIncl
+	}
+	return c
+}
+
+// String returns the interval in the bracket notation, such as '[18, inf)'.
+func (a Interval) String() string {
+	var b strings.Builder
+	if a.LowerIncl {
+		b.WriteString("[")
+	} else {
+		b.WriteString("(")
+	}
+	b.WriteString(formatBound(a.Lower))
+	b.WriteString(", ")
+	b.WriteString(formatBound(a.Upper))
+	if a.UpperIncl {
+		b.WriteString("]")
+	} else {
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// formatBound formats the bound value.
+func formatBound(x float64) string {
+	switch {
+	case math.IsInf(x, 1):
+		return "inf"
+	case math.IsInf(x, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+}
+
+// normalize removes the empty intervals, sorts the intervals, and joins the overlapping
+// or adjacent intervals.
+func (as Intervals) normalize() Intervals {
+	v := make(Intervals, 0, len(as))
+	for _, a := range as {
+		if !a.empty() {
+			v = append(v, a)
+		}
+	}
+	sort.Slice(v, func(i, j int) bool {
+		if v[i].Lower != v[j].Lower {
+			return v[i].Lower < v[j].Lower
+		}
+		return v[i].LowerIncl && !v[j].LowerIncl
+	})
+	n := make(Intervals, 0, len(v))
+	for _, a := range v {
+		if len(n) > 0 {
+			c := &n[len(n)-1]
+			if a.Lower < c.Upper || (a.Lower == c.Upper && (a.LowerIncl || c.UpperIncl)) {
+				switch {
+				case a.Upper > c.Upper:
+					c.Upper, c.UpperIncl = a.Upper, a.UpperIncl
+				case a.Upper == c.Upper:
+					c.UpperIncl = c.UpperIncl || a.UpperIncl
+				}
+				continue
+			}
+		}
+		n = append(n, a)
+	}
+	return n
+}
+
+// intersect returns the normalized intersection of the unions of intervals.
+func (as Intervals) intersect(bs Intervals) Intervals {
+	var v Intervals
+	for _, a := range as {
+		for _, b := range bs {
+			v = append(v, a.intersect(b))
+		}
+	}
+	return v.normalize()
+}
+
+// equal returns true if the normalized unions of intervals are the same.
+func (as Intervals) equal(bs Intervals) bool {
+	if len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the union of intervals, such as '(-inf, 18) ∪ (65, inf)'.
+func (as Intervals) String() string {
+	if len(as) == 0 {
+		return "∅"
+	}
+	s := make([]string, len(as))
+	for i, a := range as {
+		s[i] = a.String()
+	}
+	return strings.Join(s, " ∪ ")
+}
+
+// Domain defines the values that satisfy a criterion: a union of intervals
+// for a numerical variable or a set of values for a categorical variable.
+type Domain struct {
+	numerical bool
+	intervals Intervals
+	values    set.Set
+}
+
+// NewDomain creates the domain of the relation. A negated numerical relation, such as
+// 'age < 18 or age > 65', has its lower limit above its upper limit. It returns false if
+// the relation has no domain, such as a relation relative to a reference limit.
+func NewDomain(r *relation.Relation) (Domain, bool) {
+	switch r.VariableType {
+	case variables.Numerical:
+		a := Interval{Lower: math.Inf(-1), Upper: math.Inf(1)}
+		if r.Relative() {
+			return Domain{}, false
+		}
+		if r.Lower != nil {
+			x, err := strconv.ParseFloat(r.Lower.Value, 64)
+			if err != nil {
+				return Domain{}, false
+			}
+			a.Lower, a.LowerIncl = x, r.Lower.Incl
+		}
+		if r.Upper != nil {
+			x, err := strconv.ParseFloat(r.Upper.Value, 64)
+			if err != nil {
+				return Domain{}, false
+			}
+			a.Upper, a.UpperIncl = x, r.Upper.Incl
+		}
+		intervals := Intervals{a}
+		if r.Lower != nil && r.Upper != nil && (a.Lower > a.Upper || (a.Lower == a.Upper && !a.LowerIncl && !a.UpperIncl)) {
+			intervals = Intervals{
+				{Lower: math.Inf(-1), Upper: a.Upper, UpperIncl: a.UpperIncl},
+				{Lower: a.Lower, Upper: math.Inf(1), LowerIncl: a.LowerIncl},
+			}
+		}
+		return Domain{numerical: true, intervals: intervals.normalize()}, true
+	case variables.Boolean, variables.Nominal, variables.Ordinal:
+		return Domain{values: set.New(r.Value...)}, true
+	default:
+		return Domain{}, false
+	}
+}
+
+// union returns the union of the domains of the same variable.
+func (d Domain) union(e Domain) Domain {
+	if d.numerical {
+		intervals := append(append(Intervals{}, d.intervals...), e.intervals...)
+		return Domain{numerical: true, intervals: intervals.normalize()}
+	}
+	values := d.values.Copy()
+	values.AddSet(e.values)
+	return Domain{values: values}
+}
+
+// Intersect returns the intersection of the domains of the same variable.
+func (d Domain) Intersect(e Domain) Domain {
+	if d.numerical {
+		return Domain{numerical: true, intervals: d.intervals.intersect(e.intervals)}
+	}
+	values := set.New()
+	for a := range d.values {
+		if e.values.Contains(a) {
+			values.Add(a)
+		}
+	}
+	return Domain{values: values}
+}
+
+// Empty returns true if no value satisfies the domain.
+func (d Domain) Empty() bool {
+	if d.numerical {
+		return len(d.intervals) == 0
+	}
+	return d.values.Empty()
+}
+
+// Equal returns true if the domains have the same values.
+func (d Domain) Equal(e Domain) bool {
+	if d.numerical {
+		return d.intervals.equal(e.intervals)
+	}
+	return d.values.Size() == e.values.Size() && d.values.Intersection(e.values) == d.values.Size()
+}
+
+// Subset returns true if all values of the domain d are in the domain e.
+func (d Domain) Subset(e Domain) bool {
+	return d.Intersect(e).Equal(d)
+}
+
+// String returns the intervals or the sorted values of the domain, such as '{1, 2}'.
+func (d Domain) String() string {
+	if d.numerical {
+		return d.intervals.String()
+	}
+	if d.values.Empty() {
+		return "∅"
+	}
+	values := d.values.Slice()
+	sort.Strings(values)
+	return "{" + strings.Join(values, ", ") + "}"
+}
@@ -3,6 +3,7 @@
 package criteria
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 
@@ -14,6 +15,8 @@ type Criterion struct {
 	text         string             // raw criterion string
 	relations    relation.Relations // parsed criterion from text, may contain multiple sub-criteria
 	score        float64
+	CID          int // criterion id, same as in the output of Study.Relations
+	Source       int // index of the criterion text in the study, shared by the criteria parsed from it
 	ClusterID    int
 	ClusterTopic string
 }
@@ -85,6 +88,23 @@ func (c *Criterion) JSON() string {
 	return c.relations.JSON()
 }
 
+// MarshalJSON encodes the criterion as a JSON object with its ids, text, score, relations, and cluster if set.
+func (c *Criterion) MarshalJSON() ([]byte, error) {
+	rels := c.relations
+	if rels == nil {
+		rels = relation.NewRelations()
+	}
+	return json.Marshal(struct {
+		CID          int                `json:"cid"`
+		Source       int                `json:"source"`
+		Text         string             `json:"text"`
+		Score        float64            `json:"score"`
+		Relations    relation.Relations `json:"relations"`
+		ClusterID    int                `json:"cluster_id,omitempty"`
+		ClusterTopic string             `json:"cluster_topic,omitempty"`
+	}{c.CID, c.Source, c.text, c.score, rels, c.ClusterID, c.ClusterTopic})
+}
+
 // Contains returns true if cs contains c.
 func (c *Criterion) Contains(cs Criteria) bool {
 	for i := range cs {
@@ -82,6 +82,62 @@ func TrimCriterion(s string) string {
 	return s
 }
 
+// Align aligns the criterion c with the eligibility criteria text s, searching from the offset start.
+// Whitespace in c matches a run of whitespace in s. It returns the offsets in s of the criterion
+// bytes followed by the end offset of the criterion, or nil if the criterion is not found.
+func Align(s, c string, start int) []int {
+	if len(c) == 0 || start < 0 {
+		return nil
+	}
+	for i := start; i < len(s); i++ {
+		k := strings.IndexByte(s[i:], c[0])
+		if k < 0 {
+			break
+		}
+		i += k
+		if offsets := alignAt(s, c, i); offsets != nil {
+			return offsets
+		}
+	}
+	return nil
+}
+
+// alignAt aligns the criterion c with the text s at the offset i.
+func alignAt(s, c string, i int) []int {
+	offsets := make([]int, len(c)+1)
+	j := i
+	for k := 0; k < len(c); k++ {
+		if j >= len(s) {
+			return nil
+		}
+		offsets[k] = j
+		if isSpace(c[k]) {
+			if !isSpace(s[j]) {
+				return nil
+			}
+			j++
+			if k+1 < len(c) && isSpace(c[k+1]) {
+				continue
+			}
+			for j < len(s) && isSpace(s[j]) {
+				j++
+			}
+			continue
+		}
+		if s[j] != c[k] {
+			return nil
+		}
+		j++
+	}
+	offsets[len(c)] = j
+	return offsets
+}
+
+// isSpace reports whether the byte is an ASCII whitespace character.
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == '\v'
+}
+
 func checkLine(rule string, header string, foundTab bool) (string, string, bool) {
 	// found a bullet for a previously seen header
 	if foundTab && reMatchBulletLine.MatchString(rule) {
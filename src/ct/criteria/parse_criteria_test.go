@@ -244,3 +244,19 @@ func TestSplit(t *testing.T) {
 	actual := Split(input)
 	a.Equal(expected, actual)
 }
+
+func TestAlign(t *testing.T) {
+	a := assert.New(t)
+
+	input := "Inclusion:\n - BMI  between\n\t18 and 25.\n - BMI < 30"
+	criterion := "BMI between 18 and 25"
+	offsets := Align(input, criterion, 0)
+	a.Len(offsets, len(criterion)+1)
+	a.Equal("BMI  between\n\t18 and 25", input[offsets[0]:offsets[len(criterion)]])
+	a.Equal("18", input[offsets[12]:offsets[14]])
+
+	a.Nil(Align(input, "BMI between 18 and 26", 0))
+
+	offsets = Align(input, "BMI", 20)
+	a.Equal(42, offsets[0])
+}
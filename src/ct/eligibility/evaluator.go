@@ -0,0 +1,235 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package eligibility
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+
+	"github.com/golang/glog"
+)
+
+// Reason defines the outcome of evaluating one criterion for a patient. The criterion id (cid)
+// is the same as in the output of Study.Relations. Failed relations are the relations that
+// the patient does not satisfy, and missing relations are the relations that cannot be
+// evaluated because the patient record lacks the data.
+type Reason struct {
+	CID       int                `json:"cid"`
+	Type      Type               `json:"type"`
+	Criterion string             `json:"criterion"`
+	Status    Status             `json:"status"`
+	Failed    relation.Relations `json:"failed,omitempty"`
+	Missing   relation.Relations `json:"missing,omitempty"`
+}
+
+// Result defines the outcome of evaluating the eligibility criteria of a study for a patient.
+type Result struct {
+	NCT     string    `json:"nct"`
+	Patient string    `json:"patient,omitempty"`
+	Status  Status    `json:"status"`
+	Reasons []*Reason `json:"reasons"`
+}
+
+// JSON converts the result to the json string.
+func (r *Result) JSON() string {
+	b, err := json.Marshal(r)
+	if err == nil {
+		return string(b)
+	}
+	glog.Warningf("Failed to marshal result: %v\n", err)
+	return ""
+}
+
+// Evaluator evaluates the parsed eligibility criteria of studies for patients.
+type Evaluator struct {
+	ranges   relation.ReferenceRanges // Default reference ranges for relative criteria
+	minScore float64                  // Relations with a score not above minScore are ignored
+}
+
+// NewEvaluator creates a new evaluator.
+func NewEvaluator() *Evaluator {
+	return &Evaluator{ranges: make(relation.ReferenceRanges)}
+}
+
+// SetReferenceRanges sets the reference ranges that are used to evaluate relative
+// criteria when a patient measurement has no reference range of its own.
+func (e *Evaluator) SetReferenceRanges(ranges relation.ReferenceRanges) *Evaluator {
+	e.ranges = ranges
+	return e
+}
+
+// SetMinScore sets the minimum score of relations to be evaluated.
+func (e *Evaluator) SetMinScore(minScore float64) *Evaluator {
+	e.minScore = minScore
+	return e
+}
+
+// Evaluate evaluates the eligibility criteria of the parsed study s for the patient p.
+// The relations of a criterion are alternatives, so the criterion is met if any of them holds.
+// Exclusion criteria are already negated by Study.Parse, so they are evaluated as inclusions.
+// The patient is ineligible if a criterion is not met, indeterminate if a criterion cannot be
+// decided, and otherwise eligible. Criteria without relations to evaluate are skipped.
+func (e *Evaluator) Evaluate(s *studies.Study, p *Patient) *Result {
+	result := &Result{NCT: s.NCT(), Patient: p.ID, Status: Eligible, Reasons: make([]*Reason, 0)}
+	cid := 0
+	add := func(t Type, cs criteria.Criteria) {
+		for _, c := range cs {
+			if reason := e.evaluateCriterion(c, p); reason != nil {
+				reason.CID = cid
+				reason.Type = t
+				result.Reasons = append(result.Reasons, reason)
+				switch {
+				case reason.Status == Ineligible:
+					result.Status = Ineligible
+				case reason.Status == Indeterminate && result.Status == Eligible:
+					result.Status = Indeterminate
+				}
+			}
+			cid++
+		}
+	}
+	add(Inclusion, s.InclusionCriteria())
+	add(Exclusion, s.ExclusionCriteria())
+	return result
+}
+
+// evaluateCriterion evaluates the criterion c for the patient p.
+// It returns nil if the criterion has no relations to evaluate.
+func (e *Evaluator) evaluateCriterion(c *criteria.Criterion, p *Patient) *Reason {
+	reason := &Reason{Criterion: c.String(), Status: Ineligible}
+	cnt := 0
+	for _, r := range c.Relations() {
+		if !r.Valid() || r.Score <= e.minScore {
+			continue
+		}
+		cnt++
+		switch e.evaluateRelation(r, p) {
+		case Eligible:
+			reason.Status = Eligible
+		case Ineligible:
+			reason.Failed = append(reason.Failed, r)
+		default:
+			reason.Missing = append(reason.Missing, r)
+		}
+	}
+	switch {
+	case cnt == 0:
+		return nil
+	case reason.Status == Eligible:
+		reason.Failed = nil
+		reason.Missing = nil
+	case len(reason.Missing) > 0:
+		reason.Status = Indeterminate
+	}
+	return reason
+}
+
+// evaluateRelation evaluates the relation r for the patient p.
+func (e *Evaluator) evaluateRelation(r *relation.Relation, p *Patient) Status {
+	switch r.VariableType {
+	case variables.Numerical:
+		m, ok := p.Measurement(r.ID)
+		if !ok {
+			return Indeterminate
+		}
+		return e.evaluateMeasurement(r, m)
+	case variables.Boolean, variables.Nominal, variables.Ordinal:
+		values, ok := p.Value(r.ID)
+		if !ok {
+			return Indeterminate
+		}
+		for _, a := range r.Value {
+			for _, b := range values {
+				if a == b {
+					return Eligible
+				}
+			}
+		}
+		return Ineligible
+	default:
+		return Indeterminate
+	}
+}
+
+// evaluateMeasurement evaluates the numerical relation r for the measurement m.
+// A relative relation is first resolved with the reference range of the measurement
+// or the default reference range of the variable.
+func (e *Evaluator) evaluateMeasurement(r *relation.Relation, m Measurement) Status {
+	if r.Relative() {
+		var rr relation.ReferenceRange
+		switch {
+		case m.Reference != nil:
+			rr = *m.Reference
+		default:
+			var ok bool
+			if rr, ok = e.ranges[r.Name]; !ok {
+				return Indeterminate
+			}
+		}
+		q, err := r.Resolve(rr)
+		if err != nil {
+			return Indeterminate
+		}
+		r = q
+	}
+	val := m.Value
+	if len(r.Unit) > 0 && len(m.Unit) > 0 && r.Unit != m.Unit {
+		var ok bool
+		if val, ok = units.Get().Convert(m.Value, m.Unit, r.Unit, r.Name); !ok {
+			return Indeterminate
+		}
+	}
+	lower, lowerOK := satisfies(r.Lower, val, true)
+	upper, upperOK := satisfies(r.Upper, val, false)
+	if !lowerOK || !upperOK {
+		return Indeterminate
+	}
+	met := lower && upper
+	if r.Lower != nil && r.Upper != nil && greater(r.Lower, r.Upper) {
+		// A negated range, such as 'age < 18 or age > 65', has the lower limit above the upper limit.
+		met = lower || upper
+	}
+	if met {
+		return Eligible
+	}
+	return Ineligible
+}
+
+// satisfies returns true if the value satisfies the lower or upper limit l.
+// A missing limit is satisfied. The second value is false if the limit is not a number.
+func satisfies(l *relation.Limit, val float64, lower bool) (bool, bool) {
+	if l == nil {
+		return true, true
+	}
+	x, err := strconv.ParseFloat(l.Value, 64)
+	if err != nil {
+		return false, false
+	}
+	switch {
+	case val == x:
+		return l.Incl, true
+	case lower:
+		return val > x, true
+	default:
+		return val < x, true
+	}
+}
+
+// greater returns true if the value of the limit l is greater than the value of the limit u.
+func greater(l, u *relation.Limit) bool {
+	x, err := strconv.ParseFloat(l.Value, 64)
+	if err != nil {
+		return false
+	}
+	y, err := strconv.ParseFloat(u.Value, 64)
+	if err != nil {
+		return false
+	}
+	return x > y
+}
@@ -0,0 +1,135 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package eligibility
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const eligibilityCriteria = `Inclusion Criteria:
+
+            Male or female, aged 18 to 59 (inclusive).
+
+            NYHA Class of I or II.
+
+            AST ≤ 2.5 x ULN.
+
+            Exclusion Criteria:
+
+            Eastern cooperative oncology group is 0-2.`
+
+func newStudy() *studies.Study {
+	return studies.NewStudy("ID012345", "Better Health for Everybody", nil, eligibilityCriteria).Parse()
+}
+
+func newPatient() *Patient {
+	return NewPatient("P1").
+		SetMeasurement("200", 45, "").
+		SetValues("102", "2").
+		SetLabMeasurement("411", 60, "u/l", relation.NewReferenceRange(10, 40, "u/l")).
+		SetValues("100", "3")
+}
+
+func TestEligible(t *testing.T) {
+	a := assert.New(t)
+
+	result := NewEvaluator().Evaluate(newStudy(), newPatient())
+	a.Equal(Eligible, result.Status)
+	a.Len(result.Reasons, 4)
+	for _, reason := range result.Reasons {
+		a.Equal(Eligible, reason.Status)
+	}
+}
+
+func TestIneligible(t *testing.T) {
+	a := assert.New(t)
+
+	patient := newPatient().SetMeasurement("200", 65, "").SetValues("100", "1")
+	result := NewEvaluator().Evaluate(newStudy(), patient)
+	a.Equal(Ineligible, result.Status)
+
+	failed := make(map[string]Type)
+	for _, reason := range result.Reasons {
+		if reason.Status == Ineligible {
+			a.Len(reason.Failed, 1)
+			failed[reason.Failed[0].Name] = reason.Type
+		}
+	}
+	a.Equal(map[string]Type{"age": Inclusion, "ecog": Exclusion}, failed)
+}
+
+func TestIndeterminate(t *testing.T) {
+	a := assert.New(t)
+
+	patient := newPatient()
+	delete(patient.Measurements, "411")
+	result := NewEvaluator().Evaluate(newStudy(), patient)
+	a.Equal(Indeterminate, result.Status)
+
+	for _, reason := range result.Reasons {
+		if reason.Status == Indeterminate {
+			a.Len(reason.Missing, 1)
+			a.Equal("ast", reason.Missing[0].Name)
+		}
+	}
+}
+
+func TestRelativeWithoutReferenceRange(t *testing.T) {
+	a := assert.New(t)
+
+	patient := newPatient().SetMeasurement("411", 60, "u/l")
+	result := NewEvaluator().Evaluate(newStudy(), patient)
+	a.Equal(Indeterminate, result.Status)
+
+	ranges := relation.ReferenceRanges{"ast": relation.NewReferenceRange(10, 20, "u/l")}
+	result = NewEvaluator().SetReferenceRanges(ranges).Evaluate(newStudy(), patient)
+	a.Equal(Ineligible, result.Status)
+}
+
+func TestOrCriterion(t *testing.T) {
+	a := assert.New(t)
+
+	rs := relation.Relations{
+		relation.Parse(`{"id":"200","name":"age","lower":{"incl":true,"value":"18"},"variableType":"numerical","score":1}`),
+		relation.Parse(`{"id":"202","name":"weight","unit":"kg","lower":{"incl":false,"value":"50"},"variableType":"numerical","score":1}`),
+	}
+	c := criteria.NewCriterion("age ≥ 18 or weight > 50 kg", 1, rs)
+	e := NewEvaluator()
+
+	reason := e.evaluateCriterion(c, NewPatient("P1").SetMeasurement("200", 16, ""))
+	a.Equal(Indeterminate, reason.Status)
+	a.Len(reason.Failed, 1)
+	a.Len(reason.Missing, 1)
+
+	reason = e.evaluateCriterion(c, NewPatient("P1").SetMeasurement("200", 16, "").SetMeasurement("202", 132, "lb"))
+	a.Equal(Eligible, reason.Status)
+	a.Empty(reason.Failed)
+
+	reason = e.evaluateCriterion(c, NewPatient("P1").SetMeasurement("200", 16, "").SetMeasurement("202", 88, "lb"))
+	a.Equal(Ineligible, reason.Status)
+	a.Len(reason.Failed, 2)
+}
+
+func TestNegatedRange(t *testing.T) {
+	a := assert.New(t)
+
+	r := relation.Parse(`{"id":"200","name":"age","lower":{"incl":false,"value":"65"},"upper":{"incl":false,"value":"18"},"variableType":"numerical","score":1}`)
+	e := NewEvaluator()
+	a.Equal(Eligible, e.evaluateMeasurement(r, Measurement{Value: 70}))
+	a.Equal(Eligible, e.evaluateMeasurement(r, Measurement{Value: 16}))
+	a.Equal(Ineligible, e.evaluateMeasurement(r, Measurement{Value: 65}))
+}
+
+func TestParsePatient(t *testing.T) {
+	a := assert.New(t)
+
+	p, err := ParsePatient(`{"id":"P2","measurements":{"411":{"value":30,"unit":"u/l","reference":{"lower":10,"upper":40,"unit":"u/l"}}},"values":{"100":["1"]}}`)
+	a.NoError(err)
+	a.Equal(NewPatient("P2").SetLabMeasurement("411", 30, "u/l", relation.NewReferenceRange(10, 40, "u/l")).SetValues("100", "1"), p)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package eligibility
+
+import (
+	"encoding/json"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+)
+
+// Measurement defines a numerical value of a patient with its unit. A laboratory
+// measurement may carry the reference range of the laboratory, which is used to
+// evaluate relative criteria, such as 'AST ≤ 2.5 x ULN'.
+type Measurement struct {
+	Value     float64                  `json:"value"`
+	Unit      string                   `json:"unit,omitempty"`
+	Reference *relation.ReferenceRange `json:"reference,omitempty"`
+}
+
+// Patient defines the record of a patient to be matched against eligibility criteria.
+// Numerical variables have measurements and categorical variables have values, such as
+// ordinal levels or nominal concept ids.
+type Patient struct {
+	ID           string                       `json:"id,omitempty"`
+	Measurements map[variables.ID]Measurement `json:"measurements,omitempty"`
+	Values       map[variables.ID][]string    `json:"values,omitempty"`
+}
+
+// NewPatient creates a new patient record.
+func NewPatient(id string) *Patient {
+	return &Patient{
+		ID:           id,
+		Measurements: make(map[variables.ID]Measurement),
+		Values:       make(map[variables.ID][]string),
+	}
+}
+
+// ParsePatient parses the json string to the patient record.
+func ParsePatient(s string) (*Patient, error) {
+	p := NewPatient("")
+	if err := json.Unmarshal([]byte(s), p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetMeasurement sets the measured value and unit of the numerical variable.
+func (p *Patient) SetMeasurement(id variables.ID, value float64, unit string) *Patient {
+	p.Measurements[id] = Measurement{Value: value, Unit: unit}
+	return p
+}
+
+// SetLabMeasurement sets the measured value and unit of the laboratory variable
+// with the reference range of the laboratory.
+func (p *Patient) SetLabMeasurement(id variables.ID, value float64, unit string, rr relation.ReferenceRange) *Patient {
+	p.Measurements[id] = Measurement{Value: value, Unit: unit, Reference: &rr}
+	return p
+}
+
+// SetValues sets the values of the categorical variable.
+func (p *Patient) SetValues(id variables.ID, values ...string) *Patient {
+	p.Values[id] = values
+	return p
+}
+
+// Measurement returns the measurement of the numerical variable.
+func (p *Patient) Measurement(id variables.ID) (Measurement, bool) {
+	m, ok := p.Measurements[id]
+	return m, ok
+}
+
+// Value returns the values of the categorical variable.
+func (p *Patient) Value(id variables.ID) ([]string, bool) {
+	v, ok := p.Values[id]
+	return v, ok
+}
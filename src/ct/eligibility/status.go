@@ -0,0 +1,54 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package eligibility
+
+import (
+	"strings"
+)
+
+// Status defines the outcome of evaluating eligibility criteria for a patient.
+type Status int
+
+const (
+	// Indeterminate is the status when the patient record lacks data to decide
+	Indeterminate Status = iota
+	// Eligible is the status when the patient meets the criteria
+	Eligible
+	// Ineligible is the status when the patient fails a criterion
+	Ineligible
+)
+
+// ParseStatus converts a string to an eligibility status.
+func ParseStatus(s string) Status {
+	switch strings.ToLower(s) {
+	case "eligible":
+		return Eligible
+	case "ineligible":
+		return Ineligible
+	default:
+		return Indeterminate
+	}
+}
+
+// String converts the status to a string.
+func (s Status) String() string {
+	switch s {
+	case Eligible:
+		return "eligible"
+	case Ineligible:
+		return "ineligible"
+	default:
+		return "indeterminate"
+	}
+}
+
+// MarshalText converts the status to a text for json encoding.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText converts the text to the status for json decoding.
+func (s *Status) UnmarshalText(text []byte) error {
+	*s = ParseStatus(string(text))
+	return nil
+}
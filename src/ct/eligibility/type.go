@@ -41,3 +41,14 @@ func (t Type) String() string {
 		return "unknown"
 	}
 }
+
+// MarshalText converts the type to a text for json encoding.
+func (t Type) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText converts the text to the type for json decoding.
+func (t *Type) UnmarshalText(text []byte) error {
+	*t = ParseType(string(text))
+	return nil
+}
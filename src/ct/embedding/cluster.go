@@ -0,0 +1,67 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package embedding
+
+import (
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
+)
+
+// cluster defines the members of a cluster and the sum of their vectors.
+type cluster struct {
+	members criteria.Criteria
+	vectors [][]float64
+	sum     []float64
+}
+
+// add adds the criterion and its vector to the cluster.
+func (c *cluster) add(cr *criteria.Criterion, v []float64) {
+	c.members = append(c.members, cr)
+	c.vectors = append(c.vectors, v)
+	for i := range c.sum {
+		c.sum[i] += v[i]
+	}
+}
+
+// Cluster clusters the criteria by the vectors of their texts and sets their ClusterID and ClusterTopic.
+// The criteria are processed in order: a criterion joins the cluster whose centroid is most similar
+// to it if the cosine similarity is at least threshold, and otherwise it starts a new cluster.
+// Cluster ids start at 1, and criteria without a vector keep the id 0. The topic of a cluster
+// is the text of the member closest to the centroid. It returns the number of clusters.
+func (e *Embeddings) Cluster(cs criteria.Criteria, threshold float64) int {
+	var clusters []*cluster
+	for _, cr := range cs {
+		v, ok := e.Vector(cr.String())
+		if !ok {
+			continue
+		}
+		var best *cluster
+		bestSim := threshold
+		for _, c := range clusters {
+			if sim := Cosine(v, c.sum); sim >= bestSim {
+				best = c
+				bestSim = sim
+			}
+		}
+		if best == nil {
+			best = &cluster{sum: make([]float64, e.dim)}
+			clusters = append(clusters, best)
+		}
+		best.add(cr, v)
+	}
+
+	for i, c := range clusters {
+		topic := c.members[0].String()
+		maxSim := -2.0
+		for j, v := range c.vectors {
+			if sim := Cosine(v, c.sum); sim > maxSim {
+				topic = c.members[j].String()
+				maxSim = sim
+			}
+		}
+		for _, cr := range c.members {
+			cr.ClusterID = i + 1
+			cr.ClusterTopic = topic
+		}
+	}
+	return len(clusters)
+}
@@ -0,0 +1,235 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package embedding
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/text"
+
+	"github.com/golang/glog"
+)
+
+// Number is the token of numbers in the training text of the embeddings.
+const Number = "@NUMBER"
+
+var reToken = regexp.MustCompile(`[\p{L}\p{N}]+(?:[-'.][\p{L}\p{N}]+)*|[^\s\p{L}\p{N}]`)
+
+// Embeddings defines word vectors and optional word weights for averaging them to term vectors.
+type Embeddings struct {
+	vectors map[string][]float64
+	weights map[string]float64
+	dim     int
+}
+
+// New creates new empty embeddings of the dimension.
+func New(dim int) *Embeddings {
+	return &Embeddings{vectors: make(map[string][]float64), weights: make(map[string]float64), dim: dim}
+}
+
+// Size returns the number of word vectors.
+func (e *Embeddings) Size() int {
+	return len(e.vectors)
+}
+
+// Dim returns the dimension of the vectors.
+func (e *Embeddings) Dim() int {
+	return e.dim
+}
+
+// Add adds the vector of the word.
+func (e *Embeddings) Add(word string, vector []float64) error {
+	if len(vector) != e.dim {
+		return fmt.Errorf("vector of %q has dimension %d, %d needed", word, len(vector), e.dim)
+	}
+	e.vectors[word] = vector
+	return nil
+}
+
+// open opens the file for reading. Files with the suffix .gz are decompressed.
+func open(fname string) (io.ReadCloser, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(fname, ".gz") {
+		return f, nil
+	}
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, f}, nil
+}
+
+// Load loads word vectors from a file in the fastText text format, such as word_embeddings.vec
+// written by src/embedding/train_embeddings.py, with a line per word: the word and the vector values
+// separated by spaces. An optional header line has the number of words and the dimension.
+func Load(fname string) (*Embeddings, error) {
+	f, err := open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var e *Embeddings
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineCnt := 0
+	for scanner.Scan() {
+		lineCnt++
+		values := strings.Fields(scanner.Text())
+		if len(values) == 0 {
+			continue
+		}
+		if lineCnt == 1 && len(values) == 2 {
+			if _, err := strconv.Atoi(values[0]); err == nil {
+				continue
+			}
+		}
+		if len(values) < 2 {
+			return nil, fmt.Errorf("%s: line %d: too few columns", fname, lineCnt)
+		}
+		vector := make([]float64, len(values)-1)
+		for i, v := range values[1:] {
+			if vector[i], err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("%s: line %d: %v", fname, lineCnt, err)
+			}
+		}
+		if e == nil {
+			e = New(len(vector))
+		}
+		if err := e.Add(values[0], vector); err != nil {
+			return nil, fmt.Errorf("%s: line %d: %v", fname, lineCnt, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	if e == nil {
+		return nil, fmt.Errorf("%s: no vectors", fname)
+	}
+	glog.Infof("Number of word vectors loaded: %d, Dimension: %d\n", e.Size(), e.Dim())
+
+	return e, nil
+}
+
+// LoadFrequencies loads word frequencies from a file, such as word_embeddings.freq.gz, with a line
+// per word: the word and its count separated by a space. The words are weighted by a/(a + p(w)),
+// where p(w) is the relative frequency of the word, so that frequent words count less in term vectors.
+func (e *Embeddings) LoadFrequencies(fname string, a float64) error {
+	f, err := open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	counts := make(map[string]float64)
+	total := 0.0
+	scanner := bufio.NewScanner(f)
+	lineCnt := 0
+	for scanner.Scan() {
+		lineCnt++
+		values := strings.Fields(scanner.Text())
+		if len(values) == 0 {
+			continue
+		}
+		if len(values) != 2 {
+			return fmt.Errorf("%s: line %d: 2 columns needed", fname, lineCnt)
+		}
+		cnt, err := strconv.ParseFloat(values[1], 64)
+		if err != nil {
+			return fmt.Errorf("%s: line %d: %v", fname, lineCnt, err)
+		}
+		counts[values[0]] = cnt
+		total += cnt
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %v", fname, err)
+	}
+	for w, cnt := range counts {
+		e.weights[w] = a / (a + cnt/total)
+	}
+	glog.Infof("Number of word frequencies loaded: %d\n", len(counts))
+
+	return nil
+}
+
+// tokenize splits the term to lowercase tokens as in the training text of the embeddings.
+func tokenize(s string) []string {
+	tokens := reToken.FindAllString(strings.ToLower(s), -1)
+	for i, t := range tokens {
+		if text.IsNumber(t) {
+			tokens[i] = Number
+		}
+	}
+	return tokens
+}
+
+// Vector returns the unit-length weighted average of the word vectors of the term. Words without
+// a vector are disregarded, and words without a frequency have weight 1. It returns false if no
+// word of the term has a vector.
+func (e *Embeddings) Vector(term string) ([]float64, bool) {
+	v := make([]float64, e.dim)
+	found := false
+	for _, t := range tokenize(term) {
+		u, ok := e.vectors[t]
+		if !ok {
+			continue
+		}
+		w, ok := e.weights[t]
+		if !ok {
+			w = 1
+		}
+		for i := range v {
+			v[i] += w * u[i]
+		}
+		found = true
+	}
+	if !found || !normalize(v) {
+		return nil, false
+	}
+	return v, true
+}
+
+// normalize scales the vector to unit length. It returns false if the vector is zero.
+func normalize(v []float64) bool {
+	norm := 0.0
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm == 0 {
+		return false
+	}
+	norm = math.Sqrt(norm)
+	for i := range v {
+		v[i] /= norm
+	}
+	return true
+}
+
+// Cosine returns the cosine similarity of the vectors.
+func Cosine(a, b []float64) float64 {
+	dot, na, nb := 0.0, 0.0, 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package embedding
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const vectors = `6 3
+heart 1 0 0
+cardiac 0.9 0.1 0
+failure 0 1 0
+insufficiency 0.1 0.9 0
+kidney 0 0 1
+@NUMBER 0 0.5 0.5
+`
+
+func write(t *testing.T, name, content string) string {
+	fname := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if filepath.Ext(name) == ".gz" {
+		w := gzip.NewWriter(f)
+		defer w.Close()
+		w.Write([]byte(content))
+	} else {
+		f.WriteString(content)
+	}
+	return fname
+}
+
+func TestLoad(t *testing.T) {
+	a := assert.New(t)
+
+	e, err := Load(write(t, "vectors.vec.gz", vectors))
+	a.NoError(err)
+	a.Equal(6, e.Size())
+	a.Equal(3, e.Dim())
+
+	v, ok := e.Vector("Heart Failure")
+	a.True(ok)
+	a.InDeltaSlice([]float64{0.7071, 0.7071, 0}, v, 1e-4)
+	v, ok = e.Vector("stage 3")
+	a.True(ok)
+	a.InDeltaSlice([]float64{0, 0.7071, 0.7071}, v, 1e-4)
+	_, ok = e.Vector("unknown words")
+	a.False(ok)
+
+	a.NoError(e.LoadFrequencies(write(t, "vectors.freq", "heart 1\nfailure 9\n"), 0.1))
+	v, _ = e.Vector("heart failure")
+	a.Greater(v[0], v[1])
+
+	fname := write(t, "bad.vec", "heart 1 0 0\nfailure 1 0\n")
+	_, err = Load(fname)
+	a.EqualError(err, fname+`: line 2: vector of "failure" has dimension 2, 3 needed`)
+}
+
+func TestCluster(t *testing.T) {
+	a := assert.New(t)
+
+	e, err := Load(write(t, "vectors.vec", vectors))
+	a.NoError(err)
+	cs := criteria.Criteria{
+		criteria.NewCriterion("heart failure", 0, nil),
+		criteria.NewCriterion("kidney failure", 0, nil),
+		criteria.NewCriterion("cardiac insufficiency", 0, nil),
+		criteria.NewCriterion("unknown", 0, nil),
+		criteria.NewCriterion("kidney", 0, nil),
+	}
+	a.Equal(2, e.Cluster(cs, 0.7))
+	a.Equal(1, cs[0].ClusterID)
+	a.Equal(2, cs[1].ClusterID)
+	a.Equal(1, cs[2].ClusterID)
+	a.Equal(0, cs[3].ClusterID)
+	a.Equal(2, cs[4].ClusterID)
+	a.Equal("heart failure", cs[2].ClusterTopic)
+	a.Empty(cs[3].ClusterTopic)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
+)
+
+// GoldConcepts defines a criterion annotated with the concepts that the NEL matcher should
+// link to it. The concepts are vocabulary keys as in the concepts column of the NEL output.
+type GoldConcepts struct {
+	NCT             string   `json:"nct_id"`
+	EligibilityType string   `json:"eligibility_type"`
+	Criterion       string   `json:"criterion"`
+	Concepts        []string `json:"concepts"`
+}
+
+// key returns the key of the criterion.
+func (g *GoldConcepts) key() string {
+	return criterionKey(g.NCT, g.EligibilityType, g.Criterion)
+}
+
+// criterionKey returns the key of the criterion of the study.
+func criterionKey(nct, eligibilityType, criterion string) string {
+	return nct + "\t" + eligibilityType + "\t" + criterion
+}
+
+// ReadGoldConcepts reads gold concepts in the JSON lines format, one criterion per line.
+// Empty lines and lines starting with '#' are skipped.
+func ReadGoldConcepts(r io.Reader) ([]*GoldConcepts, error) {
+	var gold []*GoldConcepts
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == param.Comment {
+			continue
+		}
+		g := &GoldConcepts{}
+		if err := json.Unmarshal([]byte(line), g); err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if len(g.Criterion) == 0 {
+			return nil, fmt.Errorf("line %d: missing criterion", n)
+		}
+		gold = append(gold, g)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return gold, nil
+}
+
+// ReadLinkedConcepts reads the concepts linked to criteria from the tab-separated output of cmd/nel.
+// The concepts of a criterion are the union of the concepts of its matched terms.
+func ReadLinkedConcepts(r io.Reader) (map[string]set.Set, error) {
+	linked := make(map[string]set.Set)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := scanner.Text()
+		if len(line) == 0 || line[0] == param.Comment {
+			continue
+		}
+		values := strings.Split(line, "\t")
+		if len(values) < 3 {
+			return nil, fmt.Errorf("line %d: too few columns, at least 3 needed", n)
+		}
+		key := criterionKey(values[0], values[1], values[2])
+		concepts, ok := linked[key]
+		if !ok {
+			concepts = set.New()
+			linked[key] = concepts
+		}
+		if len(values) > 6 && len(values[6]) > 0 {
+			concepts.Add(strings.Split(values[6], "|")...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return linked, nil
+}
+
+// EvaluateConcepts compares the linked concepts of the gold criteria to the gold concepts.
+// A gold criterion without linked concepts has all of its concepts missing.
+func EvaluateConcepts(gold []*GoldConcepts, linked map[string]set.Set) *Report {
+	report := NewReport()
+	for _, g := range gold {
+		var predicted []string
+		if concepts, ok := linked[g.key()]; ok {
+			predicted = concepts.Slice()
+		}
+		report.AddConcepts(g.EligibilityType, g.Concepts, predicted)
+	}
+	return report
+}
+
+// AddConcepts compares the predicted concepts of a criterion to the gold concepts.
+func (r *Report) AddConcepts(eligibilityType string, gold, predicted []string) {
+	r.Criteria++
+	goldSet := set.New(gold...)
+	predictedSet := set.New(predicted...)
+	for _, c := range goldSet.Slice() {
+		if predictedSet.Contains(c) {
+			r.tp("eligibility_type", eligibilityType)
+		} else {
+			r.Errors[Missing]++
+			r.fn("eligibility_type", eligibilityType)
+		}
+	}
+	for _, c := range predictedSet.Slice() {
+		if !goldSet.Contains(c) {
+			r.Errors[Spurious]++
+			r.fp("eligibility_type", eligibilityType)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package eval
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func numerical(name, unit string, lower, upper *relation.Limit) *relation.Relation {
+	return &relation.Relation{Name: name, Unit: unit, Lower: lower, Upper: upper, VariableType: variables.Numerical}
+}
+
+func TestAddRelations(t *testing.T) {
+	a := assert.New(t)
+
+	gold := relation.Relations{
+		numerical("bmi", "kg/m2", nil, &relation.Limit{Value: "30"}),
+		numerical("age", "year", &relation.Limit{Value: "18", Incl: true}, nil),
+		numerical("a1c", "%", &relation.Limit{Value: "7"}, nil),
+		numerical("weight", "kg", nil, &relation.Limit{Value: "100"}),
+		numerical("egfr", "ml/min/1.73m2", &relation.Limit{Value: "60"}, nil),
+		numerical("platelet_count", "", &relation.Limit{Value: "100000"}, nil),
+	}
+	predicted := relation.Relations{
+		numerical("bmi", "kg/m2", nil, &relation.Limit{Value: "30.0"}),
+		numerical("age", "year", &relation.Limit{Value: "18"}, nil),
+		numerical("a1c", "%", &relation.Limit{Value: "8"}, nil),
+		numerical("weight", "lb", nil, &relation.Limit{Value: "100"}),
+		numerical("creatinine_clearance", "ml/min/1.73m2", &relation.Limit{Value: "60"}, nil),
+		numerical("heart_rate", "", nil, &relation.Limit{Value: "100"}),
+	}
+	r := NewReport()
+	r.AddRelations(gold, predicted)
+
+	a.Equal(1, r.Criteria)
+	a.Equal(Counts{TP: 1, FP: 5, FN: 5}, r.Overall)
+	a.Equal(map[ErrorClass]int{WrongInclusivity: 1, WrongBound: 1, WrongUnit: 1, WrongVariable: 1, Missing: 1, Spurious: 1}, r.Errors)
+	a.Equal(Counts{TP: 1}, *r.Groups["variable"]["bmi"])
+	a.Equal(Counts{FP: 1, FN: 1}, *r.Groups["variable"]["age"])
+	a.Equal(Counts{FN: 1}, *r.Groups["variable"]["egfr"])
+	a.Equal(Counts{FP: 1}, *r.Groups["variable"]["creatinine_clearance"])
+	a.Equal(Counts{TP: 1, FP: 5, FN: 5}, *r.Groups["variable_type"]["numerical"])
+}
+
+func TestAddCategoricalRelations(t *testing.T) {
+	a := assert.New(t)
+
+	gold := relation.Relations{
+		{Name: "gender", Value: []string{"female", "male"}, VariableType: variables.Nominal},
+		{Name: "diabetes", Value: []string{"false"}, VariableType: variables.Boolean},
+	}
+	predicted := relation.Relations{
+		{Name: "diabetes", Value: []string{"true"}, VariableType: variables.Boolean},
+		{Name: "gender", Value: []string{"male", "female"}, VariableType: variables.Nominal},
+	}
+	r := NewReport()
+	r.AddRelations(gold, predicted)
+
+	a.Equal(Counts{TP: 1, FP: 1, FN: 1}, r.Overall)
+	a.Equal(map[ErrorClass]int{WrongBound: 1}, r.Errors)
+	a.Equal(Counts{TP: 1}, *r.Groups["variable_type"]["nominal"])
+}
+
+func TestReadGoldCriteria(t *testing.T) {
+	a := assert.New(t)
+
+	input := `# gold criteria
+{"nct_id":"NCT00000001","eligibility_type":"inclusion","criterion":"BMI < 30","relations":[{"name":"bmi","unit":"kg/m2","upper":{"incl":false,"value":"30"}}]}
+
+{"nct_id":"NCT00000001","eligibility_type":"exclusion","criterion":"pregnant","relations":[]}
+`
+	gold, err := ReadGoldCriteria(strings.NewReader(input))
+	a.NoError(err)
+	a.Len(gold, 2)
+	a.Equal("BMI < 30", gold[0].Criterion)
+	a.Equal(variables.Numerical, gold[0].Relations[0].VariableType)
+	a.Equal("30", gold[0].Relations[0].Upper.Value)
+	a.Empty(gold[1].Relations)
+
+	_, err = ReadGoldCriteria(strings.NewReader(`{"eligibility_type":"inclusion","criterion":"BMI < 30"}` + "\n{"))
+	a.EqualError(err, "line 2: unexpected end of JSON input")
+	_, err = ReadGoldCriteria(strings.NewReader(`{"eligibility_type":"inclusion"}`))
+	a.EqualError(err, "line 1: missing criterion")
+	_, err = ReadGoldCriteria(strings.NewReader(`{"eligibility_type":"other","criterion":"BMI < 30"}`))
+	a.EqualError(err, `line 1: eligibility_type must be inclusion or exclusion: "other"`)
+}
+
+func TestEvaluateRelations(t *testing.T) {
+	a := assert.New(t)
+
+	input := `{"eligibility_type":"inclusion","criterion":"HbA1c between 7% and 10%","relations":[{"name":"a1c","unit":"%","lower":{"incl":true,"value":"7"},"upper":{"incl":true,"value":"10"}}]}
+{"eligibility_type":"inclusion","criterion":"age > 18 years","relations":[{"name":"age","unit":"year","lower":{"incl":true,"value":"18"}}]}
+{"eligibility_type":"exclusion","criterion":"BMI > 40","relations":[{"name":"bmi","upper":{"incl":true,"value":"40"}}]}
+`
+	gold, err := ReadGoldCriteria(strings.NewReader(input))
+	a.NoError(err)
+	r := EvaluateRelations(gold, 0)
+	a.Equal(3, r.Criteria)
+	a.Equal(Counts{TP: 2, FP: 1, FN: 1}, r.Overall)
+	a.Equal(map[ErrorClass]int{WrongInclusivity: 1}, r.Errors)
+
+	r = EvaluateRelations(gold, 1)
+	a.Equal(Counts{FN: 3}, r.Overall)
+	a.Equal(map[ErrorClass]int{Missing: 3}, r.Errors)
+}
+
+func TestEvaluateConcepts(t *testing.T) {
+	a := assert.New(t)
+
+	goldInput := `{"nct_id":"NCT00000001","eligibility_type":"inclusion","criterion":"type 2 diabetes","concepts":["Diabetes Mellitus, Type 2"]}
+{"nct_id":"NCT00000001","eligibility_type":"exclusion","criterion":"heart failure or stroke","concepts":["Heart Failure","Stroke"]}
+{"nct_id":"NCT00000002","eligibility_type":"exclusion","criterion":"pregnancy","concepts":["Pregnancy"]}
+`
+	nelInput := "#nct_id\teligibility_type\tcriterion\tlabel\tterm\tner_score\tconcepts\ttree_numbers\tnel_score\ttemporal\n" +
+		"NCT00000001\tinclusion\ttype 2 diabetes\tchronic_disease\ttype 2 diabetes\t0.9\tDiabetes Mellitus, Type 2\tC18.452.394.750.149\t1.00\t\n" +
+		"NCT00000001\texclusion\theart failure or stroke\tchronic_disease\theart failure\t0.9\tHeart Failure|Heart Diseases\tC14.280.434\t1.00\t\n" +
+		"NCT00000001\texclusion\theart failure or stroke\tchronic_disease\tstroke\t0.9\n" +
+		"NCT00000003\texclusion\tcancer\tcancer\tcancer\t0.9\tNeoplasms\tC04\t1.00\t\n"
+	gold, err := ReadGoldConcepts(strings.NewReader(goldInput))
+	a.NoError(err)
+	linked, err := ReadLinkedConcepts(strings.NewReader(nelInput))
+	a.NoError(err)
+	a.Len(linked, 3)
+
+	r := EvaluateConcepts(gold, linked)
+	a.Equal(3, r.Criteria)
+	a.Equal(Counts{TP: 2, FP: 1, FN: 2}, r.Overall)
+	a.Equal(map[ErrorClass]int{Missing: 2, Spurious: 1}, r.Errors)
+	a.Equal(Counts{TP: 1}, *r.Groups["eligibility_type"]["inclusion"])
+	a.Equal(Counts{TP: 1, FP: 1, FN: 2}, *r.Groups["eligibility_type"]["exclusion"])
+
+	_, err = ReadLinkedConcepts(strings.NewReader("NCT00000001\tinclusion\n"))
+	a.EqualError(err, "line 1: too few columns, at least 3 needed")
+}
+
+func TestWrite(t *testing.T) {
+	a := assert.New(t)
+
+	r := NewReport()
+	r.AddConcepts("inclusion", []string{"a", "b"}, []string{"a", "c"})
+	var b bytes.Buffer
+	a.NoError(r.Write(&b))
+	expected := "# Evaluated criteria: 1\n" +
+		"#group\tkey\ttp\tfp\tfn\tprecision\trecall\tf1\n" +
+		"overall\tall\t1\t1\t1\t0.500\t0.500\t0.500\n" +
+		"eligibility_type\tinclusion\t1\t1\t1\t0.500\t0.500\t0.500\n" +
+		"\n#error\tcount\n" +
+		"missing\t1\n" +
+		"spurious\t1\n"
+	a.Equal(expected, b.String())
+}
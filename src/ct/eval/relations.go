@@ -0,0 +1,205 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+)
+
+// GoldCriterion defines a criterion annotated with the expected relations. The relations have
+// the JSON shape of relation.Relation and the form of the parser output: the relations of exclusion
+// criteria are negated and numerical limits are in the default unit of the variable.
+type GoldCriterion struct {
+	NCT             string             `json:"nct_id"`
+	EligibilityType string             `json:"eligibility_type"`
+	Criterion       string             `json:"criterion"`
+	Relations       relation.Relations `json:"relations"`
+}
+
+// ReadGoldCriteria reads gold criteria in the JSON lines format, one criterion per line.
+// Empty lines and lines starting with '#' are skipped.
+func ReadGoldCriteria(r io.Reader) ([]*GoldCriterion, error) {
+	var gold []*GoldCriterion
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == param.Comment {
+			continue
+		}
+		g := &GoldCriterion{}
+		if err := json.Unmarshal([]byte(line), g); err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if len(g.Criterion) == 0 {
+			return nil, fmt.Errorf("line %d: missing criterion", n)
+		}
+		if g.EligibilityType != "inclusion" && g.EligibilityType != "exclusion" {
+			return nil, fmt.Errorf("line %d: eligibility_type must be inclusion or exclusion: %q", n, g.EligibilityType)
+		}
+		for _, rel := range g.Relations {
+			if rel.VariableType == variables.Unknown {
+				rel.VariableType = variableType(rel.Name)
+			}
+		}
+		gold = append(gold, g)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return gold, nil
+}
+
+// variableType returns the type of the variable in the catalog, or Unknown if the variable is not found.
+func variableType(name string) variables.Type {
+	catalog := variables.Get()
+	if id, ok := catalog.ID(name); ok {
+		if v := catalog.Variable(id); v != nil {
+			return v.Kind
+		}
+	}
+	return variables.Unknown
+}
+
+// EvaluateRelations parses the gold criteria with the CFG parser and compares the parsed relations
+// to the gold relations. Parsed relations whose score is not above minScore are disregarded.
+func EvaluateRelations(gold []*GoldCriterion, minScore float64) *Report {
+	report := NewReport()
+	for _, g := range gold {
+		cs := studies.ParseCriterion(g.Criterion, g.EligibilityType == "exclusion")
+		var predicted relation.Relations
+		for _, r := range cs.Relations() {
+			if r.Score > minScore {
+				predicted = append(predicted, r)
+			}
+		}
+		report.AddRelations(g.Relations, predicted)
+	}
+	return report
+}
+
+// AddRelations compares the predicted relations of a criterion to the gold relations. A prediction
+// is a true positive if it equals a gold relation. Otherwise, a prediction and a gold relation of
+// the same variable are an error of the first differing field: unit, inclusivity, or bound.
+// A prediction and a gold relation that differ only by the variable are a wrong variable.
+// The other gold relations are missing and the other predictions spurious.
+func (r *Report) AddRelations(gold, predicted relation.Relations) {
+	r.Criteria++
+	goldDone := make([]bool, len(gold))
+	predictedDone := make([]bool, len(predicted))
+
+	pair := func(match func(g, p *relation.Relation) bool, add func(g, p *relation.Relation)) {
+		for i, g := range gold {
+			if goldDone[i] {
+				continue
+			}
+			for j, p := range predicted {
+				if !predictedDone[j] && match(g, p) {
+					add(g, p)
+					goldDone[i] = true
+					predictedDone[j] = true
+					break
+				}
+			}
+		}
+	}
+	pair(func(g, p *relation.Relation) bool {
+		return g.Name == p.Name && g.Unit == p.Unit && sameConstraint(g, p, true)
+	}, func(g, p *relation.Relation) {
+		r.tp(relationKeys(g)...)
+	})
+	pair(func(g, p *relation.Relation) bool {
+		return g.Name == p.Name
+	}, func(g, p *relation.Relation) {
+		switch {
+		case g.Unit != p.Unit:
+			r.Errors[WrongUnit]++
+		case sameConstraint(g, p, false):
+			r.Errors[WrongInclusivity]++
+		default:
+			r.Errors[WrongBound]++
+		}
+		r.fn(relationKeys(g)...)
+		r.fp(relationKeys(p)...)
+	})
+	pair(func(g, p *relation.Relation) bool {
+		return sameConstraint(g, p, true)
+	}, func(g, p *relation.Relation) {
+		r.Errors[WrongVariable]++
+		r.fn(relationKeys(g)...)
+		r.fp(relationKeys(p)...)
+	})
+	for i, g := range gold {
+		if !goldDone[i] {
+			r.Errors[Missing]++
+			r.fn(relationKeys(g)...)
+		}
+	}
+	for j, p := range predicted {
+		if !predictedDone[j] {
+			r.Errors[Spurious]++
+			r.fp(relationKeys(p)...)
+		}
+	}
+}
+
+// relationKeys returns the report groups and keys of the relation.
+func relationKeys(r *relation.Relation) []string {
+	return []string{"variable_type", r.VariableType.String(), "variable", r.Name}
+}
+
+// sameConstraint returns true if the relations have the same limits and values,
+// disregarding the variables and units. The inclusivity of the limits is compared if incl is true.
+func sameConstraint(g, p *relation.Relation, incl bool) bool {
+	return sameLimit(g.Lower, p.Lower, incl) && sameLimit(g.Upper, p.Upper, incl) && sameValues(g.Value, p.Value)
+}
+
+// sameLimit returns true if the limits are both nil or have the same value and reference.
+func sameLimit(a, b *relation.Limit, incl bool) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if a.Reference != b.Reference || (incl && a.Incl != b.Incl) {
+		return false
+	}
+	return sameValue(a.Value, b.Value)
+}
+
+// sameValue returns true if the values are equal numbers or, if not numbers, equal strings.
+func sameValue(a, b string) bool {
+	x, errX := strconv.ParseFloat(a, 64)
+	y, errY := strconv.ParseFloat(b, 64)
+	if errX != nil || errY != nil {
+		return a == b
+	}
+	return math.Abs(x-y) <= 1e-6*math.Max(math.Abs(x), math.Abs(y))
+}
+
+// sameValues returns true if the categorical values are the same sets.
+func sameValues(a, b []string) bool {
+	as := make(map[string]bool)
+	for _, v := range a {
+		as[v] = true
+	}
+	bs := make(map[string]bool)
+	for _, v := range b {
+		if !as[v] {
+			return false
+		}
+		bs[v] = true
+	}
+	return len(as) == len(bs)
+}
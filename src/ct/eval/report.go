@@ -0,0 +1,154 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package eval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ErrorClass defines the kind of a prediction error.
+type ErrorClass string
+
+const (
+	WrongVariable    ErrorClass = "wrong_variable"    // Constraint is right but the variable is not
+	WrongBound       ErrorClass = "wrong_bound"       // Variable is right but a limit or value is not
+	WrongInclusivity ErrorClass = "wrong_inclusivity" // Limits are right but a limit is not inclusive, or vice versa
+	WrongUnit        ErrorClass = "wrong_unit"        // Variable is right but the unit is not
+	Missing          ErrorClass = "missing"           // Gold item has no prediction
+	Spurious         ErrorClass = "spurious"          // Prediction has no gold item
+)
+
+// errorClasses lists the error classes in the order of the report.
+var errorClasses = []ErrorClass{WrongVariable, WrongBound, WrongInclusivity, WrongUnit, Missing, Spurious}
+
+// Counts defines the counts of true positives, false positives, and false negatives.
+type Counts struct {
+	TP int
+	FP int
+	FN int
+}
+
+// Precision returns the precision, or zero if there are no predictions.
+func (c Counts) Precision() float64 {
+	if c.TP+c.FP == 0 {
+		return 0
+	}
+	return float64(c.TP) / float64(c.TP+c.FP)
+}
+
+// Recall returns the recall, or zero if there are no gold items.
+func (c Counts) Recall() float64 {
+	if c.TP+c.FN == 0 {
+		return 0
+	}
+	return float64(c.TP) / float64(c.TP+c.FN)
+}
+
+// F1 returns the harmonic mean of precision and recall.
+func (c Counts) F1() float64 {
+	p, r := c.Precision(), c.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+// Report defines the evaluation counts overall and per group, such as per variable,
+// and the number of errors per error class.
+type Report struct {
+	Criteria int
+	Overall  Counts
+	Groups   map[string]map[string]*Counts // Counts by group name and key
+	Errors   map[ErrorClass]int
+}
+
+// NewReport creates a new empty report.
+func NewReport() *Report {
+	return &Report{Groups: make(map[string]map[string]*Counts), Errors: make(map[ErrorClass]int)}
+}
+
+// counts returns the counts of the key in the group.
+func (r *Report) counts(group, key string) *Counts {
+	keys, ok := r.Groups[group]
+	if !ok {
+		keys = make(map[string]*Counts)
+		r.Groups[group] = keys
+	}
+	c, ok := keys[key]
+	if !ok {
+		c = &Counts{}
+		keys[key] = c
+	}
+	return c
+}
+
+// tp adds a true positive to the overall counts and to the keys of the groups,
+// given as alternating group names and keys.
+func (r *Report) tp(groupKeys ...string) {
+	r.Overall.TP++
+	for i := 0; i+1 < len(groupKeys); i += 2 {
+		r.counts(groupKeys[i], groupKeys[i+1]).TP++
+	}
+}
+
+// fp adds a false positive like tp.
+func (r *Report) fp(groupKeys ...string) {
+	r.Overall.FP++
+	for i := 0; i+1 < len(groupKeys); i += 2 {
+		r.counts(groupKeys[i], groupKeys[i+1]).FP++
+	}
+}
+
+// fn adds a false negative like tp.
+func (r *Report) fn(groupKeys ...string) {
+	r.Overall.FN++
+	for i := 0; i+1 < len(groupKeys); i += 2 {
+		r.counts(groupKeys[i], groupKeys[i+1]).FN++
+	}
+}
+
+// Write writes the report as tab-separated tables of the counts and the errors.
+func (r *Report) Write(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# Evaluated criteria: %d\n#group\tkey\ttp\tfp\tfn\tprecision\trecall\tf1\n", r.Criteria); err != nil {
+		return err
+	}
+	if err := writeCounts(w, "overall", "all", r.Overall); err != nil {
+		return err
+	}
+	groups := make([]string, 0, len(r.Groups))
+	for g := range r.Groups {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		keys := make([]string, 0, len(r.Groups[g]))
+		for k := range r.Groups[g] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := writeCounts(w, g, k, *r.Groups[g][k]); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := fmt.Fprint(w, "\n#error\tcount\n"); err != nil {
+		return err
+	}
+	for _, e := range errorClasses {
+		if n, ok := r.Errors[e]; ok {
+			if _, err := fmt.Fprintf(w, "%s\t%d\n", e, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeCounts writes a line of the counts table.
+func writeCounts(w io.Writer, group, key string, c Counts) error {
+	_, err := fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%.3f\t%.3f\t%.3f\n", group, key, c.TP, c.FP, c.FN, c.Precision(), c.Recall(), c.F1())
+	return err
+}
@@ -0,0 +1,32 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package fhir
+
+import (
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
+)
+
+// VocabularyMatcher matches text to the concepts of an indexed vocabulary, such as MeSH or UMLS.
+type VocabularyMatcher struct {
+	vocabulary *taxonomy.Taxonomy
+	system     string
+	margin     float64
+}
+
+// NewVocabularyMatcher creates a new matcher of the vocabulary, whose concept ids are codes of the system.
+// The concepts within the margin of the best match score are returned.
+func NewVocabularyMatcher(vocabulary *taxonomy.Taxonomy, system string, margin float64) *VocabularyMatcher {
+	return &VocabularyMatcher{vocabulary: vocabulary, system: system, margin: margin}
+}
+
+// Match returns the codings of the best matching concepts of the text. Concepts without an id are skipped.
+func (m *VocabularyMatcher) Match(text string) []Coding {
+	var codings []Coding
+	for _, t := range m.vocabulary.Match(text, m.margin, set.New()) {
+		if t.Value > 0 && len(t.ID) > 0 {
+			codings = append(codings, Coding{System: m.system, Code: t.ID, Display: t.Key})
+		}
+	}
+	return codings
+}
@@ -0,0 +1,33 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package fhir
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVocabularyMatcher(t *testing.T) {
+	a := assert.New(t)
+
+	root := taxonomy.NewNode("root")
+	obesity := taxonomy.NewNode("Obesity")
+	obesity.SetID("M0015124")
+	obesity.AddSynonym("Obesity")
+	root.AddChild(obesity)
+	custom := taxonomy.NewNode("Heart Failure")
+	custom.AddSynonym("Heart Failure")
+	root.AddChild(custom)
+	vocabulary := taxonomy.New(root)
+	vocabulary.Normalize(func(s string) (string, string) { return strings.ToLower(s), strings.ToLower(s) })
+	vocabulary.SetBaseIndex()
+
+	m := NewVocabularyMatcher(vocabulary, MeSHSystem, 0.02)
+	a.Equal([]Coding{{System: MeSHSystem, Code: "M0015124", Display: "Obesity"}}, m.Match("Obesity"))
+	a.Empty(m.Match("Heart Failure"))
+	a.Empty(m.Match("informed consent"))
+}
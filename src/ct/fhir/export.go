@@ -0,0 +1,476 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package fhir
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+)
+
+// Code systems of the exported codings and identifiers.
+const (
+	NCTSystem      = "https://clinicaltrials.gov"
+	VariableSystem = "https://github.com/facebookresearch/Clinical-Trial-Parser/variables"
+	MeSHSystem     = "http://id.nlm.nih.gov/mesh"
+	UMLSSystem     = "http://www.nlm.nih.gov/research/umls"
+	UCUMSystem     = "http://unitsofmeasure.org"
+)
+
+// CombinationExtension is the R5 element EvidenceVariable.characteristicCombination as an R4 extension.
+// Its code is any-of or all-of.
+const CombinationExtension = "http://hl7.org/fhir/5.0/StructureDefinition/extension-EvidenceVariable.characteristicCombination"
+
+// urlNamespace is the UUID namespace of URLs, used for the name-based UUIDs of the resources.
+var urlNamespace = []byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// ucumCodes maps unit names of the unit catalog to UCUM codes.
+var ucumCodes = map[string]string{
+	"%":              "%",
+	"kg":             "kg",
+	"g":              "g",
+	"mg":             "mg",
+	"lb":             "[lb_av]",
+	"msec":           "ms",
+	"sec":            "s",
+	"hour":           "h",
+	"day":            "d",
+	"week":           "wk",
+	"month":          "mo",
+	"year":           "a",
+	"ml/min":         "mL/min",
+	"g/day":          "g/d",
+	"mg/day":         "mg/d",
+	"g/dl":           "g/dL",
+	"ng/dl":          "ng/dL",
+	"ng/ml":          "ng/mL",
+	"g/l":            "g/L",
+	"mg/dl":          "mg/dL",
+	"mg/l":           "mg/L",
+	"m/ul":           "10*6/uL",
+	"k/ul":           "10*3/uL",
+	"cells/ul":       "/uL",
+	"cells/ml":       "/mL",
+	"cells/l":        "/L",
+	"umol/l":         "umol/L",
+	"mmol/l":         "mmol/L",
+	"ml/min/1.73_m2": "mL/min/{1.73_m2}",
+	"meq/l":          "meq/L",
+	"mm":             "mm",
+	"cm":             "cm",
+	"m":              "m",
+	"inches":         "[in_i]",
+	"mmhg":           "mm[Hg]",
+	"cmh2o":          "cm[H2O]",
+	"kg/m2":          "kg/m2",
+	"iu/l":           "[IU]/L",
+	"c":              "Cel",
+	"f":              "[degF]",
+	"breaths/min":    "/min",
+	"beats/min":      "/min",
+	"/min":           "/min",
+}
+
+// ConceptMatcher matches text, such as a condition or a criterion, to vocabulary
+// concepts, such as MeSH descriptors.
+type ConceptMatcher interface {
+	Match(text string) []Coding
+}
+
+// Exporter converts parsed studies to FHIR R4 resources.
+type Exporter struct {
+	units    *units.Units
+	concepts ConceptMatcher
+}
+
+// NewExporter creates a new exporter that uses the unit catalog for the unit names.
+func NewExporter() *Exporter {
+	return &Exporter{units: units.Get()}
+}
+
+// SetConceptMatcher sets the matcher of conditions and criteria to vocabulary concepts.
+// Without a matcher, conditions and criteria are exported as text only.
+func (e *Exporter) SetConceptMatcher(m ConceptMatcher) {
+	e.concepts = m
+}
+
+// Bundle converts the parsed study to a collection bundle of a ResearchStudy, its enrollment Group,
+// and EvidenceVariables. The characteristics of the group are AND-ed, so they encode only the criteria
+// of a single valid relation, and exclusion criteria have exclude=true. The main evidence variable lists
+// the criteria as stated in the text. A criterion of alternative relations is an evidence variable of
+// its own, which combines the relations and which the main evidence variable refers to. The resources
+// reference each other by name-based UUIDs, so repeated exports are identical.
+func (e *Exporter) Bundle(s *studies.Study) *Bundle {
+	nct := s.NCT()
+	studyURL := resourceURL(nct, "ResearchStudy")
+	groupURL := resourceURL(nct, "Group")
+
+	study := &ResearchStudy{
+		ResourceType: "ResearchStudy",
+		ID:           resourceID(nct),
+		Identifier:   []Identifier{{System: NCTSystem, Value: nct}},
+		Title:        s.Name(),
+		Status:       "active",
+		Enrollment:   []Reference{{Reference: groupURL}},
+	}
+	for _, c := range s.Conditions() {
+		if len(strings.TrimSpace(c)) > 0 {
+			study.Condition = append(study.Condition, e.concept(c))
+		}
+	}
+
+	group := &Group{
+		ResourceType: "Group",
+		ID:           resourceID(nct + "-enrollment"),
+		Type:         "person",
+		Actual:       false,
+		Name:         "Eligible population of " + nct,
+	}
+	add := func(cs criteria.Criteria, exclude bool) {
+		for _, c := range cs {
+			if rs := c.Relations(); len(rs) == 1 {
+				if ch, ok := e.characteristic(rs[0], exclude); ok {
+					group.Characteristic = append(group.Characteristic, ch)
+				}
+			}
+		}
+	}
+	add(s.InclusionCriteria(), false)
+	add(s.ExclusionCriteria(), true)
+
+	bundle := &Bundle{
+		ResourceType: "Bundle",
+		ID:           resourceID(nct + "-bundle"),
+		Type:         "collection",
+		Entry: []BundleEntry{
+			{FullURL: studyURL, Resource: study},
+			{FullURL: groupURL, Resource: group},
+		},
+	}
+	bundle.Entry = append(bundle.Entry, e.evidenceVariables(s)...)
+	return bundle
+}
+
+// evidenceVariables converts the criteria of the study to bundle entries of evidence variables,
+// or returns nil if the study has no criteria. The main evidence variable codes the criteria by
+// the variables of their relations and by the matched concepts. Each criterion of alternative
+// relations is also an evidence variable that the main one refers to by its canonical url.
+func (e *Exporter) evidenceVariables(s *studies.Study) []BundleEntry {
+	inclusions, exclusions := s.Criteria()
+	if len(inclusions)+len(exclusions) == 0 {
+		return nil
+	}
+	v := &EvidenceVariable{
+		ResourceType: "EvidenceVariable",
+		ID:           resourceID(s.NCT() + "-eligibility"),
+		Name:         "Eligibility",
+		Title:        "Eligibility criteria of " + s.NCT(),
+		Status:       "active",
+	}
+	entries := []BundleEntry{{FullURL: resourceURL(s.NCT(), "EvidenceVariable"), Resource: v}}
+	add := func(texts []string, parsed criteria.Criteria, exclude bool) {
+		codings := variableCodings(parsed)
+		combinations := make(map[string][]string)
+		for _, c := range parsed {
+			if cv := e.combination(s.NCT(), len(entries), c, exclude); cv != nil {
+				url := resourceURL(s.NCT(), cv.ID)
+				entries = append(entries, BundleEntry{FullURL: url, Resource: cv})
+				combinations[c.String()] = append(combinations[c.String()], url)
+			}
+		}
+		for _, text := range texts {
+			concept := e.concept(text)
+			concept.Coding = append(codings[text], concept.Coding...)
+			v.Characteristic = append(v.Characteristic, EvidenceCharacteristic{
+				Description:               text,
+				DefinitionCodeableConcept: &concept,
+				Exclude:                   exclude,
+			})
+			for _, url := range combinations[text] {
+				v.Characteristic = append(v.Characteristic, EvidenceCharacteristic{
+					Description:         text,
+					DefinitionCanonical: url,
+					Exclude:             exclude,
+				})
+			}
+		}
+	}
+	add(inclusions, s.InclusionCriteria(), false)
+	add(exclusions, s.ExclusionCriteria(), true)
+	return entries
+}
+
+// combination converts a criterion of alternative relations to an evidence variable with the
+// relations as characteristics, or returns nil if the criterion has fewer than two valid relations.
+// The alternatives of an inclusion criterion are combined by any-of. The relations of an exclusion
+// criterion are negated by the parser, so they are negated back to the relations as stated, which
+// are combined by all-of: the criterion excludes the persons who have all of them.
+func (e *Exporter) combination(nct string, i int, c *criteria.Criterion, exclude bool) *EvidenceVariable {
+	var rs relation.Relations
+	for _, r := range c.Relations() {
+		if r.Score > 0 {
+			rs = append(rs, r)
+		}
+	}
+	if len(rs) < 2 {
+		return nil
+	}
+	code := "any-of"
+	if exclude {
+		code = "all-of"
+	}
+	v := &EvidenceVariable{
+		ResourceType: "EvidenceVariable",
+		ID:           resourceID(fmt.Sprintf("%s-criterion-%d", nct, i)),
+		Extension:    []Extension{{URL: CombinationExtension, Extension: []Extension{{URL: "code", ValueCode: code}}}},
+		Title:        c.String(),
+		Status:       "active",
+	}
+	for _, r := range rs {
+		if exclude {
+			r = r.Negated()
+		}
+		coding := variableCoding(r)
+		v.Characteristic = append(v.Characteristic, EvidenceCharacteristic{
+			Description:               description(r),
+			DefinitionCodeableConcept: &CodeableConcept{Coding: []Coding{coding}, Text: coding.Display},
+		})
+	}
+	return v
+}
+
+// variableCodings returns the codings of the variables of the valid relations by criterion text.
+func variableCodings(cs criteria.Criteria) map[string][]Coding {
+	codings := make(map[string][]Coding)
+	seen := make(map[string]bool)
+	for _, c := range cs {
+		text := c.String()
+		for _, r := range c.Relations() {
+			key := text + "\t" + string(r.ID)
+			if r.Score == 0 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			codings[text] = append(codings[text], variableCoding(r))
+		}
+	}
+	return codings
+}
+
+// variableCoding returns the coding of the variable of the relation.
+func variableCoding(r *relation.Relation) Coding {
+	display := r.DisplayName
+	if len(display) == 0 {
+		display = r.Name
+	}
+	return Coding{System: VariableSystem, Code: string(r.ID), Display: display}
+}
+
+// description returns the human readable relation, named by the variable name if it has no display name.
+func description(r *relation.Relation) string {
+	if len(r.DisplayName) > 0 {
+		return r.HumanReadable()
+	}
+	n := *r
+	n.DisplayName = r.Name
+	return n.HumanReadable()
+}
+
+// concept converts the text to a codeable concept with the codings of the matched concepts.
+func (e *Exporter) concept(text string) CodeableConcept {
+	c := CodeableConcept{Text: text}
+	if e.concepts != nil {
+		c.Coding = e.concepts.Match(text)
+	}
+	return c
+}
+
+// characteristic converts the relation to a group characteristic. The relations of exclusion
+// criteria are negated by the parser, so they are negated back to the criteria as stated,
+// and the characteristic is excluded. Relations with a zero score or without a value are skipped.
+func (e *Exporter) characteristic(r *relation.Relation, exclude bool) (Characteristic, bool) {
+	if r.Score == 0 {
+		return Characteristic{}, false
+	}
+	if exclude {
+		r = r.Negated()
+	}
+	coding := variableCoding(r)
+	c := Characteristic{
+		Code:    CodeableConcept{Coding: []Coding{coding}, Text: coding.Display},
+		Exclude: exclude,
+	}
+	switch r.VariableType {
+	case variables.Numerical:
+		return c, e.setNumerical(&c, r)
+	case variables.Ordinal:
+		return c, setOrdinal(&c, r)
+	case variables.Boolean:
+		if len(r.Value) != 1 {
+			return c, false
+		}
+		b, err := strconv.ParseBool(r.Value[0])
+		if err != nil {
+			return c, false
+		}
+		c.ValueBoolean = &b
+		return c, true
+	case variables.Nominal:
+		return c, setNominal(&c, r)
+	default:
+		return c, false
+	}
+}
+
+// setNumerical sets a quantity with a comparator if the relation has one limit,
+// or a range if it has both.
+func (e *Exporter) setNumerical(c *Characteristic, r *relation.Relation) bool {
+	switch {
+	case r.Lower != nil && r.Upper != nil:
+		low, ok := e.quantity(r.Lower, r.Unit)
+		if !ok {
+			return false
+		}
+		high, ok := e.quantity(r.Upper, r.Unit)
+		if !ok {
+			return false
+		}
+		c.ValueRange = &Range{Low: low, High: high}
+	case r.Lower != nil:
+		q, ok := e.quantity(r.Lower, r.Unit)
+		if !ok {
+			return false
+		}
+		q.Comparator = ">"
+		if r.Lower.Incl {
+			q.Comparator = ">="
+		}
+		c.ValueQuantity = q
+	case r.Upper != nil:
+		q, ok := e.quantity(r.Upper, r.Unit)
+		if !ok {
+			return false
+		}
+		q.Comparator = "<"
+		if r.Upper.Incl {
+			q.Comparator = "<="
+		}
+		c.ValueQuantity = q
+	default:
+		return false
+	}
+	return true
+}
+
+// quantity converts the limit to a quantity. The unit is the display name of the unit catalog,
+// and it is coded in UCUM if the code is known. Limits relative to a reference limit, such as
+// '2.5 x ULN', have the reference limit as the unit.
+func (e *Exporter) quantity(l *relation.Limit, unit string) (*Quantity, bool) {
+	value, ok := decimal(l.Value)
+	if !ok {
+		return nil, false
+	}
+	q := &Quantity{Value: value}
+	if l.Reference != relation.NoReference {
+		q.Unit = "x " + strings.ToUpper(string(l.Reference))
+		return q, true
+	}
+	if len(unit) == 0 {
+		return q, true
+	}
+	q.Unit = unit
+	if id, ok := e.units.ID(unit); ok {
+		q.Unit = e.units.Unit(id).Display
+	}
+	if code, ok := ucumCodes[unit]; ok {
+		q.System = UCUMSystem
+		q.Code = code
+	}
+	return q, true
+}
+
+// setOrdinal sets a range if the values of the relation are consecutive integers,
+// otherwise the values as text.
+func setOrdinal(c *Characteristic, r *relation.Relation) bool {
+	if len(r.Value) == 0 {
+		return false
+	}
+	values := make([]int, 0, len(r.Value))
+	for _, v := range r.Value {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return setNominal(c, r)
+		}
+		values = append(values, n)
+	}
+	sort.Ints(values)
+	for i := 1; i < len(values); i++ {
+		if values[i] != values[i-1]+1 {
+			return setNominal(c, r)
+		}
+	}
+	low := json.Number(strconv.Itoa(values[0]))
+	high := json.Number(strconv.Itoa(values[len(values)-1]))
+	c.ValueRange = &Range{Low: &Quantity{Value: low}, High: &Quantity{Value: high}}
+	return true
+}
+
+// setNominal sets a coded value if the relation has one value, otherwise the values as text.
+func setNominal(c *Characteristic, r *relation.Relation) bool {
+	switch len(r.Value) {
+	case 0:
+		return false
+	case 1:
+		c.ValueCodeableConcept = &CodeableConcept{
+			Coding: []Coding{{System: VariableSystem + "/" + r.Name, Code: r.Value[0]}},
+			Text:   r.Value[0],
+		}
+	default:
+		c.ValueCodeableConcept = &CodeableConcept{Text: strings.Join(r.Value, " or ")}
+	}
+	return true
+}
+
+// decimal converts the string to a JSON decimal.
+func decimal(s string) (json.Number, bool) {
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return "", false
+	}
+	return json.Number(s), true
+}
+
+// resourceID returns a valid resource id for the name.
+func resourceID(name string) string {
+	id := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, name)
+	if len(id) > 64 {
+		id = id[:64]
+	}
+	return id
+}
+
+// resourceURL returns the name-based (version 5) UUID URN of the resource of the study.
+func resourceURL(nct, resourceType string) string {
+	h := sha1.New()
+	h.Write(urlNamespace)
+	h.Write([]byte(NCTSystem + "/study/" + nct + "#" + resourceType))
+	u := h.Sum(nil)[:16]
+	u[6] = (u[6] & 0x0f) | 0x50
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,144 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package fhir
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMatcher map[string][]Coding
+
+func (m testMatcher) Match(text string) []Coding {
+	return m[text]
+}
+
+func TestBundle(t *testing.T) {
+	a := assert.New(t)
+
+	input := `Inclusion Criteria:
+
+            Aged 18 to 59.
+
+            ECOG 0-1.
+
+            BMI ≥ 25 kg/m2.
+
+            Informed consent.
+
+            Exclusion Criteria:
+
+            Weigh more than 180 pounds.`
+
+	study := studies.NewStudy("NCT00000001", "Better Health for Everybody", []string{"Obesity"}, input)
+	study.Parse()
+
+	e := NewExporter()
+	e.SetConceptMatcher(testMatcher{"Obesity": {{System: MeSHSystem, Code: "D009765", Display: "Obesity"}}})
+	b := e.Bundle(study)
+	a.Equal("collection", b.Type)
+	a.Len(b.Entry, 3)
+
+	rs := b.Entry[0].Resource.(*ResearchStudy)
+	a.Equal("NCT00000001", rs.ID)
+	a.Equal("Better Health for Everybody", rs.Title)
+	a.Equal("D009765", rs.Condition[0].Coding[0].Code)
+	a.Equal(b.Entry[1].FullURL, rs.Enrollment[0].Reference)
+
+	g := b.Entry[1].Resource.(*Group)
+	a.Len(g.Characteristic, 4)
+
+	age := g.Characteristic[0]
+	a.Equal("200", age.Code.Coding[0].Code)
+	a.False(age.Exclude)
+	a.Equal(json.Number("18"), age.ValueRange.Low.Value)
+	a.Equal(json.Number("59"), age.ValueRange.High.Value)
+
+	ecog := g.Characteristic[1]
+	a.Equal(json.Number("0"), ecog.ValueRange.Low.Value)
+	a.Equal(json.Number("1"), ecog.ValueRange.High.Value)
+
+	bmi := g.Characteristic[2]
+	a.Equal(">=", bmi.ValueQuantity.Comparator)
+	a.Equal(json.Number("25"), bmi.ValueQuantity.Value)
+	a.Equal("kg/m2", bmi.ValueQuantity.Code)
+	a.Equal(UCUMSystem, bmi.ValueQuantity.System)
+
+	// Exclusions are exported as stated in the text.
+	weight := g.Characteristic[3]
+	a.True(weight.Exclude)
+	a.Equal(">", weight.ValueQuantity.Comparator)
+	a.Equal("[lb_av]", weight.ValueQuantity.Code)
+
+	ev := b.Entry[2].Resource.(*EvidenceVariable)
+	a.Len(ev.Characteristic, 5)
+	a.Equal("Informed consent", ev.Characteristic[3].Description)
+	a.Empty(ev.Characteristic[3].DefinitionCodeableConcept.Coding)
+	a.True(ev.Characteristic[4].Exclude)
+	a.Equal("202", ev.Characteristic[4].DefinitionCodeableConcept.Coding[0].Code)
+
+	// The export is deterministic.
+	a.Equal(b.JSON(), e.Bundle(study).JSON())
+
+	v, err := NewValidator()
+	a.NoError(err)
+	a.NoError(v.Validate([]byte(b.JSON())))
+}
+
+func TestBundleWithoutCriteria(t *testing.T) {
+	a := assert.New(t)
+
+	study := studies.NewStudy("NCT00000002", "", nil, "")
+	study.Parse()
+	b := NewExporter().Bundle(study)
+	a.Len(b.Entry, 2)
+
+	v, err := NewValidator()
+	a.NoError(err)
+	a.NoError(v.Validate([]byte(b.JSON())))
+}
+
+func TestBundleWithAlternatives(t *testing.T) {
+	a := assert.New(t)
+
+	input := `Inclusion Criteria:
+
+            HbA1c > 5.7% or BMI ≥ 25 kg/m2.
+
+            Exclusion Criteria:
+
+            BMI > 40 kg/m2 and weight > 150 kg.`
+
+	study := studies.NewStudy("NCT00000003", "", nil, input)
+	study.Parse()
+	b := NewExporter().Bundle(study)
+	a.Len(b.Entry, 5)
+
+	// Alternatives are not AND-ed as group characteristics.
+	g := b.Entry[1].Resource.(*Group)
+	a.Empty(g.Characteristic)
+
+	ev := b.Entry[2].Resource.(*EvidenceVariable)
+	a.Len(ev.Characteristic, 4)
+	a.Equal(b.Entry[3].FullURL, ev.Characteristic[1].DefinitionCanonical)
+	a.False(ev.Characteristic[1].Exclude)
+	a.Equal(b.Entry[4].FullURL, ev.Characteristic[3].DefinitionCanonical)
+	a.True(ev.Characteristic[3].Exclude)
+
+	inclusion := b.Entry[3].Resource.(*EvidenceVariable)
+	a.Equal("any-of", inclusion.Extension[0].Extension[0].ValueCode)
+	a.Len(inclusion.Characteristic, 2)
+
+	// The exclusion criterion excludes the persons who have both relations as stated.
+	exclusion := b.Entry[4].Resource.(*EvidenceVariable)
+	a.Equal("all-of", exclusion.Extension[0].Extension[0].ValueCode)
+	a.Equal([]string{"weight > 150 kg", "bmi > 40 kg/m2"}, []string{exclusion.Characteristic[0].Description, exclusion.Characteristic[1].Description})
+
+	v, err := NewValidator()
+	a.NoError(err)
+	a.NoError(v.Validate([]byte(b.JSON())))
+}
@@ -0,0 +1,131 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package fhir
+
+import "encoding/json"
+
+// The FHIR R4 resources and data types below define only the elements that the exporter writes.
+
+// Coding defines a code from a code system.
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// CodeableConcept defines a concept by codings and text.
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+// Quantity defines a measured amount. The comparator is one of '<', '<=', '>=', or '>'.
+type Quantity struct {
+	Value      json.Number `json:"value"`
+	Comparator string      `json:"comparator,omitempty"`
+	Unit       string      `json:"unit,omitempty"`
+	System     string      `json:"system,omitempty"`
+	Code       string      `json:"code,omitempty"`
+}
+
+// Range defines a set of values bounded by inclusive low and high quantities.
+// The quantities of a range have no comparator.
+type Range struct {
+	Low  *Quantity `json:"low,omitempty"`
+	High *Quantity `json:"high,omitempty"`
+}
+
+// Reference defines a reference to another resource.
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+// Identifier defines a business identifier of a resource.
+type Identifier struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+// ResearchStudy defines a clinical study.
+type ResearchStudy struct {
+	ResourceType string            `json:"resourceType"`
+	ID           string            `json:"id,omitempty"`
+	Identifier   []Identifier      `json:"identifier,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Status       string            `json:"status"`
+	Condition    []CodeableConcept `json:"condition,omitempty"`
+	Enrollment   []Reference       `json:"enrollment,omitempty"`
+}
+
+// Characteristic defines a trait of the members of a group. Exactly one value is set.
+type Characteristic struct {
+	Code                 CodeableConcept  `json:"code"`
+	ValueCodeableConcept *CodeableConcept `json:"valueCodeableConcept,omitempty"`
+	ValueBoolean         *bool            `json:"valueBoolean,omitempty"`
+	ValueQuantity        *Quantity        `json:"valueQuantity,omitempty"`
+	ValueRange           *Range           `json:"valueRange,omitempty"`
+	Exclude              bool             `json:"exclude"`
+}
+
+// Group defines the eligible population of a study by characteristics,
+// which all the members must have.
+type Group struct {
+	ResourceType   string           `json:"resourceType"`
+	ID             string           `json:"id,omitempty"`
+	Type           string           `json:"type"`
+	Actual         bool             `json:"actual"`
+	Name           string           `json:"name,omitempty"`
+	Characteristic []Characteristic `json:"characteristic,omitempty"`
+}
+
+// Extension defines an element that is not in the base definition, such as an element of a later
+// FHIR version. It has either nested extensions or a value.
+type Extension struct {
+	URL       string      `json:"url"`
+	Extension []Extension `json:"extension,omitempty"`
+	ValueCode string      `json:"valueCode,omitempty"`
+}
+
+// EvidenceCharacteristic defines an eligibility criterion of an evidence variable.
+// Exactly one definition is set.
+type EvidenceCharacteristic struct {
+	Description               string           `json:"description,omitempty"`
+	DefinitionCanonical       string           `json:"definitionCanonical,omitempty"`
+	DefinitionCodeableConcept *CodeableConcept `json:"definitionCodeableConcept,omitempty"`
+	Exclude                   bool             `json:"exclude,omitempty"`
+}
+
+// EvidenceVariable defines the eligibility criteria of a study as stated in the text,
+// or the alternative relations of a criterion.
+type EvidenceVariable struct {
+	ResourceType   string                   `json:"resourceType"`
+	ID             string                   `json:"id,omitempty"`
+	Extension      []Extension              `json:"extension,omitempty"`
+	Name           string                   `json:"name,omitempty"`
+	Title          string                   `json:"title,omitempty"`
+	Status         string                   `json:"status"`
+	Characteristic []EvidenceCharacteristic `json:"characteristic"`
+}
+
+// BundleEntry defines an entry of a bundle.
+type BundleEntry struct {
+	FullURL  string      `json:"fullUrl,omitempty"`
+	Resource interface{} `json:"resource"`
+}
+
+// Bundle defines a collection of resources.
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	ID           string        `json:"id,omitempty"`
+	Type         string        `json:"type"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+// JSON returns the json string of the bundle.
+func (b *Bundle) JSON() string {
+	if s, err := json.Marshal(b); err == nil {
+		return string(s)
+	}
+	return ""
+}
@@ -0,0 +1,405 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package fhir
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// definitionFiles contains the bundled structure definitions and value sets. They are
+// a subset of the FHIR R4 (4.0.1) definitions with the elements of the exported resources.
+//
+//go:embed definitions/*.json
+var definitionFiles embed.FS
+
+// primitives validates the JSON values of the FHIR primitive types.
+var primitives = map[string]func(v interface{}) bool{
+	"boolean":     isBool,
+	"decimal":     isNumber(regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)),
+	"integer":     isNumber(regexp.MustCompile(`^-?([0]|([1-9][0-9]*))$`)),
+	"unsignedInt": isNumber(regexp.MustCompile(`^(0|[1-9][0-9]*)$`)),
+	"positiveInt": isNumber(regexp.MustCompile(`^\+?[1-9][0-9]*$`)),
+	"string":      isString(regexp.MustCompile(`^[ \r\n\t\S]+$`)),
+	"markdown":    isString(regexp.MustCompile(`^[ \r\n\t\S]+$`)),
+	"code":        isString(regexp.MustCompile(`^[^\s]+( [^\s]+)*$`)),
+	"id":          isString(regexp.MustCompile(`^[A-Za-z0-9\-\.]{1,64}$`)),
+	"uri":         isString(regexp.MustCompile(`^\S+$`)),
+	"canonical":   isString(regexp.MustCompile(`^\S+$`)),
+	"instant":     isString(regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`)),
+	"dateTime":    isString(regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2}(T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2}))?)?)?$`)),
+}
+
+func isBool(v interface{}) bool {
+	_, ok := v.(bool)
+	return ok
+}
+
+func isNumber(re *regexp.Regexp) func(v interface{}) bool {
+	return func(v interface{}) bool {
+		n, ok := v.(json.Number)
+		return ok && re.MatchString(n.String())
+	}
+}
+
+func isString(re *regexp.Regexp) func(v interface{}) bool {
+	return func(v interface{}) bool {
+		s, ok := v.(string)
+		return ok && re.MatchString(s)
+	}
+}
+
+// ElementType defines a type of an element.
+type ElementType struct {
+	Code          string   `json:"code"`
+	Profile       []string `json:"profile,omitempty"`
+	TargetProfile []string `json:"targetProfile,omitempty"`
+}
+
+// ElementBinding defines the value set of a coded element.
+type ElementBinding struct {
+	Strength string `json:"strength"`
+	ValueSet string `json:"valueSet"`
+}
+
+// ElementDefinition defines an element of a resource or a data type.
+type ElementDefinition struct {
+	ID      string          `json:"id"`
+	Path    string          `json:"path"`
+	Min     int             `json:"min"`
+	Max     string          `json:"max"`
+	Type    []ElementType   `json:"type,omitempty"`
+	Binding *ElementBinding `json:"binding,omitempty"`
+}
+
+// name returns the name of the element, the last part of its path.
+func (e *ElementDefinition) name() string {
+	return e.Path[strings.LastIndexByte(e.Path, '.')+1:]
+}
+
+// StructureDefinition defines the elements of a resource or a data type.
+type StructureDefinition struct {
+	ResourceType string `json:"resourceType"`
+	URL          string `json:"url"`
+	Name         string `json:"name"`
+	Kind         string `json:"kind"`
+	Type         string `json:"type"`
+	Derivation   string `json:"derivation"`
+	Snapshot     struct {
+		Element []*ElementDefinition `json:"element"`
+	} `json:"snapshot"`
+
+	children map[string][]*ElementDefinition // Child elements by parent path
+}
+
+// valueSet defines the codes of a value set expansion.
+type valueSet struct {
+	ResourceType string `json:"resourceType"`
+	URL          string `json:"url"`
+	Expansion    struct {
+		Contains []struct {
+			System string `json:"system"`
+			Code   string `json:"code"`
+		} `json:"contains"`
+	} `json:"expansion"`
+}
+
+// Validator validates resources against structure definitions.
+type Validator struct {
+	types     map[string]*StructureDefinition // Base definitions by type
+	profiles  map[string]*StructureDefinition // Definitions by url
+	valueSets map[string]map[string]bool      // Codes by value set url
+}
+
+// NewValidator creates a new validator with the bundled definitions.
+func NewValidator() (*Validator, error) {
+	v := &Validator{
+		types:     make(map[string]*StructureDefinition),
+		profiles:  make(map[string]*StructureDefinition),
+		valueSets: make(map[string]map[string]bool),
+	}
+	fnames, err := fs.Glob(definitionFiles, "definitions/*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, fname := range fnames {
+		b, err := definitionFiles.ReadFile(fname)
+		if err != nil {
+			return nil, err
+		}
+		if err := v.add(b); err != nil {
+			return nil, fmt.Errorf("%s: %v", fname, err)
+		}
+	}
+	return v, nil
+}
+
+// add adds a structure definition, a value set, or a bundle of them.
+func (v *Validator) add(b []byte) error {
+	var header struct {
+		ResourceType string `json:"resourceType"`
+		Entry        []struct {
+			Resource json.RawMessage `json:"resource"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal(b, &header); err != nil {
+		return err
+	}
+	switch header.ResourceType {
+	case "Bundle":
+		for _, e := range header.Entry {
+			if err := v.add(e.Resource); err != nil {
+				return err
+			}
+		}
+	case "StructureDefinition":
+		sd := &StructureDefinition{}
+		if err := json.Unmarshal(b, sd); err != nil {
+			return err
+		}
+		sd.children = make(map[string][]*ElementDefinition)
+		for _, e := range sd.Snapshot.Element {
+			if i := strings.LastIndexByte(e.Path, '.'); i >= 0 {
+				sd.children[e.Path[:i]] = append(sd.children[e.Path[:i]], e)
+			}
+		}
+		v.profiles[sd.URL] = sd
+		if sd.Derivation != "constraint" {
+			v.types[sd.Type] = sd
+		}
+	case "ValueSet":
+		vs := &valueSet{}
+		if err := json.Unmarshal(b, vs); err != nil {
+			return err
+		}
+		codes := make(map[string]bool)
+		for _, c := range vs.Expansion.Contains {
+			codes[c.Code] = true
+		}
+		v.valueSets[vs.URL] = codes
+	default:
+		return fmt.Errorf("unsupported definition: %q", header.ResourceType)
+	}
+	return nil
+}
+
+// ValidationError defines an error at an element of a resource.
+type ValidationError struct {
+	Path string // Path of the element, such as 'Bundle.entry[1].resource.characteristic[0].code'
+	Msg  string
+}
+
+// Error returns the error message with the element path.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+// ValidationErrors defines a slice of validation errors.
+type ValidationErrors []*ValidationError
+
+// Error returns the error messages, one per line.
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// add adds a new error at the path.
+func (es *ValidationErrors) add(path string, format string, args ...interface{}) {
+	*es = append(*es, &ValidationError{Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+// validation defines the state of validating a resource.
+type validation struct {
+	*Validator
+	errs       ValidationErrors
+	fullURLs   map[string]bool
+	references map[string]string // Referenced urns by element path
+}
+
+// Validate validates the JSON resource. The elements must be defined, have the defined
+// cardinality and types, and coded elements with required bindings must have codes of
+// their value sets. The urn references of a bundle must resolve to its entries.
+func (v *Validator) Validate(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+	s := &validation{Validator: v, fullURLs: make(map[string]bool), references: make(map[string]string)}
+	s.resource(value, "")
+	if obj, ok := value.(map[string]interface{}); !ok || obj["resourceType"] != "Bundle" {
+		s.references = nil
+	}
+
+	paths := make([]string, 0, len(s.references))
+	for path := range s.references {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if !s.fullURLs[s.references[path]] {
+			s.errs.add(path, "reference does not resolve to a bundle entry: %s", s.references[path])
+		}
+	}
+	if len(s.errs) > 0 {
+		return s.errs
+	}
+	return nil
+}
+
+// resource validates a resource by the definition of its resource type.
+func (s *validation) resource(value interface{}, path string) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		s.errs.add(path, "resource is not an object")
+		return
+	}
+	rt, _ := obj["resourceType"].(string)
+	sd, ok := s.types[rt]
+	if !ok || sd.Kind != "resource" {
+		s.errs.add(path, "unknown resource type: %q", rt)
+		return
+	}
+	if len(path) == 0 {
+		path = rt
+	}
+	s.object(obj, sd, sd.Type, path)
+}
+
+// object validates the elements of an object, whose definition is at the element path of sd.
+func (s *validation) object(obj map[string]interface{}, sd *StructureDefinition, elementPath, path string) {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	children := sd.children[elementPath]
+	present := make(map[*ElementDefinition]bool)
+	for _, key := range keys {
+		if key == "resourceType" && elementPath == sd.Type && sd.Kind == "resource" {
+			continue
+		}
+		p := path + "." + key
+		e, typ := match(children, key)
+		if e == nil {
+			s.errs.add(p, "unknown element")
+			continue
+		}
+		if present[e] {
+			s.errs.add(p, "more than one value for %s", e.name())
+			continue
+		}
+		present[e] = true
+
+		value := obj[key]
+		if e.Max == "*" {
+			items, ok := value.([]interface{})
+			if !ok || len(items) == 0 {
+				s.errs.add(p, "must be a non-empty array")
+				continue
+			}
+			for i, item := range items {
+				s.value(item, sd, e, typ, fmt.Sprintf("%s[%d]", p, i))
+			}
+			continue
+		}
+		if _, ok := value.([]interface{}); ok {
+			s.errs.add(p, "must not be an array")
+			continue
+		}
+		s.value(value, sd, e, typ, p)
+	}
+	for _, e := range children {
+		if e.Min > 0 && !present[e] {
+			s.errs.add(path+"."+e.name(), "missing required element")
+		}
+	}
+}
+
+// match returns the child element of the JSON key and its type.
+// Choice elements, such as 'value[x]', match keys with a type suffix, such as 'valueRange'.
+func match(children []*ElementDefinition, key string) (*ElementDefinition, ElementType) {
+	for _, e := range children {
+		name := e.name()
+		if name == key && len(e.Type) > 0 {
+			return e, e.Type[0]
+		}
+		if prefix := strings.TrimSuffix(name, "[x]"); prefix != name && strings.HasPrefix(key, prefix) {
+			for _, t := range e.Type {
+				if key == prefix+strings.ToUpper(t.Code[:1])+t.Code[1:] {
+					return e, t
+				}
+			}
+		}
+	}
+	return nil, ElementType{}
+}
+
+// value validates the value of the element e with the type typ.
+func (s *validation) value(value interface{}, sd *StructureDefinition, e *ElementDefinition, typ ElementType, path string) {
+	if value == nil {
+		s.errs.add(path, "null value")
+		return
+	}
+	if valid, ok := primitives[typ.Code]; ok {
+		if !valid(value) {
+			s.errs.add(path, "invalid %s: %v", typ.Code, value)
+			return
+		}
+		if e.Binding != nil && e.Binding.Strength == "required" {
+			url := strings.SplitN(e.Binding.ValueSet, "|", 2)[0]
+			if codes, ok := s.valueSets[url]; !ok {
+				s.errs.add(path, "unknown value set: %s", url)
+			} else if !codes[value.(string)] {
+				s.errs.add(path, "code not in value set %s: %v", url, value)
+			}
+		}
+		if typ.Code == "uri" && e.Path == "Bundle.entry.fullUrl" {
+			s.fullURLs[value.(string)] = true
+		}
+		return
+	}
+
+	switch typ.Code {
+	case "Resource":
+		s.resource(value, path)
+		return
+	case "BackboneElement", "Element":
+		obj, ok := value.(map[string]interface{})
+		if !ok || len(obj) == 0 {
+			s.errs.add(path, "must be a non-empty object")
+			return
+		}
+		s.object(obj, sd, e.Path, path)
+		return
+	}
+
+	dt := s.types[typ.Code]
+	if len(typ.Profile) > 0 {
+		dt = s.profiles[typ.Profile[0]]
+	}
+	if dt == nil {
+		s.errs.add(path, "no structure definition for type %s", typ.Code)
+		return
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok || len(obj) == 0 {
+		s.errs.add(path, "must be a non-empty object")
+		return
+	}
+	s.object(obj, dt, dt.Type, path)
+	if ref, ok := obj["reference"].(string); ok && typ.Code == "Reference" && strings.HasPrefix(ref, "urn:") {
+		s.references[path] = ref
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package fhir
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	a := assert.New(t)
+
+	v, err := NewValidator()
+	a.NoError(err)
+
+	group := `{"resourceType":"Group","type":"person","actual":false,"characteristic":[
+		{"code":{"text":"BMI"},"valueQuantity":{"value":25,"comparator":">=","unit":"kg/m2"},"exclude":false}]}`
+	a.NoError(v.Validate([]byte(group)))
+
+	tests := []struct {
+		resource string
+		err      string
+	}{
+		{`{"resourceType":"Patient"}`, `: unknown resource type: "Patient"`},
+		{`{"resourceType":"Group","type":"person"}`, "Group.actual: missing required element"},
+		{`{"resourceType":"Group","type":"people","actual":true}`, "Group.type: code not in value set http://hl7.org/fhir/ValueSet/group-type: people"},
+		{`{"resourceType":"Group","type":"person","actual":"yes"}`, "Group.actual: invalid boolean: yes"},
+		{`{"resourceType":"Group","type":"person","actual":true,"size":1}`, "Group.size: unknown element"},
+		{`{"resourceType":"Group","type":"person","actual":true,"characteristic":{"exclude":true}}`, "Group.characteristic: must be a non-empty array"},
+		{`{"resourceType":"Group","type":"person","actual":true,"characteristic":[{"code":{"text":"BMI"},"exclude":true}]}`,
+			"Group.characteristic[0].value[x]: missing required element"},
+		{`{"resourceType":"Group","type":"person","actual":true,"characteristic":[{"code":{"text":"BMI"},"valueBoolean":true,"valueInteger":1,"exclude":true}]}`,
+			"Group.characteristic[0].valueInteger: unknown element"},
+		{`{"resourceType":"Group","type":"person","actual":true,"characteristic":[{"code":{"text":"BMI"},"valueRange":{"low":{"value":1,"comparator":">"}},"exclude":true}]}`,
+			"Group.characteristic[0].valueRange.low.comparator: unknown element"},
+		{`{"resourceType":"Bundle","type":"collection","entry":[{"fullUrl":"urn:uuid:1","resource":{"resourceType":"ResearchStudy","status":"active","enrollment":[{"reference":"urn:uuid:2"}]}}]}`,
+			"Bundle.entry[0].resource.enrollment[0]: reference does not resolve to a bundle entry: urn:uuid:2"},
+	}
+	for _, test := range tests {
+		a.EqualError(v.Validate([]byte(test.resource)), test.err, test.resource)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/text"
+)
+
+// LabelPrefix is the prefix of the NER labels in the slot JSON, as in the output of the NER model.
+const LabelPrefix = "word_scores:"
+
+// rule maps the tree numbers or categories that start with the prefix to the label.
+type rule struct {
+	prefix string
+	label  string
+}
+
+// Labels defines a mapping from vocabulary tree number prefixes, such as MeSH categories, or from
+// categories, such as UMLS semantic types and groups, to NER labels.
+type Labels []rule
+
+// ParseLabels parses a label mapping from a comma-separated list of prefix:label pairs,
+// such as 'C04:cancer,C:chronic_disease'.
+func ParseLabels(s string) (Labels, error) {
+	var labels Labels
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		v := strings.Split(pair, ":")
+		if len(v) != 2 || len(strings.TrimSpace(v[0])) == 0 || len(strings.TrimSpace(v[1])) == 0 {
+			return nil, fmt.Errorf("bad label mapping, prefix:label needed: %q", pair)
+		}
+		labels = append(labels, rule{prefix: strings.TrimSpace(v[0]), label: strings.TrimSpace(v[1])})
+	}
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("empty label mapping: %q", s)
+	}
+	return labels, nil
+}
+
+// Label returns the label of the longest prefix that matches a tree number or a category.
+// A prefix matches a tree number that equals it or continues it with a '.' or, if the prefix
+// is a category letter, with digits. Of equally long prefixes, the first one of the mapping is used.
+// It returns false if no prefix matches.
+func (ls Labels) Label(treeNumbers []string) (string, bool) {
+	label := ""
+	length := 0
+	for _, r := range ls {
+		for _, tn := range treeNumbers {
+			if len(r.prefix) > length && matches(r.prefix, tn) {
+				label = r.label
+				length = len(r.prefix)
+			}
+		}
+	}
+	return label, length > 0
+}
+
+// matches returns true if the prefix matches the tree number.
+func matches(prefix, tn string) bool {
+	if tn == prefix || strings.HasPrefix(tn, prefix+".") {
+		return true
+	}
+	return text.LetterPrefix(tn) == prefix
+}
@@ -0,0 +1,139 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/text"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
+)
+
+var reToken = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// Mention defines a term of a criterion that is found in the vocabulary.
+type Mention struct {
+	Label   string  // NER label of the concept
+	Text    string  // Text of the mention in the criterion
+	Concept string  // Name of the matched vocabulary node
+	Score   float64 // Confidence score of the mention
+	Begin   int     // Starting byte offset of the mention
+	End     int     // Ending byte offset (exclusive) of the mention
+}
+
+// Mentions defines a slice of mentions.
+type Mentions []*Mention
+
+// Slots returns the mentions in the slot JSON of the NER model, {label: [[score, term], ...]},
+// which cmd/nel consumes.
+func (ms Mentions) Slots() string {
+	slots := make(map[string][][]interface{})
+	for _, m := range ms {
+		label := LabelPrefix + m.Label
+		slots[label] = append(slots[label], []interface{}{m.Score, m.Text})
+	}
+	b, _ := json.Marshal(slots)
+	return string(b)
+}
+
+// entry defines a dictionary phrase.
+type entry struct {
+	label        string
+	concept      string
+	abbreviation string // Space-joined tokens of an abbreviation in its original case, if any
+}
+
+// Spotter finds vocabulary terms in criteria by dictionary lookup. The dictionary holds
+// the synonyms of the vocabulary nodes whose tree numbers or categories map to a label.
+type Spotter struct {
+	dictionary map[string]entry // Map from space-joined synonym tokens to entry
+	maxTokens  int
+	score      float64
+}
+
+// NewSpotter creates a spotter from the synonyms of the top-level vocabulary nodes. Synonyms shorter
+// than minLength characters are disregarded. The mentions are given the score. If a synonym belongs
+// to several nodes, the first one is used, but a synonym that is not an abbreviation is preferred.
+// Abbreviations, such as 'ALL', match only in their original case. The synonyms must not be normalized
+// for matching.
+func NewSpotter(vocabulary *taxonomy.Taxonomy, labels Labels, minLength int, score float64) *Spotter {
+	s := &Spotter{dictionary: make(map[string]entry), score: score}
+	for _, n := range vocabulary.Nodes() {
+		label, ok := labels.Label(append(n.TreeNumbers().Slice(), n.Categories().Slice()...))
+		if !ok {
+			continue
+		}
+		abbreviations := n.Abbreviations()
+		for syn := range n.Synonyms() {
+			tokens := tokenize(syn)
+			phrase := strings.Join(tokens, " ")
+			if len(phrase) < minLength {
+				continue
+			}
+			e := entry{label: label, concept: n.Name()}
+			if abbreviations[syn] {
+				e.abbreviation = strings.Join(reToken.FindAllString(syn, -1), " ")
+			}
+			if d, ok := s.dictionary[phrase]; !ok || (len(d.abbreviation) > 0 && len(e.abbreviation) == 0) {
+				s.dictionary[phrase] = e
+				if len(tokens) > s.maxTokens {
+					s.maxTokens = len(tokens)
+				}
+			}
+		}
+	}
+	return s
+}
+
+// Size returns the number of phrases in the dictionary.
+func (s *Spotter) Size() int {
+	return len(s.dictionary)
+}
+
+// tokenize splits the lowercase text to words.
+func tokenize(s string) []string {
+	return reToken.FindAllString(strings.ToLower(s), -1)
+}
+
+// Spot finds the longest non-overlapping mentions in the criterion from left to right.
+func (s *Spotter) Spot(criterion string) Mentions {
+	lower := text.ToLowerSameWidth(criterion)
+	offsets := reToken.FindAllStringIndex(lower, -1)
+	tokens := make([]string, len(offsets))
+	for i, o := range offsets {
+		tokens[i] = lower[o[0]:o[1]]
+	}
+
+	var mentions Mentions
+	for i := 0; i < len(tokens); {
+		n := s.maxTokens
+		if i+n > len(tokens) {
+			n = len(tokens) - i
+		}
+		for ; n > 0; n-- {
+			if e, ok := s.dictionary[strings.Join(tokens[i:i+n], " ")]; ok {
+				begin, end := offsets[i][0], offsets[i+n-1][1]
+				if len(e.abbreviation) > 0 && e.abbreviation != strings.Join(reToken.FindAllString(criterion[begin:end], -1), " ") {
+					continue
+				}
+				mentions = append(mentions, &Mention{
+					Label:   e.label,
+					Text:    criterion[begin:end],
+					Concept: e.concept,
+					Score:   s.score,
+					Begin:   begin,
+					End:     end,
+				})
+				break
+			}
+		}
+		if n > 0 {
+			i += n
+		} else {
+			i++
+		}
+	}
+	return mentions
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func node(name string, treeNumbers []string, synonyms ...string) *taxonomy.Node {
+	d := taxonomy.NewNode(name)
+	c := taxonomy.NewNode(name)
+	c.AddSynonym(name)
+	c.AddSynonym(synonyms...)
+	c.AddTreeNumber(treeNumbers...)
+	d.AddChild(c)
+	return d
+}
+
+func vocabulary() *taxonomy.Taxonomy {
+	t := taxonomy.New(taxonomy.NewNode("root"))
+	t.AddNode(node("Diabetes Mellitus", []string{"C18.452.394.750", "C19.246"}))
+	t.AddNode(node("Diabetes Mellitus, Type 2", []string{"C18.452.394.750.149", "C19.246.300"}, "Type 2 Diabetes Mellitus", "type 2 diabetes"))
+	t.AddNode(node("Breast Neoplasms", []string{"C04.588.180", "C17.800.090"}, "Breast Cancer"))
+	t.AddNode(node("Metformin", []string{"D02.078.370.141.450"}))
+	t.AddNode(node("Hepatitis B", []string{"C01.925.256.430.400"}, "b"))
+	t.AddNode(node("Patients", []string{"M01.643"}))
+	t.AddNode(node("Language Fluency, English", []string{"LF0.1"}, "english speaking"))
+	leukemia := node("Precursor Cell Lymphoblastic Leukemia-Lymphoma", []string{"C04.557.337.428.600"}, "Acute Lymphoblastic Leukemia")
+	leukemia.AddAbbreviation("ALL")
+	t.AddNode(leukemia)
+	return t
+}
+
+func TestLabels(t *testing.T) {
+	a := assert.New(t)
+
+	labels, err := ParseLabels("C04:cancer, C:chronic_disease,D:treatment,LF0:language_fluency")
+	a.NoError(err)
+
+	label, ok := labels.Label([]string{"C04.588.180", "C17.800.090"})
+	a.True(ok)
+	a.Equal("cancer", label)
+	label, _ = labels.Label([]string{"C18.452.394.750"})
+	a.Equal("chronic_disease", label)
+	label, _ = labels.Label([]string{"LF0.1"})
+	a.Equal("language_fluency", label)
+	_, ok = labels.Label([]string{"M01.643"})
+	a.False(ok)
+	_, ok = labels.Label([]string{"C040"})
+	a.True(ok)
+
+	_, err = ParseLabels("C04=cancer")
+	a.Error(err)
+	_, err = ParseLabels(" , ")
+	a.Error(err)
+}
+
+func TestSpot(t *testing.T) {
+	a := assert.New(t)
+
+	labels, err := ParseLabels("C04:cancer,C:chronic_disease,D:treatment,LF0:language_fluency")
+	a.NoError(err)
+	s := NewSpotter(vocabulary(), labels, 3, 1)
+	a.Equal(13, s.Size())
+
+	mentions := s.Spot("Patients with Type 2 Diabetes on metformin, or history of breast cancer (hepatitis B excluded)")
+	a.Len(mentions, 4)
+	a.Equal("Type 2 Diabetes", mentions[0].Text)
+	a.Equal("Diabetes Mellitus, Type 2", mentions[0].Concept)
+	a.Equal("chronic_disease", mentions[0].Label)
+	a.Equal(14, mentions[0].Begin)
+	a.Equal("metformin", mentions[1].Text)
+	a.Equal("treatment", mentions[1].Label)
+	a.Equal("breast cancer", mentions[2].Text)
+	a.Equal("cancer", mentions[2].Label)
+	a.Equal("hepatitis B", mentions[3].Text)
+
+	a.Empty(s.Spot("Able to give informed consent"))
+	a.Empty(s.Spot("All patients"))
+	mentions = s.Spot("History of ALL")
+	a.Len(mentions, 1)
+	a.Equal("ALL", mentions[0].Text)
+	a.Equal("cancer", mentions[0].Label)
+	a.Equal(`{"word_scores:language_fluency":[[1,"English-speaking"]]}`, s.Spot("English-speaking").Slots())
+	a.Equal(`{}`, Mentions{}.Slots())
+}
+
+func TestSpotUMLS(t *testing.T) {
+	a := assert.New(t)
+
+	concept := func(name, cui, treeNumber string, categories ...string) *taxonomy.Node {
+		n := taxonomy.NewNode(name)
+		n.SetID(cui)
+		n.AddSynonym(name)
+		n.AddTreeNumber(treeNumber)
+		n.AddCategory(categories...)
+		return n
+	}
+	tx := taxonomy.New(taxonomy.NewNode("root"))
+	tx.AddNode(concept("Neoplasms", "C0027651", "1", "DISO", "T191"))
+	tx.AddNode(concept("Brain Neoplasms", "C0006118", "1.1", "DISO", "T191"))
+	tx.AddNode(concept("Heart Failure", "C0018801", "2", "DISO", "T047"))
+	tx.AddNode(concept("Metformin", "C0025598", "3", "CHEM", "T121"))
+
+	labels, err := ParseLabels("DISO:chronic_disease,T191:cancer,CHEM:treatment")
+	a.NoError(err)
+	s := NewSpotter(tx, labels, 3, 1)
+	a.Equal(4, s.Size())
+
+	mentions := s.Spot("Brain neoplasms or heart failure treated with metformin")
+	a.Len(mentions, 3)
+	a.Equal("chronic_disease", mentions[0].Label)
+	a.Equal("Brain Neoplasms", mentions[0].Concept)
+	a.Equal("chronic_disease", mentions[1].Label)
+	a.Equal("treatment", mentions[2].Label)
+
+	labels, err = ParseLabels("T191:cancer,DISO:chronic_disease")
+	a.NoError(err)
+	mentions = NewSpotter(tx, labels, 3, 1).Spot("Brain neoplasms or heart failure")
+	a.Equal("cancer", mentions[0].Label)
+	a.Equal("chronic_disease", mentions[1].Label)
+}
@@ -0,0 +1,267 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package omop
+
+import (
+	"strconv"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+)
+
+// Cohort defines the eligibility criteria of a study compiled to conditions on the OMOP CDM tables.
+// A person is in the cohort if every inclusion criterion holds and no exclusion criterion holds.
+type Cohort struct {
+	NCT        string
+	Inclusions []*Criterion
+	Exclusions []*Criterion
+	Unmapped   []string // Criteria that cannot be compiled and are left out of the cohort
+	year       int      // Index year of the age, or the current year if zero
+}
+
+// Criterion defines a compiled eligibility criterion. An inclusion criterion holds if any of its
+// conditions holds. The relations of an exclusion criterion are negated back to the criterion as
+// stated, so the exclusion criterion holds if all of its conditions hold.
+type Criterion struct {
+	Text       string
+	All        bool
+	conditions []condition
+}
+
+// condition defines a relation compiled to a condition on the OMOP CDM tables.
+type condition interface {
+	sql(year string) string
+	holds(db *Database, p *Person, year int) bool
+}
+
+// Generator compiles the parsed relations of studies to cohorts.
+type Generator struct {
+	concepts *Concepts
+	year     int
+}
+
+// NewGenerator creates a new cohort generator with the concept mapping.
+func NewGenerator(concepts *Concepts) *Generator {
+	return &Generator{concepts: concepts}
+}
+
+// SetIndexYear sets the year at which the age of a person is computed.
+// By default, the age is computed at the current date.
+func (g *Generator) SetIndexYear(year int) {
+	g.year = year
+}
+
+// Cohort compiles the criteria of the parsed study to a cohort. A criterion is compiled only if
+// all of its relations can be mapped to concepts, because dropping a relation would make an inclusion
+// criterion stricter or an exclusion criterion broader. The cohort is therefore a superset of the
+// eligible persons. Time windows of the relations are not compiled.
+func (g *Generator) Cohort(s *studies.Study) *Cohort {
+	c := &Cohort{NCT: s.NCT(), year: g.year}
+	for _, k := range s.InclusionCriteria() {
+		if cr, ok := g.criterion(k, false); ok {
+			c.Inclusions = append(c.Inclusions, cr)
+		} else {
+			c.Unmapped = append(c.Unmapped, k.String())
+		}
+	}
+	for _, k := range s.ExclusionCriteria() {
+		if cr, ok := g.criterion(k, true); ok {
+			c.Exclusions = append(c.Exclusions, cr)
+		} else {
+			c.Unmapped = append(c.Unmapped, k.String())
+		}
+	}
+	return c
+}
+
+// criterion compiles the relations of the criterion. The second value is false if
+// the criterion has no relations or a relation cannot be compiled.
+func (g *Generator) criterion(k *criteria.Criterion, exclude bool) (*Criterion, bool) {
+	rs := k.Relations()
+	if len(rs) == 0 {
+		return nil, false
+	}
+	c := &Criterion{Text: k.String(), All: exclude}
+	for _, r := range rs {
+		if exclude {
+			r = r.Negated()
+		}
+		cond, ok := g.condition(r)
+		if !ok {
+			return nil, false
+		}
+		c.conditions = append(c.conditions, cond)
+	}
+	return c, true
+}
+
+// condition compiles the relation. The second value is false if the relation is invalid
+// or its variable, values, or unit are not mapped to concepts.
+func (g *Generator) condition(r *relation.Relation) (condition, bool) {
+	if r.Score == 0 {
+		return nil, false
+	}
+	switch r.VariableType {
+	case variables.Numerical:
+		if _, ok := g.concepts.Get(PersonDomain, r.Name); ok && r.Name == "age" {
+			return g.age(r)
+		}
+		return g.measurement(r)
+	case variables.Ordinal:
+		ids, ok := g.concepts.Get(MeasurementDomain, r.Name)
+		if !ok || len(r.Value) == 0 {
+			return nil, false
+		}
+		c := &measurementCondition{concepts: ids}
+		for _, s := range r.Value {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, false
+			}
+			c.values = append(c.values, v)
+		}
+		return c, true
+	case variables.Boolean:
+		ids, ok := g.concepts.Get(ConditionDomain, r.Name)
+		if !ok || len(r.Value) != 1 {
+			return nil, false
+		}
+		b, err := strconv.ParseBool(r.Value[0])
+		if err != nil {
+			return nil, false
+		}
+		return &occurrenceCondition{concepts: ids, absent: !b}, true
+	case variables.Nominal:
+		domain := ConditionDomain
+		if _, ok := g.concepts.Get(PersonDomain, r.Name); ok && r.Name == "gender" {
+			domain = GenderDomain
+		}
+		ids, ok := g.lookup(domain, r.Value)
+		if !ok {
+			return nil, false
+		}
+		if domain == GenderDomain {
+			return &genderCondition{concepts: ids}, true
+		}
+		return &occurrenceCondition{concepts: ids}, true
+	default:
+		return nil, false
+	}
+}
+
+// lookup returns the concept ids of all the keys. The second value is false if a key is not mapped.
+func (g *Generator) lookup(domain Domain, keys []string) ([]int64, bool) {
+	if len(keys) == 0 {
+		return nil, false
+	}
+	var ids []int64
+	for _, k := range keys {
+		c, ok := g.concepts.Get(domain, k)
+		if !ok {
+			return nil, false
+		}
+		ids = append(ids, c...)
+	}
+	return ids, true
+}
+
+// age compiles the age relation to a condition on the year of birth. The limits are converted to years.
+func (g *Generator) age(r *relation.Relation) (condition, bool) {
+	c := &ageCondition{}
+	var ok bool
+	if c.lower, ok = newBound(r.Lower, r.Unit, "year"); !ok {
+		return nil, false
+	}
+	if c.upper, ok = newBound(r.Upper, r.Unit, "year"); !ok {
+		return nil, false
+	}
+	if c.lower == nil && c.upper == nil {
+		return nil, false
+	}
+	if (c.lower != nil && c.lower.reference != relation.NoReference) ||
+		(c.upper != nil && c.upper.reference != relation.NoReference) {
+		return nil, false
+	}
+	return c, true
+}
+
+// measurement compiles the numerical relation to a condition on the measurements of its variable.
+// If the relation has a unit, the unit concept of the measurement must match it. A unit without
+// a concept is converted to the default unit of the variable, such as pounds to kilograms.
+func (g *Generator) measurement(r *relation.Relation) (condition, bool) {
+	ids, ok := g.concepts.Get(MeasurementDomain, r.Name)
+	if !ok {
+		return nil, false
+	}
+	c := &measurementCondition{concepts: ids}
+	unit := r.Unit
+	if len(unit) > 0 {
+		if c.units, ok = g.concepts.Get(UnitDomain, unit); !ok {
+			if v := variables.Get().Variable(r.ID); v != nil && len(v.UnitName) > 0 {
+				unit = v.UnitName
+				c.units, ok = g.concepts.Get(UnitDomain, unit)
+			}
+			if !ok {
+				return nil, false
+			}
+		}
+	}
+	if c.lower, ok = newBound(r.Lower, r.Unit, unit); !ok {
+		return nil, false
+	}
+	if c.upper, ok = newBound(r.Upper, r.Unit, unit); !ok {
+		return nil, false
+	}
+	if c.lower == nil && c.upper == nil {
+		return nil, false
+	}
+	return c, true
+}
+
+// bound defines a compiled limit. If the limit refers to a reference limit,
+// the value is the multiplier of the reference limit.
+type bound struct {
+	value     float64
+	incl      bool
+	reference relation.Reference
+}
+
+// newBound compiles the limit and converts an absolute limit from one unit to another.
+// A nil limit is compiled to a nil bound. The second value is false if the limit is invalid.
+func newBound(l *relation.Limit, from, to string) (*bound, bool) {
+	if l == nil {
+		return nil, true
+	}
+	v, err := strconv.ParseFloat(l.Value, 64)
+	if err != nil {
+		return nil, false
+	}
+	if l.Reference == relation.NoReference && len(from) > 0 && from != to {
+		var ok bool
+		if v, ok = units.Get().Convert(v, from, to, ""); !ok {
+			return nil, false
+		}
+	}
+	return &bound{value: v, incl: l.Incl, reference: l.Reference}, true
+}
+
+// below returns true if x satisfies the bound as a lower limit, given the reference limit ref.
+func (b *bound) below(x, ref float64) bool {
+	limit := b.value
+	if b.reference != relation.NoReference {
+		limit *= ref
+	}
+	return x > limit || (b.incl && x == limit)
+}
+
+// above returns true if x satisfies the bound as an upper limit, given the reference limit ref.
+func (b *bound) above(x, ref float64) bool {
+	limit := b.value
+	if b.reference != relation.NoReference {
+		limit *= ref
+	}
+	return x < limit || (b.incl && x == limit)
+}
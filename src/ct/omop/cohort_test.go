@@ -0,0 +1,165 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package omop
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/studies"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const concepts = `#key,domain,concept_ids
+age,person,
+gender,person,
+female,gender,8532
+bmi,measurement,3038553
+a1c,measurement,3004410
+weight,measurement,3025315
+ast,measurement,3013721
+D003924,condition,201826
+kg/m2,unit,9531
+%,unit,8554
+kg,unit,9529
+`
+
+func value(v float64) *float64 {
+	return &v
+}
+
+func TestReadConcepts(t *testing.T) {
+	a := assert.New(t)
+
+	c, err := ReadConcepts(strings.NewReader(concepts + "D003922,condition,201254|443238\n"))
+	a.NoError(err)
+	a.Equal(12, c.Size())
+	ids, ok := c.Get(ConditionDomain, "D003922")
+	a.True(ok)
+	a.Equal([]int64{201254, 443238}, ids)
+	_, ok = c.Get(MeasurementDomain, "ecog")
+	a.False(ok)
+
+	for _, s := range []string{"bmi,measurement,\n", "bmi,measurement,x\n", "bmi,lab,3038553\n", "height,person,\n", "bmi\n"} {
+		_, err := ReadConcepts(strings.NewReader(s))
+		a.Error(err, s)
+	}
+}
+
+func TestCohort(t *testing.T) {
+	a := assert.New(t)
+
+	input := `Inclusion Criteria:
+
+            Aged 18 to 59.
+
+            BMI ≥ 30 kg/m2 or HbA1c > 7%.
+
+            ECOG 0-1.
+
+            Exclusion Criteria:
+
+            Weigh more than 120 kg.
+
+            AST > 2.5 x ULN.`
+
+	study := studies.NewStudy("NCT00000001", "Better Health for Everybody", nil, input)
+	study.Parse()
+
+	c, err := ReadConcepts(strings.NewReader(concepts))
+	a.NoError(err)
+	g := NewGenerator(c)
+	g.SetIndexYear(2024)
+	cohort := g.Cohort(study)
+	a.Len(cohort.Inclusions, 2)
+	a.Len(cohort.Exclusions, 2)
+	a.Equal([]string{"ECOG 0-1"}, cohort.Unmapped)
+
+	expected := `-- NCT00000001
+-- Unmapped: ECOG 0-1
+SELECT p.person_id
+FROM person p
+WHERE
+  -- Aged 18 to 59
+  ((2024 - p.year_of_birth) >= 18 AND (2024 - p.year_of_birth) <= 59)
+  -- BMI ≥ 30 kg/m2 or HbA1c > 7%
+  AND ((EXISTS (SELECT 1 FROM measurement m WHERE m.person_id = p.person_id AND m.measurement_concept_id IN (3038553) AND m.unit_concept_id IN (9531) AND m.value_as_number >= 30)) OR (EXISTS (SELECT 1 FROM measurement m WHERE m.person_id = p.person_id AND m.measurement_concept_id IN (3004410) AND m.unit_concept_id IN (8554) AND m.value_as_number > 7)))
+  -- Exclusion: Weigh more than 120 kg
+  AND NOT (EXISTS (SELECT 1 FROM measurement m WHERE m.person_id = p.person_id AND m.measurement_concept_id IN (3025315) AND m.unit_concept_id IN (9529) AND m.value_as_number > 120))
+  -- Exclusion: AST > 2.5 x ULN
+  AND NOT (EXISTS (SELECT 1 FROM measurement m WHERE m.person_id = p.person_id AND m.measurement_concept_id IN (3013721) AND m.value_as_number > 2.5 * m.range_high));
+`
+	a.Equal(expected, cohort.SQL())
+
+	db := &Database{
+		Persons: []Person{
+			{PersonID: 1, GenderConceptID: 8532, YearOfBirth: 1980},
+			{PersonID: 2, GenderConceptID: 8507, YearOfBirth: 1980},
+			{PersonID: 3, GenderConceptID: 8532, YearOfBirth: 1960},
+			{PersonID: 4, GenderConceptID: 8532, YearOfBirth: 1990},
+			{PersonID: 5, GenderConceptID: 8507, YearOfBirth: 1990},
+			{PersonID: 6, GenderConceptID: 8532, YearOfBirth: 1990},
+			{PersonID: 7, GenderConceptID: 8507, YearOfBirth: 1990},
+			{PersonID: 8, GenderConceptID: 8532, YearOfBirth: 1990},
+		},
+		Measurements: []Measurement{
+			{PersonID: 1, MeasurementConceptID: 3038553, ValueAsNumber: value(32), UnitConceptID: 9531},
+			{PersonID: 2, MeasurementConceptID: 3004410, ValueAsNumber: value(8), UnitConceptID: 8554},
+			{PersonID: 3, MeasurementConceptID: 3038553, ValueAsNumber: value(35), UnitConceptID: 9531},
+			{PersonID: 4, MeasurementConceptID: 3038553, ValueAsNumber: value(32)},
+			{PersonID: 5, MeasurementConceptID: 3038553, ValueAsNumber: value(31), UnitConceptID: 9531},
+			{PersonID: 5, MeasurementConceptID: 3025315, ValueAsNumber: value(130), UnitConceptID: 9529},
+			{PersonID: 6, MeasurementConceptID: 3038553, ValueAsNumber: value(31), UnitConceptID: 9531},
+			{PersonID: 6, MeasurementConceptID: 3013721, ValueAsNumber: value(100), RangeHigh: value(35)},
+			{PersonID: 7, MeasurementConceptID: 3038553, ValueAsNumber: value(31), UnitConceptID: 9531},
+			{PersonID: 7, MeasurementConceptID: 3013721, ValueAsNumber: value(50), RangeHigh: value(35)},
+			{PersonID: 7, MeasurementConceptID: 3013721, ValueAsNumber: value(200)},
+			{PersonID: 8, MeasurementConceptID: 3004410, ValueAsNumber: value(7), UnitConceptID: 8554},
+		},
+	}
+	a.Equal([]int64{1, 2, 7}, cohort.Persons(db))
+}
+
+func TestCategoricalCriteria(t *testing.T) {
+	a := assert.New(t)
+
+	c, err := ReadConcepts(strings.NewReader(concepts))
+	a.NoError(err)
+	g := NewGenerator(c)
+
+	female := &relation.Relation{Name: "gender", Value: []string{"female"}, VariableType: variables.Nominal, Score: 1}
+	diabetes := &relation.Relation{Name: "diabetes", Value: []string{"D003924"}, VariableType: variables.Nominal, Score: 1}
+	k, ok := g.criterion(criteria.NewCriterion("Women or type 2 diabetes", 1, relation.Relations{female, diabetes}), false)
+	a.True(ok)
+	a.Equal("((p.gender_concept_id IN (8532)) OR (EXISTS (SELECT 1 FROM condition_occurrence co"+
+		" WHERE co.person_id = p.person_id AND co.condition_concept_id IN (201826))))", k.sql(currentYear))
+
+	db := &Database{
+		Persons: []Person{
+			{PersonID: 1, GenderConceptID: 8532},
+			{PersonID: 2, GenderConceptID: 8507},
+			{PersonID: 3, GenderConceptID: 8507},
+		},
+		ConditionOccurrences: []ConditionOccurrence{{PersonID: 2, ConditionConceptID: 201826}},
+	}
+	cohort := &Cohort{Inclusions: []*Criterion{k}}
+	a.Equal([]int64{1, 2}, cohort.Persons(db))
+
+	k, ok = g.criterion(criteria.NewCriterion("Type 2 diabetes", 1, relation.Relations{diabetes}), true)
+	a.True(ok)
+	cohort = &Cohort{Exclusions: []*Criterion{k}}
+	a.Equal([]int64{1, 3}, cohort.Persons(db))
+
+	male := &relation.Relation{Name: "gender", Value: []string{"male"}, VariableType: variables.Nominal, Score: 1}
+	_, ok = g.criterion(criteria.NewCriterion("Men", 1, relation.Relations{male}), false)
+	a.False(ok)
+
+	infant := &relation.Relation{Name: "age", Unit: "month", Upper: &relation.Limit{Incl: false, Value: "18"}, VariableType: variables.Numerical, Score: 1}
+	k, ok = g.criterion(criteria.NewCriterion("Younger than 18 months", 1, relation.Relations{infant}), false)
+	a.True(ok)
+	a.Equal("((EXTRACT(YEAR FROM CURRENT_DATE) - p.year_of_birth) < 1.5)", k.sql(currentYear))
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package omop
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
+
+	"github.com/golang/glog"
+)
+
+// Domain defines the OMOP CDM domain of a concept mapping.
+type Domain string
+
+const (
+	PersonDomain      Domain = "person"      // Variables of the person table: age and gender
+	GenderDomain      Domain = "gender"      // Gender values mapped to gender_concept_id
+	MeasurementDomain Domain = "measurement" // Variables mapped to measurement_concept_id
+	ConditionDomain   Domain = "condition"   // Nominal values or boolean variables mapped to condition_concept_id
+	UnitDomain        Domain = "unit"        // Unit names mapped to unit_concept_id
+)
+
+// Concepts maps variable names, nominal values (such as MeSH ids), and unit names to OMOP concept ids.
+type Concepts struct {
+	domains map[Domain]map[string][]int64
+}
+
+// NewConcepts creates a new empty concept mapping.
+func NewConcepts() *Concepts {
+	return &Concepts{domains: make(map[Domain]map[string][]int64)}
+}
+
+// Add maps the key to the concept ids in the domain.
+func (c *Concepts) Add(domain Domain, key string, ids ...int64) {
+	keys, ok := c.domains[domain]
+	if !ok {
+		keys = make(map[string][]int64)
+		c.domains[domain] = keys
+	}
+	keys[key] = append(keys[key], ids...)
+}
+
+// Get returns the concept ids of the key in the domain. The second value is false if the key is not mapped.
+func (c *Concepts) Get(domain Domain, key string) ([]int64, bool) {
+	ids, ok := c.domains[domain][key]
+	return ids, ok
+}
+
+// Size returns the number of mapped keys.
+func (c *Concepts) Size() int {
+	n := 0
+	for _, keys := range c.domains {
+		n += len(keys)
+	}
+	return n
+}
+
+// LoadConcepts loads the concept mapping from a csv file with the columns key, domain,
+// and concept ids separated by '|'. Measurement, condition, gender, and unit keys need
+// at least one concept id. Person keys, 'age' and 'gender', need none.
+func LoadConcepts(fname string) (*Concepts, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := ReadConcepts(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	glog.Infof("Number of OMOP concept mappings loaded: %d\n", c.Size())
+	return c, nil
+}
+
+// ReadConcepts reads the concept mapping in the csv format of LoadConcepts.
+func ReadConcepts(r io.Reader) (*Concepts, error) {
+	c := NewConcepts()
+	reader := csv.NewReader(r)
+	reader.Comment = rune(param.Comment)
+	reader.FieldsPerRecord = -1
+
+	for {
+		line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(line) < 2 {
+			return nil, fmt.Errorf("too few columns, at least 2 needed: %v", line)
+		}
+		key, domain := strings.TrimSpace(line[0]), Domain(strings.TrimSpace(line[1]))
+		var ids []int64
+		if len(line) > 2 && len(strings.TrimSpace(line[2])) > 0 {
+			for _, s := range strings.Split(line[2], "|") {
+				id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("bad concept id: %v", line)
+				}
+				ids = append(ids, id)
+			}
+		}
+		switch domain {
+		case PersonDomain:
+			if key != "age" && key != "gender" {
+				return nil, fmt.Errorf("unknown person variable, expected age or gender: %v", line)
+			}
+		case GenderDomain, MeasurementDomain, ConditionDomain, UnitDomain:
+			if len(ids) == 0 {
+				return nil, fmt.Errorf("missing concept ids: %v", line)
+			}
+		default:
+			return nil, fmt.Errorf("unknown domain: %v", line)
+		}
+		c.Add(domain, key, ids...)
+	}
+	return c, nil
+}
@@ -0,0 +1,187 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package omop
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+)
+
+// ageCondition defines an age range computed from the year of birth of the person.
+type ageCondition struct {
+	lower *bound
+	upper *bound
+}
+
+func (c *ageCondition) sql(year string) string {
+	age := "(" + year + " - p.year_of_birth)"
+	var s []string
+	if c.lower != nil {
+		s = append(s, age+" "+comparator(">", c.lower.incl)+" "+formatFloat(c.lower.value))
+	}
+	if c.upper != nil {
+		s = append(s, age+" "+comparator("<", c.upper.incl)+" "+formatFloat(c.upper.value))
+	}
+	return strings.Join(s, " AND ")
+}
+
+func (c *ageCondition) holds(db *Database, p *Person, year int) bool {
+	age := float64(year - p.YearOfBirth)
+	return (c.lower == nil || c.lower.below(age, 0)) && (c.upper == nil || c.upper.above(age, 0))
+}
+
+// genderCondition defines the gender concepts of the person.
+type genderCondition struct {
+	concepts []int64
+}
+
+func (c *genderCondition) sql(year string) string {
+	return "p.gender_concept_id IN (" + formatIDs(c.concepts) + ")"
+}
+
+func (c *genderCondition) holds(db *Database, p *Person, year int) bool {
+	return contains(c.concepts, p.GenderConceptID)
+}
+
+// measurementCondition defines a measurement of the person whose value is in a range or,
+// for ordinal variables, one of the values. If units are set, the unit of the measurement
+// must be one of them. A relative limit is multiplied by the range of the measurement.
+type measurementCondition struct {
+	concepts []int64
+	units    []int64
+	lower    *bound
+	upper    *bound
+	values   []float64
+}
+
+func (c *measurementCondition) sql(year string) string {
+	s := []string{
+		"m.person_id = p.person_id",
+		"m.measurement_concept_id IN (" + formatIDs(c.concepts) + ")",
+	}
+	if len(c.units) > 0 {
+		s = append(s, "m.unit_concept_id IN ("+formatIDs(c.units)+")")
+	}
+	if c.lower != nil {
+		s = append(s, "m.value_as_number "+comparator(">", c.lower.incl)+" "+limit(c.lower))
+	}
+	if c.upper != nil {
+		s = append(s, "m.value_as_number "+comparator("<", c.upper.incl)+" "+limit(c.upper))
+	}
+	if len(c.values) > 0 {
+		values := make([]string, len(c.values))
+		for i, v := range c.values {
+			values[i] = formatFloat(v)
+		}
+		s = append(s, "m.value_as_number IN ("+strings.Join(values, ", ")+")")
+	}
+	return "EXISTS (SELECT 1 FROM measurement m WHERE " + strings.Join(s, " AND ") + ")"
+}
+
+func (c *measurementCondition) holds(db *Database, p *Person, year int) bool {
+	for _, m := range db.Measurements {
+		if m.PersonID != p.PersonID || m.ValueAsNumber == nil || !contains(c.concepts, m.MeasurementConceptID) {
+			continue
+		}
+		if len(c.units) > 0 && !contains(c.units, m.UnitConceptID) {
+			continue
+		}
+		x := *m.ValueAsNumber
+		if c.lower != nil {
+			if ref, ok := m.reference(c.lower.reference); !ok || !c.lower.below(x, ref) {
+				continue
+			}
+		}
+		if c.upper != nil {
+			if ref, ok := m.reference(c.upper.reference); !ok || !c.upper.above(x, ref) {
+				continue
+			}
+		}
+		if len(c.values) > 0 && !containsFloat(c.values, x) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// occurrenceCondition defines a condition occurrence of the person,
+// or its absence for a negated boolean relation.
+type occurrenceCondition struct {
+	concepts []int64
+	absent   bool
+}
+
+func (c *occurrenceCondition) sql(year string) string {
+	s := "EXISTS (SELECT 1 FROM condition_occurrence co WHERE co.person_id = p.person_id" +
+		" AND co.condition_concept_id IN (" + formatIDs(c.concepts) + "))"
+	if c.absent {
+		return "NOT " + s
+	}
+	return s
+}
+
+func (c *occurrenceCondition) holds(db *Database, p *Person, year int) bool {
+	for _, co := range db.ConditionOccurrences {
+		if co.PersonID == p.PersonID && contains(c.concepts, co.ConditionConceptID) {
+			return !c.absent
+		}
+	}
+	return c.absent
+}
+
+// comparator returns the SQL comparison operator, such as '>' or '>='.
+func comparator(op string, incl bool) string {
+	if incl {
+		return op + "="
+	}
+	return op
+}
+
+// limit returns the SQL expression of the bound on the measurement value.
+func limit(b *bound) string {
+	switch b.reference {
+	case relation.ULN:
+		return formatFloat(b.value) + " * m.range_high"
+	case relation.LLN:
+		return formatFloat(b.value) + " * m.range_low"
+	default:
+		return formatFloat(b.value)
+	}
+}
+
+// formatFloat formats the number without trailing zeros.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatIDs formats the concept ids as a comma-separated list.
+func formatIDs(ids []int64) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(s, ", ")
+}
+
+// contains returns true if the concept ids contain the id.
+func contains(ids []int64, id int64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFloat returns true if the values contain the value.
+func containsFloat(values []float64, v float64) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
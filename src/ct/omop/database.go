@@ -0,0 +1,56 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package omop
+
+import (
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+)
+
+// Person defines a row of the OMOP CDM person table.
+type Person struct {
+	PersonID        int64
+	GenderConceptID int64
+	YearOfBirth     int
+}
+
+// Measurement defines a row of the OMOP CDM measurement table. Nil values are NULL.
+type Measurement struct {
+	PersonID             int64
+	MeasurementConceptID int64
+	ValueAsNumber        *float64
+	UnitConceptID        int64
+	RangeLow             *float64
+	RangeHigh            *float64
+}
+
+// reference returns the reference limit of the measurement. The second value is false
+// if the limit is NULL. The reference of an absolute limit is zero.
+func (m Measurement) reference(ref relation.Reference) (float64, bool) {
+	var limit *float64
+	switch ref {
+	case relation.NoReference:
+		return 0, true
+	case relation.ULN:
+		limit = m.RangeHigh
+	case relation.LLN:
+		limit = m.RangeLow
+	}
+	if limit == nil {
+		return 0, false
+	}
+	return *limit, true
+}
+
+// ConditionOccurrence defines a row of the OMOP CDM condition_occurrence table.
+type ConditionOccurrence struct {
+	PersonID           int64
+	ConditionConceptID int64
+}
+
+// Database defines an in-memory OMOP CDM database with the tables that cohorts refer to.
+// It evaluates cohorts without a database server, for example, in tests.
+type Database struct {
+	Persons              []Person
+	Measurements         []Measurement
+	ConditionOccurrences []ConditionOccurrence
+}
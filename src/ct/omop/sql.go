@@ -0,0 +1,105 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package omop
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// currentYear is the SQL expression of the current year.
+const currentYear = "EXTRACT(YEAR FROM CURRENT_DATE)"
+
+// SQL returns the SQL query that selects the person ids of the cohort from the OMOP CDM tables.
+// Each criterion is preceded by its text as a comment, and unmapped criteria are listed in
+// the header comment.
+func (c *Cohort) SQL() string {
+	year := currentYear
+	if c.year > 0 {
+		year = strconv.Itoa(c.year)
+	}
+	var b strings.Builder
+	b.WriteString("-- " + c.NCT + "\n")
+	for _, text := range c.Unmapped {
+		b.WriteString("-- Unmapped: " + comment(text) + "\n")
+	}
+	b.WriteString("SELECT p.person_id\nFROM person p")
+	if len(c.Inclusions)+len(c.Exclusions) > 0 {
+		b.WriteString("\nWHERE")
+	}
+	op := ""
+	for _, k := range c.Inclusions {
+		b.WriteString("\n  -- " + comment(k.Text) + "\n  " + op + k.sql(year))
+		op = "AND "
+	}
+	for _, k := range c.Exclusions {
+		b.WriteString("\n  -- Exclusion: " + comment(k.Text) + "\n  " + op + "NOT " + k.sql(year))
+		op = "AND "
+	}
+	b.WriteString(";\n")
+	return b.String()
+}
+
+// sql returns the SQL expression of the criterion.
+func (k *Criterion) sql(year string) string {
+	if len(k.conditions) == 1 {
+		return "(" + k.conditions[0].sql(year) + ")"
+	}
+	op := " OR "
+	if k.All {
+		op = " AND "
+	}
+	s := make([]string, len(k.conditions))
+	for i, c := range k.conditions {
+		s[i] = "(" + c.sql(year) + ")"
+	}
+	return "(" + strings.Join(s, op) + ")"
+}
+
+// holds returns true if the criterion holds for the person.
+func (k *Criterion) holds(db *Database, p *Person, year int) bool {
+	for _, c := range k.conditions {
+		if c.holds(db, p, year) != k.All {
+			return !k.All
+		}
+	}
+	return k.All
+}
+
+// Persons returns the ids of the persons in the database who belong to the cohort,
+// in the order of the person table. It evaluates the same conditions as the SQL query.
+func (c *Cohort) Persons(db *Database) []int64 {
+	year := c.year
+	if year == 0 {
+		year = time.Now().Year()
+	}
+	var ids []int64
+	for i := range db.Persons {
+		p := &db.Persons[i]
+		if c.holds(db, p, year) {
+			ids = append(ids, p.PersonID)
+		}
+	}
+	return ids
+}
+
+// holds returns true if every inclusion criterion and no exclusion criterion holds for the person.
+func (c *Cohort) holds(db *Database, p *Person, year int) bool {
+	for _, k := range c.Inclusions {
+		if !k.holds(db, p, year) {
+			return false
+		}
+	}
+	for _, k := range c.Exclusions {
+		if k.holds(db, p, year) {
+			return false
+		}
+	}
+	return true
+}
+
+// comment converts the text to a single-line SQL comment.
+func comment(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
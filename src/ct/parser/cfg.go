@@ -3,7 +3,10 @@
 package parser
 
 import (
-	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
 )
 
 // Element defines the element of the CYK state table.
@@ -50,58 +53,172 @@ func NewCFGrammar(s string) *CFG {
 	return &CFG{rules: LoadRules(s)}
 }
 
-// BuildTrees computes the parse trees from the input items using the Lange-Leiss implementation
+// ParseCFGrammar creates a new Context-Free Grammar from the production rules in s.
+// It returns GrammarErrors if the rules are not valid.
+func ParseCFGrammar(s string) (*CFG, error) {
+	rules, err := ParseRules(s)
+	if err != nil {
+		return nil, err
+	}
+	return &CFG{rules: rules}, nil
+}
+
+// LoadCFGrammar creates a new Context-Free Grammar from the production rules in a file.
+func LoadCFGrammar(fname string) (*CFG, error) {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	g, err := ParseCFGrammar(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s:\n%v", fname, err)
+	}
+	return g, nil
+}
+
+// Rules returns the production rules of the grammar.
+func (g *CFG) Rules() *Rules {
+	return g.rules
+}
+
+// Chart defines the CYK state table populated for the input items. The cell (begin, end)
+// holds the nonterminals that derive the items from begin to end with the probability of their
+// most probable (Viterbi) derivation, the total (inside) probability of all their derivations,
+// and the elements from which the most probable derivations are built.
+// Items without terminal rules are not in the table.
+type Chart struct {
+	items    Items
+	index    []int // index maps the state table positions to the items.
+	best     [][]Probabilities
+	inside   [][]Probabilities
+	children [][]map[string]Element
+}
+
+// Dim returns the dimension of the state table.
+func (c *Chart) Dim() int {
+	return len(c.index)
+}
+
+// NonTerminals returns the sorted nonterminals that derive the items from begin to end
+// in the state table.
+func (c *Chart) NonTerminals(begin, end int) []string {
+	return sortedKeys(c.best[begin][end])
+}
+
+// Probability returns the probability of the most probable derivation of the items
+// from begin to end by the nonterminal A.
+func (c *Chart) Probability(begin, end int, A string) float64 {
+	return c.best[begin][end][A]
+}
+
+// Inside returns the total probability of all derivations of the items from begin to end
+// by the nonterminal A.
+func (c *Chart) Inside(begin, end int, A string) float64 {
+	return c.inside[begin][end][A]
+}
+
+// String returns the string representation of the non-empty cells of the state table.
+// Each nonterminal is followed by the probability of its most probable derivation.
+func (c *Chart) String() string {
+	s := ""
+	for span := 1; span <= c.Dim(); span++ {
+		for begin := 0; begin <= c.Dim()-span; begin++ {
+			end := begin + span - 1
+			if len(c.best[begin][end]) == 0 {
+				continue
+			}
+			values := make([]string, 0, span)
+			for _, i := range c.index[begin : end+1] {
+				values = append(values, c.items[i].val)
+			}
+			nonTerminals := c.NonTerminals(begin, end)
+			for i, A := range nonTerminals {
+				nonTerminals[i] = fmt.Sprintf("%s:%.3g", A, c.best[begin][end][A])
+			}
+			s += fmt.Sprintf("[%d,%d] %q: %s\n", begin, end, strings.Join(values, " "), strings.Join(nonTerminals, " "))
+		}
+	}
+	return s
+}
+
+// sortedKeys returns the sorted nonterminals of the probabilities.
+func sortedKeys(ps Probabilities) []string {
+	keys := make([]string, 0, len(ps))
+	for A := range ps {
+		keys = append(keys, A)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Chart populates the CYK state table from the input items using the Lange-Leiss implementation
 // of the CYK algorithm. The grammar is assumed to be in the binary normal form.
 // Lange and Leiss, "To CNF or not to CNF? An Efficient Yet Presentable Version of the CYK Algorithm",
 // Informatica Didactica 8 (2009).
-func (g *CFG) BuildTrees(items Items) Trees {
+// Each cell keeps the most probable derivation per nonterminal (Viterbi) and the sum of
+// the probabilities of all derivations (inside probability). Ties are broken by the order
+// of the splits and the nonterminals, so the result is deterministic.
+func (g *CFG) Chart(items Items) *Chart {
 	dim := items.Len()
-	if dim == 0 {
-		return nil
-	}
 
-	state := make([][]set.Set, dim)
-	for i := 0; i < dim; i++ {
-		state[i] = make([]set.Set, dim)
-		for j := 0; j < dim; j++ {
-			state[i][j] = set.New()
-		}
-	}
+	best := make([][]Probabilities, dim)
+	inside := make([][]Probabilities, dim)
 	children := make([][]map[string]Element, dim)
 	for i := 0; i < dim; i++ {
+		best[i] = make([]Probabilities, dim)
+		inside[i] = make([]Probabilities, dim)
 		children[i] = make([]map[string]Element, dim)
 		for j := 0; j < dim; j++ {
+			best[i][j] = Probabilities{}
+			inside[i][j] = Probabilities{}
 			children[i][j] = map[string]Element{}
 		}
 	}
+	keys := make([][][]string, dim)
+	for i := 0; i < dim; i++ {
+		keys[i] = make([][]string, dim)
+	}
 
 	rules := g.rules
 
+	// closeUnary applies the unary rules A -> B to the cell. Nonterminals are visited
+	// so that the probabilities of B are final before they are propagated to A.
+	closeUnary := func(begin, end int) {
+		cellBest := best[begin][end]
+		cellInside := inside[begin][end]
+		for _, B := range rules.unaryOrder {
+			pb, ok := cellBest[B]
+			if !ok {
+				continue
+			}
+			e := NewUnary(B)
+			for _, A := range sortedKeys(rules.unaryRules[e]) {
+				p := rules.unaryRules[e][A]
+				cellInside[A] += p * cellInside[B]
+				if q := p * pb; q > cellBest[A] {
+					cellBest[A] = q
+					children[begin][end][A] = e.Set(begin, end, end)
+				}
+			}
+		}
+		keys[begin][end] = sortedKeys(cellBest)
+	}
+
+	index := make([]int, 0, dim)
+
 	k := 0
 	for i := 0; i < dim; i++ {
 		term := items[i].typ
-		if rules.terminalRules[term].Empty() {
+		if len(rules.terminalRules[term]) == 0 {
 			continue
 		}
-		for A := range rules.terminalRules[term] {
-			state[k][k].Add(A)
+		index = append(index, i)
+		for A, p := range rules.terminalRules[term] {
+			best[k][k][A] = p
+			inside[k][k][A] = p
 			children[k][k][A] = NewUnary(items[i].val).Set(k, k, k)
 		}
-		added := true
-		for added {
-			added = false
-			for p, v := range rules.unaryRules {
-				if A := p.leftNonTerminal; state[k][k][A] {
-					for B := range v {
-						if !state[k][k][B] {
-							state[k][k].Add(B)
-							children[k][k][B] = p.Set(k, k, k)
-							added = true
-						}
-					}
-				}
-			}
-		}
+		closeUnary(k, k)
 		k++
 	}
 	dim = k
@@ -109,41 +226,69 @@ func (g *CFG) BuildTrees(items Items) Trees {
 	for span := 2; span <= dim; span++ {
 		for begin := 0; begin <= dim-span; begin++ {
 			end := begin + span - 1
+			cellBest := best[begin][end]
+			cellInside := inside[begin][end]
 			for split := begin; split < end; split++ {
-				for B := range state[begin][split] {
-					for C := range state[split+1][end] {
+				for _, B := range keys[begin][split] {
+					for _, C := range keys[split+1][end] {
 						p := NewBinary(B, C)
-						for A := range rules.binaryRules[p] {
-							state[begin][end].Add(A)
-							children[begin][end][A] = p.Set(begin, split, end)
+						ps, ok := rules.binaryRules[p]
+						if !ok {
+							continue
 						}
-					}
-				}
-			}
-			foundUnaryDerivation := true
-			for foundUnaryDerivation {
-				foundUnaryDerivation = false
-				for p, v := range rules.unaryRules {
-					if A := p.leftNonTerminal; state[begin][end][A] {
-						for B := range v {
-							if !state[begin][end][B] {
-								state[begin][end].Add(B)
-								children[begin][end][B] = p.Set(begin, end, end)
-								foundUnaryDerivation = true
+						for _, A := range sortedKeys(ps) {
+							cellInside[A] += ps[A] * inside[begin][split][B] * inside[split+1][end][C]
+							if q := ps[A] * best[begin][split][B] * best[split+1][end][C]; q > cellBest[A] {
+								cellBest[A] = q
+								children[begin][end][A] = p.Set(begin, split, end)
 							}
 						}
 					}
 				}
 			}
+			closeUnary(begin, end)
 		}
 	}
 
-	// Build a parse tree stored in the children table:
+	return &Chart{items: items, index: index, best: best, inside: inside, children: children}
+}
+
+// BuildTrees computes the parse trees from the input items with the CYK algorithm.
+func (g *CFG) BuildTrees(items Items) Trees {
+	if items.Len() == 0 {
+		return nil
+	}
+	return g.Chart(items).Trees()
+}
+
+// node builds the most probable subtree of the nonterminal A that spans the items
+// from begin to end in the state table.
+func (c *Chart) node(A string, begin, end int) *Node {
+	items := c.items
+	index := c.index
+	children := c.children
+
+	// newNode creates a node that spans the items from begin to end in the state table.
+	// Items are not necessarily in the input order, e.g., when the variable is inferred.
+	newNode := func(val string, begin, end int) *Node {
+		node := NewNode(val)
+		node.begin = items[index[begin]].begin
+		node.end = items[index[begin]].end
+		for _, i := range index[begin+1 : end+1] {
+			if items[i].begin < node.begin {
+				node.begin = items[i].begin
+			}
+			if items[i].end > node.end {
+				node.end = items[i].end
+			}
+		}
+		return node
+	}
 
 	var iter func(n *Node, p Element)
 
 	iter = func(n *Node, p Element) {
-		node := NewNode(p.leftNonTerminal)
+		node := newNode(p.leftNonTerminal, p.begin, p.split)
 		n.left = node
 		next, ok := children[p.begin][p.split][p.leftNonTerminal]
 		if ok {
@@ -154,7 +299,7 @@ func (g *CFG) BuildTrees(items Items) Trees {
 			return
 		}
 
-		node = NewNode(p.rightNonTerminal)
+		node = newNode(p.rightNonTerminal, p.split+1, p.end)
 		n.right = node
 		next, ok = children[p.split+1][p.end][p.rightNonTerminal]
 		if ok {
@@ -162,15 +307,31 @@ func (g *CFG) BuildTrees(items Items) Trees {
 		}
 	}
 
+	node := newNode(A, begin, end)
+	if next, ok := children[begin][end][A]; ok {
+		iter(node, next)
+	}
+	return node
+}
+
+// Trees builds the most probable parse trees stored in the state table. The trees that span
+// the most items are returned. The tree score is the probability of the tree conditioned on
+// the items that it spans, i.e., its Viterbi probability divided by the inside probability,
+// times a coverage penalty that decreases linearly from 1 to 0.5 with the fraction of the items
+// that the tree does not span, so a tree of a small part of the criterion does not score 1.
+func (c *Chart) Trees() Trees {
+	dim := c.Dim()
 	trees := NewTrees()
 
 	for k := 0; k < dim; k++ {
-		score := 1.0 - 0.5*float64(k)/float64(dim)
+		coverage := 1.0 - 0.5*float64(k)/float64(dim)
 		for i := 0; i <= k; i++ {
-			if next, ok := children[i][dim+i-k-1]["S"]; ok {
-				node := NewNode("S")
-				iter(node, next)
+			j := dim + i - k - 1
+			if _, ok := c.children[i][j][startSymbol]; ok {
+				node := c.node(startSymbol, i, j)
 				if node.Size() > 1 {
+					// The score is the probability of the most probable tree given the items it spans.
+					score := coverage * c.best[i][j][startSymbol] / c.inside[i][j][startSymbol]
 					tree := NewTree(node, score)
 					trees = append(trees, tree)
 				}
@@ -183,3 +344,26 @@ func (g *CFG) BuildTrees(items Items) Trees {
 
 	return trees
 }
+
+// Nodes builds the most probable subtrees of the nonterminal A that span the most items
+// without overlapping. The spans are chosen from left to right, the longest first.
+func (c *Chart) Nodes(A string) []*Node {
+	var nodes []*Node
+	dim := c.Dim()
+	for begin := 0; begin < dim; {
+		end := -1
+		for j := dim - 1; j >= begin; j-- {
+			if _, ok := c.best[begin][j][A]; ok {
+				end = j
+				break
+			}
+		}
+		if end < 0 {
+			begin++
+			continue
+		}
+		nodes = append(nodes, c.node(A, begin, end))
+		begin = end + 1
+	}
+	return nodes
+}
@@ -3,6 +3,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser/production"
@@ -24,11 +25,62 @@ func TestCYKParsingAlgoritm(t *testing.T) {
 		NewItem(ItemType(x), x),
 		NewItem(ItemType(x), x),
 	}
-	expected := `{"score":1.000,"tree":{"value":"S","left":{"value":"A","left":{"value":"B","left":{"value":"and"}},"right":{"value":"A","left":{"value":"or"}}},"right":{"value":"B","left":{"value":"C","left":{"value":"or"}},"right":{"value":"C","left":{"value":"or"}}}}}`
+	// Both parse trees, S -> A B and S -> B C, are equally probable, so the score is one half.
+	// On a tie, the Viterbi search keeps the derivation with the first split, so this tree is
+	// returned. The S -> A B tree expected before the rules had probabilities changed on the tie,
+	// not because either tree became more probable.
+	expected := `{"score":0.500,"tree":{"value":"S","left":{"value":"B","left":{"value":"and"}},"right":{"value":"C","left":{"value":"A","left":{"value":"or"}},"right":{"value":"B","left":{"value":"C","left":{"value":"or"}},"right":{"value":"C","left":{"value":"or"}}}}}}`
 	actual := g.BuildTrees(input).String()
 	a.Equal(expected, actual)
 }
 
+func TestWeightedCYKParsingAlgoritm(t *testing.T) {
+	a := assert.New(t)
+	rules := strings.Replace(production.TestRules, "S -> A B | B C", "S -> A B [3] | B C [1]", 1)
+	g := NewCFGrammar(rules)
+
+	x := "or"
+	y := "and"
+	// input pattern: "y x x x"
+	input := Items{
+		NewItem(ItemType(y), y),
+		NewItem(ItemType(x), x),
+		NewItem(ItemType(x), x),
+		NewItem(ItemType(x), x),
+	}
+	expected := `{"score":0.750,"tree":{"value":"S","left":{"value":"A","left":{"value":"B","left":{"value":"and"}},"right":{"value":"A","left":{"value":"or"}}},"right":{"value":"B","left":{"value":"C","left":{"value":"or"}},"right":{"value":"C","left":{"value":"or"}}}}}`
+	actual := g.BuildTrees(input).String()
+	a.Equal(expected, actual)
+
+	chart := g.Chart(input)
+	a.InDelta(0.75*0.015625, chart.Probability(0, 3, "S"), 1e-9)
+	a.InDelta(0.015625, chart.Inside(0, 3, "S"), 1e-9)
+}
+
+func TestCoveragePenalty(t *testing.T) {
+	a := assert.New(t)
+	g := NewCFGrammar(production.TestRules)
+
+	x := "or"
+	y := "and"
+	// input pattern: "x y"
+	input := Items{
+		NewItem(ItemType(x), x),
+		NewItem(ItemType(y), y),
+	}
+	trees := g.BuildTrees(input)
+	a.Len(trees, 1)
+	a.InDelta(1.0, trees[0].score, 1e-9)
+
+	// input pattern: "x y x"; the trees "x y" and "y x" span two of the three items.
+	input = append(input, NewItem(ItemType(x), x))
+	trees = g.BuildTrees(input)
+	a.Len(trees, 2)
+	for _, tree := range trees {
+		a.InDelta(1.0-0.5/3.0, tree.score, 1e-9)
+	}
+}
+
 func TestCriteriaParsing(t *testing.T) {
 	a := assert.New(t)
 	g := NewCFGrammar(production.CriterionRules)
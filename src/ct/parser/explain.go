@@ -0,0 +1,111 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+)
+
+// Explanation records the intermediate results of interpreting a criterion
+// for debugging the lexer, the parser, and the grammar.
+type Explanation struct {
+	Input        string
+	Tokens       Tokens             // Tokens from the lexer
+	List         List               // Items from the parser after fixing missing variables
+	Charts       []*Chart           // CYK state tables, one per items in the list
+	Candidates   Trees              // Parse trees before deduplication
+	Trees        Trees              // Parse trees after deduplication
+	OrRelations  relation.Relations // 'or' relations from the parse trees with tree scores
+	AndRelations relation.Relations // 'and' relations from the parse trees with tree scores
+	Processed    relation.Relations // Relations after processing and transformation
+}
+
+// Explain interprets the criterion like Interpret and records the intermediate results.
+func (i *Interpreter) Explain(input string) *Explanation {
+	e := &Explanation{Input: input}
+	e.Tokens = NewLexer(input).Drain()
+
+	list := NewParser().Parse(input)
+	list.FixMissingVariable()
+	e.List = list
+
+	candidates := NewTrees()
+	for _, items := range list {
+		chart := i.grammar.Chart(items)
+		e.Charts = append(e.Charts, chart)
+		candidates = append(candidates, chart.Trees()...)
+	}
+	e.Candidates = candidates
+
+	trees := make(Trees, len(candidates))
+	copy(trees, candidates)
+	trees.Dedupe()
+	e.Trees = trees
+
+	e.OrRelations, e.AndRelations = trees.Relations()
+
+	orRels, andRels := trees.Relations()
+	orRels.Process()
+	andRels.Process()
+	e.Processed = append(orRels, andRels...)
+	e.Processed.Transform()
+
+	return e
+}
+
+// String returns the human readable representation of the explanation.
+func (e *Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "input: %q\n", e.Input)
+
+	b.WriteString("\ntokens:\n")
+	for _, t := range e.Tokens {
+		fmt.Fprintf(&b, "  %d-%d %s %q\n", t.pos, t.End(), t.typ, t.val)
+	}
+
+	b.WriteString("\nitems:\n")
+	for k, items := range e.List {
+		fmt.Fprintf(&b, "  segment %d:\n", k)
+		for _, item := range items {
+			fmt.Fprintf(&b, "    %d-%d %s %q\n", item.begin, item.end, item.typ, item.val)
+		}
+	}
+
+	b.WriteString("\ncharts:\n")
+	for k, chart := range e.Charts {
+		fmt.Fprintf(&b, "  segment %d:\n", k)
+		for _, line := range strings.Split(strings.TrimSpace(chart.String()), "\n") {
+			if len(line) > 0 {
+				fmt.Fprintf(&b, "    %s\n", line)
+			}
+		}
+	}
+
+	b.WriteString("\ncandidate trees:\n")
+	for _, t := range e.Candidates {
+		fmt.Fprintf(&b, "  %s\n", t)
+	}
+
+	b.WriteString("\ndeduplicated trees:\n")
+	for _, t := range e.Trees {
+		fmt.Fprintf(&b, "  %s\n", t)
+	}
+
+	b.WriteString("\nor relations:\n")
+	for _, r := range e.OrRelations {
+		fmt.Fprintf(&b, "  %s\n", r.JSON())
+	}
+	b.WriteString("\nand relations:\n")
+	for _, r := range e.AndRelations {
+		fmt.Fprintf(&b, "  %s\n", r.JSON())
+	}
+	b.WriteString("\nprocessed relations:\n")
+	for _, r := range e.Processed {
+		fmt.Fprintf(&b, "  %s\n", r.JSON())
+	}
+
+	return b.String()
+}
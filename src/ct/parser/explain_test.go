@@ -0,0 +1,34 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	a := assert.New(t)
+
+	input := "bmi > 18 or weight > 100 pounds"
+	e := interpreter.Explain(input)
+	a.Len(e.Tokens, 8)
+	a.Len(e.List, 1)
+	a.Len(e.Charts, 1)
+	a.Equal(8, e.Charts[0].Dim())
+	a.Equal([]string{"C", "S"}, e.Charts[0].NonTerminals(0, 7))
+	a.Equal([]string{"A", "B"}, e.Charts[0].NonTerminals(5, 7))
+	a.Len(e.Candidates, 1)
+	a.Len(e.Trees, 1)
+
+	expectedOrRels, expectedAndRels := interpreter.Interpret(input)
+	a.Equal(expectedOrRels, e.OrRelations)
+	a.Equal(expectedAndRels, e.AndRelations)
+	a.Len(e.Processed, 2)
+	a.Equal("weight", e.Processed[0].Name)
+
+	s := e.String()
+	a.Contains(s, `[5,7] "> 100 lb": A:0.358 B:0.6`)
+	a.Contains(s, `25-31 identifier "pounds"`)
+}
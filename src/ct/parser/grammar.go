@@ -4,5 +4,6 @@ package parser
 
 // Grammar defines the grammar interface to parse items into a parse tree.
 type Grammar interface {
+	Chart(Items) *Chart
 	BuildTrees(Items) Trees
 }
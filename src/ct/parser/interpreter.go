@@ -13,6 +13,12 @@ func init() {
 	interpreter = NewInterpreter()
 }
 
+// Set sets the interpreter to parse strings to relations.
+// It must be called before the interpreter is used by concurrent goroutines.
+func Set(i *Interpreter) {
+	interpreter = i
+}
+
 // Get gets the interpreter to parse strings to relations.
 func Get() *Interpreter {
 	return interpreter
@@ -20,24 +26,49 @@ func Get() *Interpreter {
 
 // Interpreter defines the interpreter struct to convert
 // unstructured criteria strings to structured relations.
+// The interpreter is safe for concurrent use: every call parses
+// the input with a new parser, and the grammar is read only.
 type Interpreter struct {
-	parser  *Parser
 	grammar Grammar
 }
 
-// NewInterpreter creates a new interpreter.
+// NewInterpreter creates a new interpreter with the compiled-in criterion grammar.
 func NewInterpreter() *Interpreter {
-	return &Interpreter{parser: NewParser(), grammar: NewCFGrammar(production.CriterionRules)}
+	return NewInterpreterWithGrammar(NewCFGrammar(production.CriterionRules))
+}
+
+// NewInterpreterWithGrammar creates a new interpreter with the grammar g.
+func NewInterpreterWithGrammar(g Grammar) *Interpreter {
+	return &Interpreter{grammar: g}
 }
 
 // Interpret interprets clinical trial criteria using parse trees and formal grammars.
 func (i *Interpreter) Interpret(input string) (relation.Relations, relation.Relations) {
-	list := i.parser.Parse(input)
+	list := NewParser().Parse(input)
 	list.FixMissingVariable()
 	trees := i.buildTrees(list)
 	return trees.Relations()
 }
 
+// Temporals extracts the time windows of the criterion, such as 'within the past 6 months',
+// independently of the relations. It is used to attach time windows to the concepts that
+// are matched to the criterion outside the grammar, such as the NEL output.
+func (i *Interpreter) Temporals(input string) []*relation.Temporal {
+	var ts []*relation.Temporal
+	for _, items := range NewParser().Parse(input) {
+		for _, n := range i.grammar.Chart(items).Nodes(temporalSymbol) {
+			if n.left.val != "K" && items.variableBefore(n.begin) {
+				// The duration is the value of the variable, such as 'age > 18 years before randomization'.
+				continue
+			}
+			if t := n.EvalTemporal(); t != nil {
+				ts = append(ts, t)
+			}
+		}
+	}
+	return ts
+}
+
 // buildTrees builds trees from the parsed items. Trees represent criteria.
 func (i *Interpreter) buildTrees(list List) Trees {
 	trees := NewTrees()
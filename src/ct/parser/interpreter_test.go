@@ -3,9 +3,12 @@
 package parser
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/text"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,6 +23,7 @@ func TestOneNumericalVariableInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -35,6 +39,7 @@ func TestOneNumericalVariableRangeInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -50,6 +55,7 @@ func TestOneCategoricalVariableRangeInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -65,6 +71,7 @@ func TestOneCategoricalVariableSetInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -80,6 +87,7 @@ func TestNumberUnitInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -95,6 +103,7 @@ func TestReverseOrderInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -110,6 +119,7 @@ func TestMisspelledVariableNameInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -125,6 +135,7 @@ func TestMissingVariableNameInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -141,6 +152,7 @@ func TestTwoVariablesInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -157,6 +169,7 @@ func TestTwoVariablesWithExtraWordsInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -173,6 +186,7 @@ func TestTwoVariablesWithMoreStuffInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -189,6 +203,7 @@ func TestRangeWithBetweenAndInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -205,6 +220,7 @@ func TestRangeWithBetweenOrInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualOrRels.Process()
 	actualOrRels.SetScore(0)
+	actualOrRels.ClearSpans()
 
 	a.Empty(actualAndRels)
 	a.Equal(expected, actualOrRels)
@@ -221,6 +237,7 @@ func TestTwoVariablesWithAndOrInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualOrRels.Process()
 	actualOrRels.SetScore(0)
+	actualOrRels.ClearSpans()
 
 	a.Empty(actualAndRels)
 	a.Equal(expected, actualOrRels)
@@ -237,6 +254,7 @@ func TestRangeWithDashInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -252,6 +270,7 @@ func TestRangeWithToInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -267,6 +286,7 @@ func TestRangeWithOrInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -283,6 +303,7 @@ func TestParenthesisInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -299,6 +320,7 @@ func TestTwoVariablesInParenthesisInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -315,6 +337,7 @@ func TestOneVariableWithTwoValuesInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -325,11 +348,44 @@ func TestVariableWithAcronymUnitInterpreter(t *testing.T) {
 
 	input := "aspartate aminotransferase (ast) =< 2.5 x uln (to be performed within14 days prior to day 1 of protocol therapy unless otherwise stated)"
 	expected := relation.Relations{
-		relation.Parse(`{"id":"411","name":"ast","unit":"uln","upper":{"incl":true,"value":"2.5"},"variableType":"numerical"}`),
+		relation.Parse(`{"id":"411","name":"ast","upper":{"incl":true,"value":"2.5","reference":"uln"},"variableType":"numerical"}`),
 	}
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
+
+	a.Empty(actualOrRels)
+	a.Equal(expected, actualAndRels)
+}
+
+func TestTimesReferenceInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	input := "alt less than 3 times the upper limit of normal"
+	expected := relation.Relations{
+		relation.Parse(`{"id":"412","name":"alt","upper":{"incl":false,"value":"3","reference":"uln"},"variableType":"numerical"}`),
+	}
+	actualOrRels, actualAndRels := interpreter.Interpret(input)
+	actualAndRels.Process()
+	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
+
+	a.Empty(actualOrRels)
+	a.Equal(expected, actualAndRels)
+}
+
+func TestReferenceRangeInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	input := "ast ≥ lln and ≤ 1.5 uln"
+	expected := relation.Relations{
+		relation.Parse(`{"id":"411","name":"ast","lower":{"incl":true,"value":"1","reference":"lln"},"upper":{"incl":true,"value":"1.5","reference":"uln"},"variableType":"numerical"}`),
+	}
+	actualOrRels, actualAndRels := interpreter.Interpret(input)
+	actualAndRels.Process()
+	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -340,12 +396,13 @@ func TestCompositeVariableWithSpaceInterpreter(t *testing.T) {
 
 	input := "aspartate aminotransferase (ast)/alanine aminotransferase (alt) ≤ 2.0 x upper limits of normal"
 	expected := relation.Relations{
-		relation.Parse(`{"id":"411","name":"ast","unit":"uln","upper":{"incl":true,"value":"2.0"},"variableType":"numerical"}`),
-		relation.Parse(`{"id":"412","name":"alt","unit":"uln","upper":{"incl":true,"value":"2.0"},"variableType":"numerical"}`),
+		relation.Parse(`{"id":"411","name":"ast","upper":{"incl":true,"value":"2.0","reference":"uln"},"variableType":"numerical"}`),
+		relation.Parse(`{"id":"412","name":"alt","upper":{"incl":true,"value":"2.0","reference":"uln"},"variableType":"numerical"}`),
 	}
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -356,13 +413,14 @@ func TestCompositeVariableWithoutSpaceInterpreter(t *testing.T) {
 
 	input := "sgot/sgpt ≤ 3 x laboratory normal or ≤ 5 x laboratory normal if something else"
 	expected := relation.Relations{
-		relation.Parse(`{"id":"411","name":"ast","unit":"uln","upper":{"incl":true,"value":"3"},"variableType":"numerical"}`),
-		relation.Parse(`{"id":"412","name":"alt","unit":"uln","upper":{"incl":true,"value":"3"},"variableType":"numerical"}`),
+		relation.Parse(`{"id":"411","name":"ast","upper":{"incl":true,"value":"3","reference":"uln"},"variableType":"numerical"}`),
+		relation.Parse(`{"id":"412","name":"alt","upper":{"incl":true,"value":"3","reference":"uln"},"variableType":"numerical"}`),
 	}
 
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -373,12 +431,13 @@ func TestCompositeVariableWithOrInterpreter(t *testing.T) {
 
 	input := "aspartate aminotransferase (ast) or alanine aminotransferase (alt) ≤ 2.0 x upper limits of normal"
 	expected := relation.Relations{
-		relation.Parse(`{"id":"411","name":"ast","unit":"uln","upper":{"incl":true,"value":"2.0"},"variableType":"numerical"}`),
-		relation.Parse(`{"id":"412","name":"alt","unit":"uln","upper":{"incl":true,"value":"2.0"},"variableType":"numerical"}`),
+		relation.Parse(`{"id":"411","name":"ast","upper":{"incl":true,"value":"2.0","reference":"uln"},"variableType":"numerical"}`),
+		relation.Parse(`{"id":"412","name":"alt","upper":{"incl":true,"value":"2.0","reference":"uln"},"variableType":"numerical"}`),
 	}
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -395,6 +454,7 @@ func TestRatioInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -410,6 +470,7 @@ func TestVariableWithMultipleNumbersInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -425,6 +486,7 @@ func TestVariableNamesWithSharedWordsShortInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -440,6 +502,7 @@ func TestVariableNamesWithSharedWordsLongInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -456,6 +519,7 @@ func TestTwoOrVariablesInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualOrRels.Process()
 	actualOrRels.SetScore(0)
+	actualOrRels.ClearSpans()
 
 	a.Empty(actualAndRels)
 	a.Equal(expected, actualOrRels)
@@ -471,6 +535,7 @@ func TestMultiNumberlVariableInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -486,6 +551,7 @@ func TestComparisonInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -501,6 +567,7 @@ func TestToRangeInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -516,6 +583,7 @@ func TestUnknownVariableInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualOrRels.Process()
 	actualOrRels.SetScore(0)
+	actualOrRels.ClearSpans()
 
 	a.Empty(actualAndRels)
 	a.Equal(expected, actualOrRels)
@@ -531,6 +599,7 @@ func TestColonInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -547,6 +616,7 @@ func TestTwoSameVariablesInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualOrRels.Process()
 	actualOrRels.SetScore(0)
+	actualOrRels.ClearSpans()
 
 	a.Empty(actualAndRels)
 	a.Equal(expected, actualOrRels)
@@ -562,6 +632,7 @@ func TestRomanNumeralsInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -577,6 +648,7 @@ func TestSimpleCholesterolInterpreter(t *testing.T) {
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -593,6 +665,7 @@ func TestNumberInterpreter(t *testing.T) {
 	actualAndRels.Process()
 	actualAndRels.Transform()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
@@ -603,12 +676,139 @@ func TestNonCompositeVariableWithSlashInterpreter(t *testing.T) {
 
 	input := "pao2 /fio2 < 200."
 	expected := relation.Relations{
-		relation.Parse(`{"id":"904","name":"pf_ratio","unit":"mmhg","upper":{"incl":false,"value":"200"},"variableType":"numerical"}`),
+		relation.Parse(`{"id":"904","name":"pf_ratio","unit":"mmhg","unitInferred":true,"upper":{"incl":false,"value":"200"},"variableType":"numerical"}`),
 	}
 	actualOrRels, actualAndRels := interpreter.Interpret(input)
 	actualAndRels.Process()
 	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
 
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
 }
+
+func TestSpanInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	input := "uncontrolled hypertension, defined as blood pressure (bp) between 200 and 300 mm/hg."
+	_, actualAndRels := interpreter.Interpret(input)
+	actualAndRels.Process()
+
+	a.Len(actualAndRels, 2)
+	for _, r := range actualAndRels {
+		a.Equal("blood pressure (bp) between 200 and 300", input[r.Span.Begin:r.Span.End])
+	}
+}
+
+func TestInferredVariableSpanInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	input := "patients less than 31 pounds"
+	_, actualAndRels := interpreter.Interpret(input)
+
+	a.Len(actualAndRels, 1)
+	r := actualAndRels[0]
+	a.Equal("less than 31 pounds", input[r.Span.Begin:r.Span.End])
+}
+
+func TestTemporalInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	input := "a1c > 7% within the past 3 months"
+	expected := relation.Relations{
+		relation.Parse(`{"id":"400","name":"a1c","unit":"%","lower":{"incl":false,"value":"7"},"temporal":{"unit":"month","upper":{"incl":true,"value":"3"},"direction":"before"},"variableType":"numerical"}`),
+	}
+	actualOrRels, actualAndRels := interpreter.Interpret(input)
+	actualAndRels.Process()
+	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
+
+	a.Empty(actualOrRels)
+	a.Equal(expected, actualAndRels)
+}
+
+func TestAnchoredTemporalInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	input := "bmi ≥ 30 kg/m2 at least 4 weeks before the first dose"
+	expected := relation.Relations{
+		relation.Parse(`{"id":"203","name":"bmi","unit":"kg/m2","lower":{"incl":true,"value":"30"},"temporal":{"unit":"week","lower":{"incl":true,"value":"4"},"direction":"before","anchor":"first_dose"},"variableType":"numerical"}`),
+	}
+	actualOrRels, actualAndRels := interpreter.Interpret(input)
+	actualAndRels.Process()
+	actualAndRels.SetScore(0)
+	actualAndRels.ClearSpans()
+
+	a.Empty(actualOrRels)
+	a.Equal(expected, actualAndRels)
+}
+
+func TestTemporals(t *testing.T) {
+	a := assert.New(t)
+
+	tests := map[string]string{
+		"myocardial infarction within 6 months prior to screening": `[{"unit":"month","upper":{"incl":true,"value":"6"},"direction":"before","anchor":"screening"}]`,
+		"chemotherapy within 4 weeks after randomisation":          `[{"unit":"week","upper":{"incl":true,"value":"4"},"direction":"after","anchor":"randomization"}]`,
+		"major surgery 28 days prior to enrollment":                `[{"unit":"day","upper":{"incl":true,"value":"28"},"direction":"before","anchor":"enrollment"}]`,
+		"investigational drug within 30 days of the first dose":    `[{"unit":"day","upper":{"incl":true,"value":"30"},"anchor":"first_dose"}]`,
+		"investigational drug within 30 days of the last dose":     `[{"unit":"day","upper":{"incl":true,"value":"30"},"anchor":"last_dose"}]`,
+		"chemotherapy within 28 days before the final dose":        `[{"unit":"day","upper":{"incl":true,"value":"28"},"direction":"before","anchor":"last_dose"}]`,
+		"stroke more than 6 months ago":                            `[{"unit":"month","lower":{"incl":false,"value":"6"},"direction":"before"}]`,
+		"prior chemotherapy is allowed":                            `null`,
+		"life expectancy > 3 months":                               `null`,
+		"age > 18 years before randomization":                      `null`,
+	}
+	for input, expected := range tests {
+		b, err := json.Marshal(interpreter.Temporals(input))
+		a.NoError(err)
+		a.Equal(expected, string(b), input)
+	}
+}
+
+func TestComparisonWithoutTimeWindow(t *testing.T) {
+	a := assert.New(t)
+
+	catalog, err := variables.Load("../../resources/variables/variables.csv")
+	a.NoError(err)
+	defer variables.Set(variables.Get())
+	variables.Set(catalog)
+
+	tests := map[string]string{
+		"age >= 18 years at the time of screening":              `[{"id":"200","name":"age","unit":"year","lower":{"incl":true,"value":"18"},"variableType":"numerical","score":0}]`,
+		"age > 18 years before randomization":                   `[{"id":"200","name":"age","unit":"year","lower":{"incl":false,"value":"18"},"variableType":"numerical","score":0}]`,
+		"life expectancy of at least 3 months after enrollment": `[{"id":"206","name":"life_expectancy","unit":"month","lower":{"incl":true,"value":"3"},"variableType":"numerical","score":0}]`,
+		"lung infiltrates > 50% within 24 to 48 hours":          `[{"id":"911","name":"pulmonary_infiltrate_level","unit":"%","lower":{"incl":false,"value":"50"},"variableType":"numerical","score":0}]`,
+	}
+	for input, expected := range tests {
+		_, actualAndRels := interpreter.Interpret(input)
+		actualAndRels.Process()
+		actualAndRels.SetScore(0)
+		actualAndRels.ClearSpans()
+		a.Equal(expected, actualAndRels.JSON(), input)
+	}
+}
+
+func TestInferredUnitInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	catalog, err := variables.Load("../../resources/variables/variables.csv")
+	a.NoError(err)
+	defer variables.Set(variables.Get())
+	variables.Set(catalog)
+
+	tests := map[string]string{
+		"platelet count ≥ 100x109 PG L":          `[{"id":"405","name":"platelet_count","lower":{"incl":true,"value":"100e9"},"variableType":"numerical","score":1}]`,
+		"Serum creatinine ≤ 1.25xULN":            `[{"id":"415","name":"creatinine_level","upper":{"incl":true,"value":"1.25","reference":"uln"},"variableType":"numerical","score":1}]`,
+		"total bilirubin<1.5 normal limit (ULN)": `[{"id":"407","name":"total_bilirubin_level","unit":"mg/dl","unitInferred":true,"upper":{"incl":false,"value":"1.5"},"variableType":"numerical","score":1}]`,
+		"temperature > 100.4":                    `[{"id":"207","name":"body_temperature","lower":{"incl":false,"value":"100.4"},"variableType":"numerical","score":0}]`,
+		"creatinine < 1.5 mg/dl":                 `[{"id":"415","name":"creatinine_level","unit":"mg/dl","upper":{"incl":false,"value":"1.5"},"variableType":"numerical","score":1}]`,
+	}
+	for input, expected := range tests {
+		_, actualAndRels := interpreter.Interpret(text.ToLowerSameWidth(input))
+		actualAndRels.SetScore(1)
+		actualAndRels.Process()
+		actualAndRels.Transform()
+		actualAndRels.ClearSpans()
+		a.Equal(expected, actualAndRels.JSON(), input)
+	}
+}
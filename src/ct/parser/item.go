@@ -24,6 +24,8 @@ const (
 	itemRange
 	itemNumber
 	itemUnit
+	itemTemporal
+	itemAnchor
 )
 
 // ItemType converts a string to itemType.
@@ -47,6 +49,10 @@ func ItemType(s string) itemType {
 		return itemNumber
 	case "unit":
 		return itemUnit
+	case "temporal":
+		return itemTemporal
+	case "anchor":
+		return itemAnchor
 	default:
 		return itemUnknown
 	}
@@ -73,6 +79,10 @@ func (t itemType) String() string {
 		return "number"
 	case itemUnit:
 		return "unit"
+	case itemTemporal:
+		return "temporal"
+	case itemAnchor:
+		return "anchor"
 	default:
 		return "unknown"
 	}
@@ -80,8 +90,10 @@ func (t itemType) String() string {
 
 // Item defines the lexical item that is syntactically constructed from the lexer token.
 type Item struct {
-	typ itemType
-	val string
+	typ   itemType
+	val   string
+	begin Pos // The starting position, in bytes, of the item in the input string.
+	end   Pos // The ending position (exclusive), in bytes, of the item in the input string.
 }
 
 // NewItem creates a new item.
@@ -101,13 +113,27 @@ func (i *Item) Set(typ itemType, val string) *Item {
 	return i
 }
 
+// SetSpan sets the byte offsets of the input string from which the item is parsed.
+func (i *Item) SetSpan(begin, end Pos) *Item {
+	i.begin = begin
+	i.end = end
+	return i
+}
+
+// Span returns the byte offsets of the input string from which the item is parsed.
+func (i *Item) Span() (Pos, Pos) {
+	return i.begin, i.end
+}
+
 // Copy copies the fields from the other item.
 func (i *Item) Copy(j *Item) {
 	i.typ = j.typ
 	i.val = j.val
+	i.begin = j.begin
+	i.end = j.end
 }
 
-// Equal tests whether two items have the same fields.
+// Equal tests whether two items have the same type and value.
 func (i *Item) Equal(j *Item) bool {
 	return i.typ == j.typ && i.val == j.val
 }
@@ -169,6 +195,43 @@ func (is Items) LastType() itemType {
 	return is[is.Len()-1].typ
 }
 
+// LastKnownType returns the type of the last item that is not unknown.
+func (is Items) LastKnownType() itemType {
+	if i := is.LastKnown(); i != nil {
+		return i.typ
+	}
+	return itemUnknown
+}
+
+// LastKnown returns the last item that is not unknown or nil if there is no such item.
+func (is Items) LastKnown() *Item {
+	for i := is.Len() - 1; i >= 0; i-- {
+		if is[i].typ != itemUnknown {
+			return is[i]
+		}
+	}
+	return nil
+}
+
+// variableBefore returns true if the last item that ends at or before the position pos is a variable.
+func (is Items) variableBefore(pos Pos) bool {
+	for i := is.Len() - 1; i >= 0; i-- {
+		if is[i].end <= pos {
+			return is[i].typ == itemVariable
+		}
+	}
+	return false
+}
+
+// TrimUnknown removes the trailing unknown items.
+func (is *Items) TrimUnknown() {
+	a := *is
+	for a.LastType() == itemUnknown && !a.Empty() {
+		a = a[:a.Len()-1]
+	}
+	*is = a
+}
+
 // Get gets the items of type 'typ'.
 func (is Items) Get(typ itemType) set.Set {
 	set := set.New()
@@ -191,14 +254,22 @@ func (is *Items) FixMissingVariable() bool {
 	}
 	unitCatalog := units.Get()
 	candidates := set.New()
-	for u := range is.Get(itemUnit) {
-		if v, ok := unitCatalog.Variable(u); ok {
+	var unit *Item
+	for _, i := range *is {
+		if i.typ != itemUnit {
+			continue
+		}
+		if v, ok := unitCatalog.Variable(i.val); ok {
 			candidates.Add(v)
+			if unit == nil {
+				unit = i
+			}
 		}
 	}
 	if candidates.Size() == 1 {
 		v, _ := candidates.Get()
-		i := NewItem(itemVariable, v)
+		// The variable is inferred from the unit, so it points to the unit text.
+		i := NewItem(itemVariable, v).SetSpan(unit.Span())
 		*is = append(Items{i}, *is...)
 		return true
 	}
@@ -252,6 +323,7 @@ func (is *Items) TrimKnownItems() {
 	j := 0
 	for i := 1; i < len(a); i++ {
 		if (a[j].typ == itemVariable || a[j].typ == itemUnit || a[j].typ == itemComparison) && a[i].Equal(a[j]) {
+			a[j].end = a[i].end
 			continue
 		}
 		j++
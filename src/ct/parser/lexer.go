@@ -41,6 +41,45 @@ var key = map[string]tokenType{
 	"at":      tokenComparison,
 	"least":   tokenComparison,
 	"than":    tokenComparison,
+
+	"within":    tokenTemporal,
+	"past":      tokenTemporal,
+	"last":      tokenTemporal,
+	"previous":  tokenTemporal,
+	"preceding": tokenTemporal,
+	"prior":     tokenTemporal,
+	"before":    tokenTemporal,
+	"after":     tokenTemporal,
+	"following": tokenTemporal,
+	"ago":       tokenTemporal,
+	"since":     tokenTemporal,
+
+	"screening":     tokenAnchor,
+	"baseline":      tokenAnchor,
+	"enrollment":    tokenAnchor,
+	"enrolment":     tokenAnchor,
+	"entry":         tokenAnchor,
+	"registration":  tokenAnchor,
+	"randomization": tokenAnchor,
+	"randomisation": tokenAnchor,
+	"consent":       tokenAnchor,
+	"dose":          tokenAnchor,
+	"dosing":        tokenAnchor,
+	"drug":          tokenAnchor,
+	"treatment":     tokenAnchor,
+	"diagnosis":     tokenAnchor,
+}
+
+// references are the reference limits that can follow a multiplier without a space, such as 'xuln' in '1.25xuln'.
+var references = map[string]bool{
+	"uln": true,
+	"lln": true,
+}
+
+// multipliers are the words that multiply a reference limit, such as 'x' in '2.5 x uln'.
+var multipliers = map[string]bool{
+	"x":     true,
+	"times": true,
 }
 
 // stateFn represents the state of the lexer as a function that returns the next state.
@@ -150,6 +189,27 @@ func (l *Lexer) acceptRun(valid string) {
 	l.backup()
 }
 
+// referenceAhead returns true if a reference limit follows the current position, such as 'uln' in
+// '2.5 x uln' or 'upper limit of normal' in '2.5 times the upper limit of normal'. In other contexts,
+// such as 'chest x ray' or '2 times daily', a multiplier is a word.
+func (l *Lexer) referenceAhead() bool {
+	words := strings.Fields(l.input[l.pos:])
+	for len(words) > 0 && (words[0] == "the" || words[0] == "institutional") {
+		words = words[1:]
+	}
+	switch {
+	case len(words) == 0:
+		return false
+	case references[strings.TrimFunc(words[0], isPunctuationChar)]:
+		return true
+	case len(words) > 1 && (words[0] == "upper" || words[0] == "lower"):
+		return strings.HasPrefix(words[1], "limit")
+	case len(words) > 1 && words[0] == "normal":
+		return words[1] == "upper" || words[1] == "lower"
+	}
+	return false
+}
+
 // errorf returns an error token and terminates the scan by passing
 // back a nil pointer that will be the next state, terminating l.nexttoken.
 func (l *Lexer) errorf(format string, args ...interface{}) stateFn {
@@ -186,6 +246,8 @@ func lexAction(l *Lexer) stateFn {
 		return lexNumber
 	case r == '/':
 		l.emit(tokenSlash)
+	case r == '×' && l.referenceAhead():
+		l.emit(tokenMultiplier)
 	case r == '(':
 		l.emit(tokenLeftParenthesis)
 		l.parenDepth++
@@ -229,6 +291,14 @@ Loop:
 			l.backup()
 			word := l.input[l.start:l.pos]
 			switch {
+			case len(word) > 1 && word[0] == 'x' && references[word[1:]]:
+				end := l.pos
+				l.pos = l.start + 1
+				l.emit(tokenMultiplier)
+				l.pos = end
+				l.emit(tokenIdentifier)
+			case multipliers[word] && l.referenceAhead():
+				l.emit(tokenMultiplier)
 			case key[word] > tokenKeyword:
 				l.emit(key[word])
 			case text.IsRomanNumeral(word):
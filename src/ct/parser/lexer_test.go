@@ -113,7 +113,7 @@ func TestASTLexer(t *testing.T) {
 		NewToken(tokenRightParenthesis, 62, ")"),
 		NewToken(tokenComparison, 64, "≤"),
 		NewToken(tokenNumber, 68, "2.0"),
-		NewToken(tokenIdentifier, 72, "x"),
+		NewToken(tokenMultiplier, 72, "x"),
 		NewToken(tokenIdentifier, 74, "upper"),
 		NewToken(tokenIdentifier, 80, "limits"),
 		NewToken(tokenIdentifier, 87, "of"),
@@ -123,6 +123,88 @@ func TestASTLexer(t *testing.T) {
 	a.Equal(expected, actual)
 }
 
+func TestMultiplierLexer(t *testing.T) {
+	a := assert.New(t)
+
+	input := "alt ≤ 1.5 × uln or 3 times uln"
+	expected := Tokens{
+		NewToken(tokenIdentifier, 0, "alt"),
+		NewToken(tokenComparison, 4, "≤"),
+		NewToken(tokenNumber, 8, "1.5"),
+		NewToken(tokenMultiplier, 12, "×"),
+		NewToken(tokenIdentifier, 15, "uln"),
+		NewToken(tokenConjunction, 19, "or"),
+		NewToken(tokenNumber, 22, "3"),
+		NewToken(tokenMultiplier, 24, "times"),
+		NewToken(tokenIdentifier, 30, "uln"),
+	}
+	actual := NewLexer(input).Drain()
+	a.Equal(expected, actual)
+}
+
+func TestCompactMultiplierLexer(t *testing.T) {
+	a := assert.New(t)
+
+	input := "creatinine ≤ 1.25xuln, ast < 2.5×uln and xylose"
+	expected := Tokens{
+		NewToken(tokenIdentifier, 0, "creatinine"),
+		NewToken(tokenComparison, 11, "≤"),
+		NewToken(tokenNumber, 15, "1.25"),
+		NewToken(tokenMultiplier, 19, "x"),
+		NewToken(tokenIdentifier, 20, "uln"),
+		NewToken(tokenChar, 23, ","),
+		NewToken(tokenIdentifier, 25, "ast"),
+		NewToken(tokenComparison, 29, "<"),
+		NewToken(tokenNumber, 31, "2.5"),
+		NewToken(tokenMultiplier, 34, "×"),
+		NewToken(tokenIdentifier, 36, "uln"),
+		NewToken(tokenConjunction, 40, "and"),
+		NewToken(tokenIdentifier, 44, "xylose"),
+	}
+	actual := NewLexer(input).Drain()
+	a.Equal(expected, actual)
+}
+
+func TestMultiplierWordLexer(t *testing.T) {
+	a := assert.New(t)
+
+	input := "chest x ray 2 times daily or 3 times the upper limit of normal"
+	expected := Tokens{
+		NewToken(tokenIdentifier, 0, "chest"),
+		NewToken(tokenIdentifier, 6, "x"),
+		NewToken(tokenIdentifier, 8, "ray"),
+		NewToken(tokenNumber, 12, "2"),
+		NewToken(tokenIdentifier, 14, "times"),
+		NewToken(tokenIdentifier, 20, "daily"),
+		NewToken(tokenConjunction, 26, "or"),
+		NewToken(tokenNumber, 29, "3"),
+		NewToken(tokenMultiplier, 31, "times"),
+		NewToken(tokenIdentifier, 37, "the"),
+		NewToken(tokenIdentifier, 41, "upper"),
+		NewToken(tokenIdentifier, 47, "limit"),
+		NewToken(tokenIdentifier, 53, "of"),
+		NewToken(tokenIdentifier, 56, "normal"),
+	}
+	actual := NewLexer(input).Drain()
+	a.Equal(expected, actual)
+}
+
+func TestTemporalLexer(t *testing.T) {
+	a := assert.New(t)
+
+	input := "within 4 weeks prior to randomization"
+	expected := Tokens{
+		NewToken(tokenTemporal, 0, "within"),
+		NewToken(tokenNumber, 7, "4"),
+		NewToken(tokenIdentifier, 9, "weeks"),
+		NewToken(tokenTemporal, 15, "prior"),
+		NewToken(tokenIdentifier, 21, "to"),
+		NewToken(tokenAnchor, 24, "randomization"),
+	}
+	actual := NewLexer(input).Drain()
+	a.Equal(expected, actual)
+}
+
 func TestWBCLexer(t *testing.T) {
 	a := assert.New(t)
 
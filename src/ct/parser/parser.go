@@ -5,6 +5,7 @@ package parser
 import (
 	"strings"
 
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
 
@@ -15,6 +16,8 @@ import (
 type Parser struct {
 	lexer  *Lexer
 	tokens []*Token // lookahead for parser.
+	end    Pos      // end position of the last consumed token.
+	last   *Token   // last consumed token.
 }
 
 // NewParser creates a new parser.
@@ -32,6 +35,8 @@ func (p *Parser) Parse(input string) (criteria List) {
 	}()
 	p.lexer = NewLexer(input)
 	p.tokens = make([]*Token, 0)
+	p.end = 0
+	p.last = nil
 	criteria = p.parseSegment(tokenEOF)
 	criteria.TrimItems()
 	return
@@ -39,12 +44,16 @@ func (p *Parser) Parse(input string) (criteria List) {
 
 // next returns the next token.
 func (p *Parser) next() *Token {
+	var t *Token
 	if len(p.tokens) > 0 {
-		t := p.tokens[0]
+		t = p.tokens[0]
 		p.tokens = p.tokens[1:]
-		return t
+	} else {
+		t = p.lexer.NextToken()
 	}
-	return p.lexer.NextToken()
+	p.end = t.End()
+	p.last = t
+	return t
 }
 
 // peek returns but does not consume the next token.
@@ -63,8 +72,14 @@ func (p *Parser) parseSegment(tokenEnd tokenType) List {
 	list := make(List, 0)
 	nodes := NewItems()
 
+	// add adds the item with the span from begin to the end of the last consumed token.
+	add := func(n *Item, begin Pos) {
+		nodes.Add(n.SetSpan(begin, p.end))
+	}
+
 loop:
 	for {
+		begin := p.peek(1).pos
 		switch p.peek(1).typ {
 		case tokenLeftParenthesis:
 			p.next()
@@ -80,48 +95,78 @@ loop:
 				}
 			}
 		case tokenIdentifier:
+			if p.peek(1).val == "of" && isTimeUnit(nodes.LastKnown()) && p.anchorAhead() {
+				// The anchor of a window, such as 'within 28 days of randomization', has no direction.
+				p.next()
+				add(NewItem(itemTemporal, "of"), begin)
+				continue
+			}
 			n := p.parseIdentifier()
-			nodes.Add(n)
+			if n.typ == itemUnit && relation.ParseReference(n.val) != relation.NoReference && nodes.LastKnownType() != itemNumber {
+				// A reference limit without a multiplier, such as '≤ ULN', is one times the limit.
+				// Unknown items, such as 'the' in '≤ the ULN', would separate the multiplier from the comparison.
+				nodes.TrimUnknown()
+				add(NewItem(itemNumber, "1"), begin)
+			}
+			add(n, begin)
+		case tokenTemporal:
+			n := p.parseTemporal(nodes)
+			if n.typ == itemTemporal && n.val == "within" {
+				// Unknown items, such as 'in the' in 'in the past 6 months', precede the window.
+				nodes.TrimUnknown()
+			}
+			add(n, begin)
+		case tokenAnchor:
+			if nodes.LastKnownType() == itemTemporal {
+				// Unknown items, such as 'the first' in 'before the first dose', precede the anchor.
+				nodes.TrimUnknown()
+				add(p.parseAnchor(), begin)
+			} else {
+				add(p.parseIdentifier(), begin)
+			}
 		case tokenNumber:
 			if n := p.parseNumber(); n.Valid() {
-				nodes.Add(n)
+				add(n, begin)
 			}
 		case tokenUnit:
 			if n := p.parseUnit(); n.Valid() {
-				nodes.Add(n)
+				add(n, begin)
 			}
 		case tokenNegation, tokenComparison, tokenLessComparison, tokenGreaterComparison:
 			if n := p.parseComparison(); n.Valid() {
-				nodes.Add(n)
+				add(n, begin)
 			}
 		case tokenConjunction:
 			if n := p.parseConjunction(); n.Valid() {
-				nodes.Add(n)
+				add(n, begin)
 			}
 		case tokenSlash:
 			if nodes.LastType() == itemNumber {
 				// Because a number preceded the slash, these tokens
 				// may compose to a unit, such as '/ul'.
 				n := p.parseIdentifier()
-				nodes.Add(n)
+				add(n, begin)
 			} else {
 				if n := p.parseSlash(); n.Valid() {
-					nodes.Add(n)
+					add(n, begin)
 				}
 			}
 		case tokenDash:
 			if n := p.parseDash(); n.Valid() {
-				nodes.Add(n)
+				add(n, begin)
 			}
 		case tokenPunctuation:
 			if n := p.parsePunctuation(); n.Valid() {
-				nodes.Add(n)
+				add(n, begin)
 			}
 		case tokenEOF:
 			break loop
 		case tokenEnd:
 			p.next()
 			break loop
+		case tokenMultiplier:
+			// The multiplier of a reference limit, such as 'x' in '2.5 x uln', is implied by the number before it.
+			p.next()
 		default:
 			p.next()
 		}
@@ -210,7 +255,8 @@ loop:
 			}
 		}
 		candidate += " " + t.val
-		isIdentifier = t.typ == tokenIdentifier || t.typ == tokenConjunction || t.typ == tokenSlash
+		isIdentifier = t.typ == tokenIdentifier || t.typ == tokenConjunction || t.typ == tokenSlash ||
+			t.typ == tokenTemporal || t.typ == tokenAnchor
 	}
 
 	switch {
@@ -231,6 +277,84 @@ loop:
 	return n
 }
 
+// parseTemporal parses the temporal keyword. A direction, such as 'prior to', follows the duration
+// of a time window, e.g., '4 weeks prior to', and a window, such as 'past', precedes it, e.g., 'past 6 months'.
+// In other contexts, such as 'prior chemotherapy', the keyword is parsed as an identifier.
+func (p *Parser) parseTemporal(nodes Items) *Item {
+	t := p.peek(1)
+	if relation.IsLastWord(t.val) && p.peek(2).typ == tokenAnchor {
+		// The word qualifies the anchor, such as 'last' in '4 weeks since the last dose'.
+		return p.parseIdentifier()
+	}
+	if isTimeUnit(nodes.LastKnown()) {
+		if d := relation.ParseDirection(t.val); d != relation.NoDirection && t.val != "within" {
+			p.next()
+			if p.peek(1).val == "to" {
+				p.next()
+			}
+			return NewItem(itemTemporal, string(d))
+		}
+	}
+	switch t.val {
+	case "within":
+		p.next()
+		for isWindowWord(p.peek(1).val) {
+			p.next()
+		}
+		return NewItem(itemTemporal, "within")
+	case "past", "last", "previous", "preceding":
+		if p.peek(2).typ == tokenNumber {
+			p.next()
+			return NewItem(itemTemporal, "within")
+		}
+	}
+	return p.parseIdentifier()
+}
+
+// anchorAhead returns true if an anchor event follows the next token within a few identifiers,
+// such as 'randomization' in 'of the first randomization' or 'dose' in 'of the last dose'.
+func (p *Parser) anchorAhead() bool {
+	for k := 2; k <= 4; k++ {
+		switch t := p.peek(k); {
+		case t.typ == tokenAnchor:
+			return true
+		case t.typ == tokenIdentifier || relation.IsLastWord(t.val):
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// isTimeUnit returns true if the item is a time unit, such as 'week'.
+func isTimeUnit(i *Item) bool {
+	return i != nil && i.typ == itemUnit && relation.IsTimeUnit(i.val)
+}
+
+// isWindowWord returns true if the word may follow 'within', such as 'the' in 'within the past 6 months'.
+func isWindowWord(s string) bool {
+	switch s {
+	case "the", "past", "last", "previous", "preceding":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseAnchor parses the anchor event of a time window, such as 'randomization'. The token
+// before the anchor may qualify it, such as 'last' in 'since the last dose'.
+func (p *Parser) parseAnchor() *Item {
+	var word string
+	if p.last != nil {
+		word = p.last.val
+	}
+	t := p.next()
+	if a := relation.ParseLastAnchor(word, t.val); a != relation.NoAnchor {
+		return NewItem(itemAnchor, string(a))
+	}
+	return UnknownItem()
+}
+
 func (p *Parser) parseComparison() *Item {
 	n := UnknownItem()
 	t := p.next()
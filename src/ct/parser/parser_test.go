@@ -14,6 +14,16 @@ func init() {
 	parser = NewParser()
 }
 
+// withoutSpans clears the item spans, which are tested separately.
+func withoutSpans(l List) List {
+	for _, items := range l {
+		for _, i := range items {
+			i.SetSpan(0, 0)
+		}
+	}
+	return l
+}
+
 func TestOneNumericalVariableParser(t *testing.T) {
 	a := assert.New(t)
 
@@ -27,7 +37,7 @@ func TestOneNumericalVariableParser(t *testing.T) {
 			NewItem(itemPunctuation, "."),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -46,7 +56,7 @@ func TestToRangeParser(t *testing.T) {
 			NewItem(itemUnit, "%"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -62,7 +72,7 @@ func TestOneCategoricalVariableParser(t *testing.T) {
 			NewItem(itemNumber, "3"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 
 	input = "eastern cooperative oncology group (ecog) performance status of 0, 1, or 2."
@@ -76,7 +86,7 @@ func TestOneCategoricalVariableParser(t *testing.T) {
 			NewItem(itemPunctuation, "."),
 		},
 	}
-	actual = parser.Parse(input)
+	actual = withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -93,7 +103,7 @@ func TestRomanNumeralsParser(t *testing.T) {
 			NewItem(itemPunctuation, "."),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -109,7 +119,7 @@ func TestReverseOrderParser(t *testing.T) {
 			NewItem(itemVariable, "weight"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -125,7 +135,7 @@ func TestMisspelledVariableNameParser(t *testing.T) {
 			NewItem(itemUnit, "lb"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -140,7 +150,7 @@ func TestMissingVariableNameParser(t *testing.T) {
 			NewItem(itemUnit, "lb"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -161,7 +171,7 @@ func TestTwoVariablesParser(t *testing.T) {
 			NewItem(itemUnit, "%"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -182,7 +192,7 @@ func TestTwoVariablesWithMoreStuffParser(t *testing.T) {
 			NewItem(itemUnit, "kg/m2"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -204,7 +214,7 @@ func TestRangeWithToParser(t *testing.T) {
 			NewItem(itemUnit, "kg"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -225,7 +235,7 @@ func TestParenthesisParser(t *testing.T) {
 			NewItem(itemNumber, "2"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -245,7 +255,7 @@ func TestTwoVariablesInParenthesisParser(t *testing.T) {
 			NewItem(itemUnit, "mmhg"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -260,7 +270,7 @@ func TestOneVariableWithTwoValuesParser(t *testing.T) {
 			NewItem(itemNumber, "140/90"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -278,7 +288,40 @@ func TestRatioVariableParser(t *testing.T) {
 			NewItem(itemUnit, "uln"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
+	a.Equal(expected, actual)
+}
+
+func TestBareReferenceParser(t *testing.T) {
+	a := assert.New(t)
+
+	input := "ast ≤ the upper limit of normal"
+	expected := List{
+		Items{
+			NewItem(itemVariable, "ast"),
+			NewItem(itemComparison, "≤"),
+			NewItem(itemNumber, "1"),
+			NewItem(itemUnit, "uln"),
+		},
+	}
+	actual := withoutSpans(parser.Parse(input))
+	a.Equal(expected, actual)
+}
+
+func TestMultiplierWordParser(t *testing.T) {
+	a := assert.New(t)
+
+	input := "bmi > 30 x 2"
+	expected := List{
+		Items{
+			NewItem(itemVariable, "bmi"),
+			NewItem(itemComparison, ">"),
+			NewItem(itemNumber, "30"),
+			UnknownItem(),
+			NewItem(itemNumber, "2"),
+		},
+	}
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -294,7 +337,7 @@ func TestAtLeastParser(t *testing.T) {
 			NewItem(itemUnit, "year"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
 	a.Equal(expected, actual)
 }
 
@@ -310,6 +353,21 @@ func TestCountParser(t *testing.T) {
 			NewItem(itemUnit, "cells/ul"),
 		},
 	}
-	actual := parser.Parse(input)
+	actual := withoutSpans(parser.Parse(input))
+	a.Equal(expected, actual)
+}
+
+func TestSpanParser(t *testing.T) {
+	a := assert.New(t)
+
+	input := "a1c greater than or equal to 5.0%."
+	expected := []string{"a1c", "greater than or equal to", "5.0", "%", "."}
+	actual := []string{}
+	for _, items := range parser.Parse(input) {
+		for _, i := range items {
+			begin, end := i.Span()
+			actual = append(actual, input[begin:end])
+		}
+	}
 	a.Equal(expected, actual)
 }
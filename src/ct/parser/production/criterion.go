@@ -3,33 +3,38 @@
 package production
 
 // CriterionRules defines the context-free grammar production rules
-// for parsing a clinical-trial eligibility criterion.
+// for parsing a clinical-trial eligibility criterion. The rule weights
+// are hand-set priors, seeded from the small treebank in data/treebank.
 var CriterionRules = `
 
 #nonterminals:
 
-S -> C
-C -> C X | R
-X -> O R | R
-R -> V A | A V | V
-V -> V1 V2 | V1
-V2 -> H V1
-A -> L Y | Y Y | B W | B B | B | E
-E -> E N | E Z | N
-Z -> O N
-B -> T L | L T
-W -> O B
-L -> N U | N
-Y -> D L
+S -> C [1]
+C -> C X [0.1071] | R [0.8929]
+X -> O R [0.75] | R [0.25]
+R -> V A [0.5135] | A V [0.05405] | V [0.2162] | R P [0.2162]
+V -> V1 V2 [0.07143] | V1 [0.9286]
+V2 -> H V1 [1]
+A -> L Y [0.12] | Y Y [0.04] | B W [0.08] | B B [0.04] | B [0.56] | E [0.16]
+E -> E N [0.1] | E Z [0.5] | N [0.4]
+Z -> O N [1]
+B -> T L [0.8947] | L T [0.1053]
+W -> O B [1]
+L -> N U [0.7143] | N [0.2857]
+Y -> D L [1]
+P -> K L [0.3571] | L J [0.1429] | B J [0.2143] | P J [0.2857]
+J -> K G [0.75] | K [0.25]
 
 #terminals:
 
-O -> or | and | punctuation
-V1 -> variable | unknown
-T -> comparison
-N -> number
-U -> unit
-D -> range | and
-H -> slash
+O -> or [0.5] | and [0.3] | punctuation [0.2]
+V1 -> variable [0.7241] | unknown [0.2759]
+T -> comparison [1]
+N -> number [1]
+U -> unit [1]
+D -> range [0.75] | and [0.25]
+H -> slash [1]
+K -> temporal [1]
+G -> anchor [1]
 
 `
@@ -3,6 +3,10 @@
 package parser
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
@@ -11,12 +15,27 @@ import (
 	"github.com/golang/glog"
 )
 
-// Rules define the grammar production rules.
+// startSymbol is the nonterminal from which the parse trees are derived.
+const startSymbol = "S"
+
+// temporalSymbol is the nonterminal from which the time windows are derived.
+const temporalSymbol = "P"
+
+// reWeight matches the optional weight of a rule alternative, such as 'A -> B C [0.5]'.
+var reWeight = regexp.MustCompile(`\[\s*([^\]]*?)\s*\]\s*$`)
+
+// Probabilities maps the left-hand side nonterminals of production rules to the rule probabilities.
+type Probabilities map[string]float64
+
+// Rules define the grammar production rules and their probabilities. The probabilities
+// of the rules with the same left-hand side nonterminal sum to one.
 type Rules struct {
-	terminalRules  map[itemType]set.Set
-	unaryRules     map[Element]set.Set
-	binaryRules    map[Element]set.Set
+	terminalRules  map[itemType]Probabilities
+	unaryRules     map[Element]Probabilities
+	binaryRules    map[Element]Probabilities
 	nonTerminalSet set.Set
+	unaryOrder     []string          // nonterminals ordered so that B precedes A for unary rules A -> B
+	alternatives   []ruleAlternative // rules in the order of the grammar text
 }
 
 // RuleType defines the type of rules that are being loaded from the string.
@@ -28,17 +47,63 @@ const (
 	nonTerminalRule
 )
 
-// LoadRules loads the grammar production rules from the string.
-func LoadRules(s string) *Rules {
-	terminalRules := map[itemType]set.Set{}
-	unaryRules := map[Element]set.Set{}
-	binaryRules := map[Element]set.Set{}
-	nonTerminalSet := set.New()
+// ruleAlternative defines one alternative of a production rule, its weight, and the line where it is defined.
+type ruleAlternative struct {
+	line    int
+	typ     RuleType
+	lhs     string
+	symbols []string
+	weight  float64 // unnormalized weight, one by default
+	prob    float64 // weight normalized over the rules of the lhs nonterminal
+}
+
+// key returns the string representation of the rule without the weight.
+func (p ruleAlternative) key() string {
+	return p.lhs + " -> " + strings.Join(p.symbols, " ")
+}
+
+// GrammarError defines an error in the grammar production rules.
+type GrammarError struct {
+	Line int // Line number of the rule, or zero if the error concerns the whole grammar
+	Msg  string
+}
+
+// Error returns the error message with the line number.
+func (e *GrammarError) Error() string {
+	if e.Line == 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// GrammarErrors defines a slice of grammar errors.
+type GrammarErrors []*GrammarError
+
+// Error returns the error messages, one per line.
+func (es GrammarErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// add adds a new error at the line.
+func (es *GrammarErrors) add(line int, format string, args ...interface{}) {
+	*es = append(*es, &GrammarError{Line: line, Msg: fmt.Sprintf(format, args...)})
+}
+
+// readProductions reads the production rules from the string. Lines are numbered from one.
+// It returns the syntax errors of malformed lines.
+func readProductions(s string) ([]ruleAlternative, GrammarErrors) {
+	var productions []ruleAlternative
+	var errs GrammarErrors
 
 	ruleType := unknownRule
 
 	lines := strings.Split(s, "\n")
-	for _, line := range lines {
+	for i, line := range lines {
+		n := i + 1
 		line = strings.TrimSpace(line)
 
 		switch {
@@ -48,53 +113,211 @@ func LoadRules(s string) *Rules {
 			ruleType = nonTerminalRule
 		}
 
-		if ruleType == unknownRule || len(line) == 0 || line[0] == '#' {
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if ruleType == unknownRule {
+			errs.add(n, "rule before '#nonterminals' or '#terminals' section: %q", line)
 			continue
 		}
 
 		values := strings.Split(line, "->")
 		if len(values) != 2 {
+			errs.add(n, "cannot read production rule: %q", line)
 			continue
 		}
 		slice.TrimSpace(values)
 		A := values[0]
-		list := strings.Split(values[1], "|")
-		slice.TrimSpace(list)
+		if len(A) == 0 || len(strings.Fields(A)) != 1 {
+			errs.add(n, "left-hand side must be one nonterminal: %q", line)
+			continue
+		}
+		for _, a := range strings.Split(values[1], "|") {
+			weight := 1.0
+			if m := reWeight.FindStringSubmatch(a); m != nil {
+				w, err := strconv.ParseFloat(m[1], 64)
+				if err != nil || w <= 0 {
+					errs.add(n, "weight must be a positive number: %q", line)
+					continue
+				}
+				weight = w
+				a = a[:len(a)-len(m[0])]
+			}
+			symbols := strings.Fields(a)
+			if len(symbols) == 0 {
+				errs.add(n, "empty alternative: %q", line)
+				continue
+			}
+			productions = append(productions, ruleAlternative{line: n, typ: ruleType, lhs: A, symbols: symbols, weight: weight})
+		}
+	}
 
-		nonTerminalSet.Add(A)
+	return productions, errs
+}
 
-		switch ruleType {
+// validateProductions validates that the rules are in the binary normal form assumed
+// by the CYK implementation, terminals are known item types, all nonterminals are defined
+// and reachable from the start symbol, and unary rules have no cycles.
+func validateProductions(productions []ruleAlternative) GrammarErrors {
+	var errs GrammarErrors
+
+	defined := make(map[string]int)
+	for _, p := range productions {
+		if _, ok := defined[p.lhs]; !ok {
+			defined[p.lhs] = p.line
+		}
+	}
+	if _, ok := defined[startSymbol]; !ok {
+		errs.add(0, "start symbol %q is not defined", startSymbol)
+	}
+
+	graph := make(map[string][]string) // nonterminal -> nonterminals in its productions
+	unary := make(map[string][]ruleAlternative)
+	for _, p := range productions {
+		switch p.typ {
 		case terminalRule:
-			for _, s := range list {
-				a := ItemType(s)
-				if _, ok := terminalRules[a]; !ok {
-					terminalRules[a] = set.New()
+			if len(p.symbols) != 1 {
+				errs.add(p.line, "terminal rule %s -> %s must have one terminal", p.lhs, strings.Join(p.symbols, " "))
+				continue
+			}
+			if a := p.symbols[0]; ItemType(a) == itemUnknown && a != itemUnknown.String() {
+				errs.add(p.line, "unknown terminal: %q", a)
+			}
+		case nonTerminalRule:
+			if len(p.symbols) > 2 {
+				errs.add(p.line, "rule %s -> %s is not in the binary normal form", p.lhs, strings.Join(p.symbols, " "))
+			}
+			for _, B := range p.symbols {
+				if _, ok := defined[B]; !ok {
+					errs.add(p.line, "undefined nonterminal: %q", B)
 				}
-				terminalRules[a].Add(A)
+				graph[p.lhs] = append(graph[p.lhs], B)
+			}
+			if len(p.symbols) == 1 {
+				unary[p.lhs] = append(unary[p.lhs], p)
+			}
+		}
+	}
+
+	// Check the reachability from the start symbol:
+	reached := set.New(startSymbol)
+	queue := []string{startSymbol}
+	for len(queue) > 0 {
+		A := queue[0]
+		queue = queue[1:]
+		for _, B := range graph[A] {
+			if !reached.Contains(B) {
+				reached.Add(B)
+				queue = append(queue, B)
+			}
+		}
+	}
+	unreachable := make([]string, 0)
+	for A := range defined {
+		if !reached.Contains(A) {
+			unreachable = append(unreachable, A)
+		}
+	}
+	sort.Slice(unreachable, func(i, j int) bool {
+		return defined[unreachable[i]] < defined[unreachable[j]]
+	})
+	for _, A := range unreachable {
+		errs.add(defined[A], "nonterminal %q is unreachable from %q", A, startSymbol)
+	}
+
+	// Check the unary cycles with depth-first search:
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	status := make(map[string]int)
+	var visit func(A string)
+	visit = func(A string) {
+		status[A] = visiting
+		for _, p := range unary[A] {
+			B := p.symbols[0]
+			switch status[B] {
+			case unvisited:
+				visit(B)
+			case visiting:
+				errs.add(p.line, "unary cycle through rule %s -> %s", p.lhs, B)
+			}
+		}
+		status[A] = visited
+	}
+	nonTerminals := make([]string, 0, len(unary))
+	for A := range unary {
+		nonTerminals = append(nonTerminals, A)
+	}
+	sort.Strings(nonTerminals)
+	for _, A := range nonTerminals {
+		if status[A] == unvisited {
+			visit(A)
+		}
+	}
+
+	return errs
+}
+
+// ValidateRules validates the grammar production rules in the string.
+// It returns GrammarErrors with line numbers or nil if the rules are valid.
+func ValidateRules(s string) error {
+	_, err := ParseRules(s)
+	return err
+}
+
+// ParseRules parses and validates the grammar production rules from the string.
+func ParseRules(s string) (*Rules, error) {
+	productions, errs := readProductions(s)
+	errs = append(errs, validateProductions(productions)...)
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool {
+			return errs[i].Line < errs[j].Line
+		})
+		return nil, errs
+	}
+
+	return newRules(productions), nil
+}
+
+// newRules creates the rules from the valid production rules by normalizing their weights.
+func newRules(productions []ruleAlternative) *Rules {
+	normalize(productions)
+
+	terminalRules := map[itemType]Probabilities{}
+	unaryRules := map[Element]Probabilities{}
+	binaryRules := map[Element]Probabilities{}
+	nonTerminalSet := set.New()
+
+	for _, p := range productions {
+		A := p.lhs
+		nonTerminalSet.Add(A)
+
+		switch p.typ {
+		case terminalRule:
+			a := ItemType(p.symbols[0])
+			if _, ok := terminalRules[a]; !ok {
+				terminalRules[a] = Probabilities{}
 			}
+			terminalRules[a][A] += p.prob
 		case nonTerminalRule:
-			for _, a := range list {
-				symbols := strings.Fields(a)
-				slice.TrimSpace(symbols)
-				switch len(symbols) {
-				case 0:
-					glog.Fatalf("Cannot read production rule: %s\n", line)
-				case 1:
-					e := NewUnary(symbols[0])
-					if _, ok := unaryRules[e]; !ok {
-						unaryRules[e] = set.New()
-					}
-					unaryRules[e].Add(A)
-					nonTerminalSet.Add(e.leftNonTerminal)
-				default:
-					e := NewBinary(symbols[0], symbols[1])
-					if _, ok := binaryRules[e]; !ok {
-						binaryRules[e] = set.New()
-					}
-					binaryRules[e].Add(A)
-					nonTerminalSet.Add(e.leftNonTerminal)
-					nonTerminalSet.Add(e.rightNonTerminal)
+			switch len(p.symbols) {
+			case 1:
+				e := NewUnary(p.symbols[0])
+				if _, ok := unaryRules[e]; !ok {
+					unaryRules[e] = Probabilities{}
 				}
+				unaryRules[e][A] += p.prob
+				nonTerminalSet.Add(e.leftNonTerminal)
+			default:
+				e := NewBinary(p.symbols[0], p.symbols[1])
+				if _, ok := binaryRules[e]; !ok {
+					binaryRules[e] = Probabilities{}
+				}
+				binaryRules[e][A] += p.prob
+				nonTerminalSet.Add(e.leftNonTerminal)
+				nonTerminalSet.Add(e.rightNonTerminal)
 			}
 		}
 	}
@@ -104,5 +327,91 @@ func LoadRules(s string) *Rules {
 		unaryRules:     unaryRules,
 		binaryRules:    binaryRules,
 		nonTerminalSet: nonTerminalSet,
+		unaryOrder:     unaryOrder(productions),
+		alternatives:   productions,
+	}
+}
+
+// normalize normalizes the rule weights to probabilities over the rules of each lhs nonterminal.
+func normalize(productions []ruleAlternative) {
+	sums := make(map[string]float64)
+	for _, p := range productions {
+		sums[p.lhs] += p.weight
+	}
+	for i := range productions {
+		productions[i].prob = productions[i].weight / sums[productions[i].lhs]
+	}
+}
+
+// unaryOrder orders the nonterminals so that B precedes A for each unary rule A -> B.
+// The unary rules are assumed to have no cycles.
+func unaryOrder(productions []ruleAlternative) []string {
+	graph := make(map[string][]string)
+	nonTerminals := set.New()
+	for _, p := range productions {
+		nonTerminals.Add(p.lhs)
+		if p.typ == nonTerminalRule && len(p.symbols) == 1 {
+			graph[p.lhs] = append(graph[p.lhs], p.symbols[0])
+			nonTerminals.Add(p.symbols[0])
+		}
+	}
+	visited := set.New()
+	order := make([]string, 0, nonTerminals.Size())
+	var visit func(A string)
+	visit = func(A string) {
+		visited.Add(A)
+		for _, B := range graph[A] {
+			if !visited.Contains(B) {
+				visit(B)
+			}
+		}
+		order = append(order, A)
+	}
+	sorted := nonTerminals.Slice()
+	sort.Strings(sorted)
+	for _, A := range sorted {
+		if !visited.Contains(A) {
+			visit(A)
+		}
+	}
+	return order
+}
+
+// String returns the production rules with their probabilities in the grammar text format.
+func (rs *Rules) String() string {
+	var b strings.Builder
+	for _, section := range []RuleType{nonTerminalRule, terminalRule} {
+		switch section {
+		case nonTerminalRule:
+			b.WriteString("#nonterminals:\n\n")
+		case terminalRule:
+			b.WriteString("\n#terminals:\n\n")
+		}
+		var lhs []string
+		alternatives := make(map[string][]string)
+		for _, p := range rs.alternatives {
+			if p.typ != section {
+				continue
+			}
+			if _, ok := alternatives[p.lhs]; !ok {
+				lhs = append(lhs, p.lhs)
+			}
+			a := fmt.Sprintf("%s [%s]", strings.Join(p.symbols, " "), strconv.FormatFloat(p.prob, 'g', 4, 64))
+			alternatives[p.lhs] = append(alternatives[p.lhs], a)
+		}
+		for _, A := range lhs {
+			fmt.Fprintf(&b, "%s -> %s\n", A, strings.Join(alternatives[A], " | "))
+		}
+	}
+	return b.String()
+}
+
+// LoadRules loads the grammar production rules from the string.
+// It terminates the program if the rules are not valid.
+func LoadRules(s string) *Rules {
+	rules, err := ParseRules(s)
+	if err != nil {
+		glog.Fatalf("Invalid grammar production rules:\n%v\n", err)
 	}
+	return rules
 }
@@ -0,0 +1,105 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser/production"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidRules(t *testing.T) {
+	a := assert.New(t)
+
+	a.NoError(ValidateRules(production.CriterionRules))
+	a.NoError(ValidateRules(production.TestRules))
+}
+
+func TestInvalidRules(t *testing.T) {
+	a := assert.New(t)
+
+	rules := `#nonterminals:
+S -> C
+C -> C X Y | R
+R -> V A | V
+V -> V1 | R
+A -> N
+Q -> N N
+X -> O R
+bad rule
+
+#terminals:
+O -> or
+V1 -> variable
+N -> number | numeral
+`
+	expected := GrammarErrors{
+		&GrammarError{Line: 3, Msg: `rule C -> C X Y is not in the binary normal form`},
+		&GrammarError{Line: 3, Msg: `undefined nonterminal: "Y"`},
+		&GrammarError{Line: 5, Msg: `unary cycle through rule V -> R`},
+		&GrammarError{Line: 7, Msg: `nonterminal "Q" is unreachable from "S"`},
+		&GrammarError{Line: 9, Msg: `cannot read production rule: "bad rule"`},
+		&GrammarError{Line: 14, Msg: `unknown terminal: "numeral"`},
+	}
+	err := ValidateRules(rules)
+	a.Equal(expected, err)
+
+	_, err = ParseCFGrammar(rules)
+	a.Error(err)
+}
+
+func TestUndefinedNonTerminal(t *testing.T) {
+	a := assert.New(t)
+
+	rules := `#nonterminals:
+C -> R
+R -> V Z
+
+#terminals:
+V -> variable
+`
+	expected := GrammarErrors{
+		&GrammarError{Line: 0, Msg: `start symbol "S" is not defined`},
+		&GrammarError{Line: 2, Msg: `nonterminal "C" is unreachable from "S"`},
+		&GrammarError{Line: 3, Msg: `undefined nonterminal: "Z"`},
+		&GrammarError{Line: 3, Msg: `nonterminal "R" is unreachable from "S"`},
+		&GrammarError{Line: 6, Msg: `nonterminal "V" is unreachable from "S"`},
+	}
+	a.Equal(expected, ValidateRules(rules))
+}
+
+func TestLoadCFGrammar(t *testing.T) {
+	a := assert.New(t)
+
+	g, err := LoadCFGrammar("../../resources/grammar/criterion.txt")
+	a.NoError(err)
+	a.Equal(NewCFGrammar(production.CriterionRules).rules.String(), g.rules.String())
+}
+
+func TestWeightedRules(t *testing.T) {
+	a := assert.New(t)
+
+	rules := `#nonterminals:
+S -> A B [3] | B C
+A -> B A [0]
+B -> C C [x]
+C -> A B
+
+#terminals:
+A -> or
+B -> and
+C -> or
+`
+	expected := GrammarErrors{
+		&GrammarError{Line: 3, Msg: `weight must be a positive number: "A -> B A [0]"`},
+		&GrammarError{Line: 4, Msg: `weight must be a positive number: "B -> C C [x]"`},
+	}
+	a.Equal(expected, ValidateRules(rules))
+
+	rs, err := ParseRules(strings.Replace(strings.Replace(rules, "[0]", "", 1), "[x]", "", 1))
+	a.NoError(err)
+	a.Contains(rs.String(), "S -> A B [0.75] | B C [0.25]\n")
+}
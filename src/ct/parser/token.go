@@ -31,8 +31,59 @@ const (
 	tokenComparison                         // comparison token
 	tokenLessComparison                     // less than comparison token
 	tokenGreaterComparison                  // greater than comparison token
+	tokenMultiplier                         // multiplier of a reference limit: 'x', '×', 'times'
+	tokenTemporal                           // temporal: 'within', 'prior', 'after', ...
+	tokenAnchor                             // anchor event: 'screening', 'enrollment', 'dose', ...
 )
 
+// String converts tokenType to string.
+func (t tokenType) String() string {
+	switch t {
+	case tokenError:
+		return "error"
+	case tokenEOF:
+		return "eof"
+	case tokenChar:
+		return "char"
+	case tokenSpace:
+		return "space"
+	case tokenIdentifier:
+		return "identifier"
+	case tokenNumber:
+		return "number"
+	case tokenUnit:
+		return "unit"
+	case tokenLeftParenthesis:
+		return "left_parenthesis"
+	case tokenRightParenthesis:
+		return "right_parenthesis"
+	case tokenDash:
+		return "dash"
+	case tokenSlash:
+		return "slash"
+	case tokenPunctuation:
+		return "punctuation"
+	case tokenConjunction:
+		return "conjunction"
+	case tokenNegation:
+		return "negation"
+	case tokenComparison:
+		return "comparison"
+	case tokenLessComparison:
+		return "less_comparison"
+	case tokenGreaterComparison:
+		return "greater_comparison"
+	case tokenMultiplier:
+		return "multiplier"
+	case tokenTemporal:
+		return "temporal"
+	case tokenAnchor:
+		return "anchor"
+	default:
+		return "keyword"
+	}
+}
+
 // Pos is the rune position of the token in the string.
 type Pos int
 
@@ -48,6 +99,11 @@ func NewToken(typ tokenType, pos Pos, val string) *Token {
 	return &Token{typ: typ, pos: pos, val: val}
 }
 
+// End returns the ending position (exclusive), in bytes, of the token in the input string.
+func (t *Token) End() Pos {
+	return t.pos + Pos(len(t.val))
+}
+
 // String returns a string representation of the token.
 func (t *Token) String() string {
 	return fmt.Sprintf("{id:%d,value:%q}", t.typ, t.val)
@@ -30,6 +30,11 @@ func (t *Tree) Size() int {
 	return t.root.Size()
 }
 
+// Span returns the byte offsets of the input string from which the tree is parsed.
+func (t *Tree) Span() (Pos, Pos) {
+	return t.root.Span()
+}
+
 // Contains returns true if the tree t contains the tree v as a sub-tree or they are same.
 func (t *Tree) Contains(v *Tree) bool {
 	return t.root.Contains(v.root)
@@ -118,6 +123,8 @@ type Node struct {
 	val   string
 	left  *Node
 	right *Node
+	begin Pos // The starting position, in bytes, of the node in the input string.
+	end   Pos // The ending position (exclusive), in bytes, of the node in the input string.
 }
 
 // NewNode creates a new node.
@@ -125,6 +132,19 @@ func NewNode(val string) *Node {
 	return &Node{val: val}
 }
 
+// Span returns the byte offsets of the input string from which the node is parsed.
+func (n *Node) Span() (Pos, Pos) {
+	return n.begin, n.end
+}
+
+// relationSpan returns the relation span of the node or nil if the node has no span.
+func (n *Node) relationSpan() *relation.Span {
+	if n.end <= n.begin {
+		return nil
+	}
+	return &relation.Span{Begin: int(n.begin), End: int(n.end)}
+}
+
 // Size calculates the number of leafs (terminals).
 func (n *Node) Size() int {
 	switch {
@@ -259,6 +279,7 @@ func (n *Node) EvalBound() (*relation.Limit, bool) {
 	}
 	num := n.right.left
 	l.Value = num.left.val
+	l.Reference = relation.ParseReference(n.right.EvalUnit())
 	return l, lower
 }
 
@@ -270,16 +291,91 @@ func (n *Node) EvalRange() *relation.Limit {
 	if len(nums) == 1 {
 		l.Value = nums[0]
 	}
+	l.Reference = relation.ParseReference(n.EvalUnit())
 	return l
 }
 
+// EvalTemporal evaluates and returns the time window stored in the temporal node P.
+// It returns nil if the duration of the window has no time unit.
+func (n *Node) EvalTemporal() *relation.Temporal {
+	t := &relation.Temporal{}
+	var eval func(n *Node)
+	eval = func(n *Node) {
+		left := n.left
+		right := n.right
+		switch left.val {
+		case "P":
+			eval(left)
+		case "K":
+			// P -> K L: 'within 6 months'
+			if left.left.val == "within" {
+				t.Direction = relation.Before
+			}
+			t.Unit = right.EvalUnit()
+			t.Upper = right.EvalRange()
+			return
+		case "L":
+			// P -> L J: '4 weeks prior to'
+			t.Unit = left.EvalUnit()
+			t.Upper = left.EvalRange()
+		case "B":
+			// P -> B J: 'at least 4 weeks prior to'
+			b, lower := left.EvalBound()
+			t.Unit = left.EvalUnit()
+			if lower {
+				t.Lower = b
+			} else {
+				t.Upper = b
+			}
+		}
+		if right != nil && right.val == "J" {
+			t.Direction = relation.ParseDirection(right.left.left.val)
+			if right.right != nil {
+				t.Anchor = relation.Anchor(right.right.left.val)
+			}
+		}
+	}
+	eval(n)
+	if !t.Valid() {
+		return nil
+	}
+	return t
+}
+
 // EvalRelation evaluates and returns the relation stored in the parse node based on the production rules.
 func (n *Node) EvalRelation() (*relation.Relation, error) {
-	r := relation.New()
-
 	left := n.left
 	right := n.right
 
+	// A relation with a time window, such as 'a1c > 7% within 3 months', is derived by R -> R P.
+	// The window applies to the limits or values of the relation, so a relation without them is an error.
+	if left.val == "R" && right != nil && right.val == "P" {
+		if v := left.left; left.right == nil && v.val == "V" && right.left.val == "B" {
+			// The bound of a known variable is its limit, not a time window,
+			// such as 'age > 18 years before randomization'.
+			if _, ok := variables.Get().ID(v.EvalVariable()); ok {
+				b := right.left
+				m := &Node{val: "R", left: v, right: &Node{val: "A", left: b, begin: b.begin, end: b.end}, begin: n.begin, end: b.end}
+				return m.EvalRelation()
+			}
+		}
+		r, err := left.EvalRelation()
+		if err != nil {
+			return nil, err
+		}
+		if r.Lower == nil && r.Upper == nil && len(r.Value) == 0 {
+			return nil, fmt.Errorf("time window without limits or values: %s", r.Name)
+		}
+		r.Span = n.relationSpan()
+		if t := right.EvalTemporal(); t != nil {
+			r.Temporal = t
+		}
+		return r, nil
+	}
+
+	r := relation.New()
+	r.Span = n.relationSpan()
+
 	if left.val != "V" {
 		left, right = right, left
 	}
@@ -300,6 +396,13 @@ func (n *Node) EvalRelation() (*relation.Relation, error) {
 
 	r.Unit = m.EvalUnit()
 
+	// A reference unit, such as 'uln', applies to the limits without a unit of their own,
+	// such as the lower limit in '1-2 x ULN'.
+	ref := relation.ParseReference(r.Unit)
+	if ref != relation.NoReference {
+		r.Unit = ""
+	}
+
 	// Check the left branch of A:
 
 	setBound := func(b *relation.Limit, lower bool) {
@@ -334,6 +437,7 @@ func (n *Node) EvalRelation() (*relation.Relation, error) {
 	right = m.right
 
 	if right == nil {
+		r.SetReference(ref)
 		return r, nil
 	}
 
@@ -348,6 +452,8 @@ func (n *Node) EvalRelation() (*relation.Relation, error) {
 		r.Upper = right.EvalRange()
 	}
 
+	r.SetReference(ref)
+
 	return r, nil
 }
 
@@ -362,8 +468,9 @@ func (n *Node) EvalRelations() (relation.Relations, relation.Relations) {
 	case n.left.val == "R" && n.right == nil:
 		orRels := relation.NewRelations()
 		andRels := relation.NewRelations()
-		r, _ := n.left.EvalRelation()
-		andRels = append(andRels, r)
+		if r, err := n.left.EvalRelation(); err == nil {
+			andRels = append(andRels, r)
+		}
 		return orRels, andRels
 	default:
 		m := n.right
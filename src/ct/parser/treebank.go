@@ -0,0 +1,219 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// TreebankNode defines a node of a hand-parsed tree in a treebank. Leaves are item
+// types, such as 'variable' or 'number', optionally followed by the item value
+// after a colon, such as 'variable:bmi'.
+type TreebankNode struct {
+	Label    string
+	Children []*TreebankNode
+	Line     int // Line number of the tree in the treebank, set for roots only
+}
+
+// Leaf returns true if the node is a leaf.
+func (n *TreebankNode) Leaf() bool {
+	return len(n.Children) == 0
+}
+
+// Symbol returns the grammar symbol of the node. The value of a leaf is dropped.
+func (n *TreebankNode) Symbol() string {
+	if n.Leaf() {
+		if i := strings.IndexByte(n.Label, ':'); i >= 0 {
+			return n.Label[:i]
+		}
+	}
+	return n.Label
+}
+
+// String returns the bracketed representation of the node.
+func (n *TreebankNode) String() string {
+	if n.Leaf() {
+		return n.Label
+	}
+	var b strings.Builder
+	b.WriteString("(" + n.Label)
+	for _, c := range n.Children {
+		b.WriteString(" " + c.String())
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// ParseTreebankTree parses a tree in the bracketed format, such as
+// '(S (C (R (V (V1 variable:bmi)) (A (B (T comparison) (L (N number)))))))'.
+func ParseTreebankTree(s string) (*TreebankNode, error) {
+	tokens := bracketTokens(s)
+	n, k, err := parseTreebankNode(tokens, 0)
+	if err != nil {
+		return nil, err
+	}
+	if k != len(tokens) {
+		return nil, fmt.Errorf("unexpected %q after the tree", tokens[k])
+	}
+	if n.Leaf() {
+		return nil, fmt.Errorf("tree has no brackets: %q", s)
+	}
+	return n, nil
+}
+
+// bracketTokens splits the string to brackets and labels.
+func bracketTokens(s string) []string {
+	var tokens []string
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			tokens = append(tokens, b.String())
+			b.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// parseTreebankNode parses the node starting at the token k and returns the index of the next token.
+func parseTreebankNode(tokens []string, k int) (*TreebankNode, int, error) {
+	if k >= len(tokens) {
+		return nil, k, fmt.Errorf("unexpected end of tree")
+	}
+	switch tokens[k] {
+	case ")":
+		return nil, k, fmt.Errorf("unexpected ')'")
+	case "(":
+	default:
+		return &TreebankNode{Label: tokens[k]}, k + 1, nil
+	}
+	k++
+	if k >= len(tokens) || tokens[k] == "(" || tokens[k] == ")" {
+		return nil, k, fmt.Errorf("missing label after '('")
+	}
+	n := &TreebankNode{Label: tokens[k]}
+	k++
+	for k < len(tokens) && tokens[k] != ")" {
+		c, next, err := parseTreebankNode(tokens, k)
+		if err != nil {
+			return nil, next, err
+		}
+		n.Children = append(n.Children, c)
+		k = next
+	}
+	if k >= len(tokens) {
+		return nil, k, fmt.Errorf("missing ')'")
+	}
+	if n.Leaf() {
+		return nil, k, fmt.Errorf("node %q has no children", n.Label)
+	}
+	return n, k + 1, nil
+}
+
+// ReadTreebank reads hand-parsed trees, one per line. Empty lines and lines
+// starting with '#' are skipped. Errors are reported with line numbers.
+func ReadTreebank(r io.Reader) ([]*TreebankNode, error) {
+	var trees []*TreebankNode
+	var errs GrammarErrors
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		t, err := ParseTreebankTree(line)
+		if err != nil {
+			errs.add(n, "%v", err)
+			continue
+		}
+		t.Line = n
+		trees = append(trees, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return trees, nil
+}
+
+// countRules counts the production rules used in the tree t. Every rule must be
+// defined by the grammar, whose rules are keyed by ruleAlternative.key.
+func countRules(t *TreebankNode, defined map[string]bool, counts map[string]float64) error {
+	symbols := make([]string, len(t.Children))
+	for i, c := range t.Children {
+		symbols[i] = c.Symbol()
+	}
+	key := t.Label + " -> " + strings.Join(symbols, " ")
+	if !defined[key] {
+		return fmt.Errorf("rule not in the grammar: %s", key)
+	}
+	counts[key]++
+	for _, c := range t.Children {
+		if c.Leaf() {
+			continue
+		}
+		if err := countRules(c, defined, counts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Estimate estimates the rule probabilities from the treebank of hand-parsed criteria.
+// The probabilities are maximum-likelihood estimates with add-λ smoothing, where
+// λ is the smoothing parameter, so that rules absent from the treebank keep a positive
+// probability. The trees must use the rules of rs only and be rooted at the start symbol.
+func (rs *Rules) Estimate(r io.Reader, smoothing float64) (*Rules, error) {
+	if smoothing <= 0 {
+		return nil, fmt.Errorf("smoothing must be positive: %v", smoothing)
+	}
+	trees, err := ReadTreebank(r)
+	if err != nil {
+		return nil, err
+	}
+	defined := make(map[string]bool)
+	for _, p := range rs.alternatives {
+		defined[p.key()] = true
+	}
+	counts := make(map[string]float64)
+	var errs GrammarErrors
+	for _, t := range trees {
+		if t.Label != startSymbol {
+			errs.add(t.Line, "tree is not rooted at %q: %s", startSymbol, t)
+			continue
+		}
+		if err := countRules(t, defined, counts); err != nil {
+			errs.add(t.Line, "%v", err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	productions := make([]ruleAlternative, len(rs.alternatives))
+	for i, p := range rs.alternatives {
+		p.weight = counts[p.key()] + smoothing
+		productions[i] = p
+	}
+	return newRules(productions), nil
+}
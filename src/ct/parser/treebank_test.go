@@ -0,0 +1,73 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser/production"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTreebankTree(t *testing.T) {
+	a := assert.New(t)
+
+	s := "(S (C (R (V (V1 variable:bmi)) (A (B (T comparison) (L (N number)))))))"
+	n, err := ParseTreebankTree(s)
+	a.NoError(err)
+	a.Equal(s, n.String())
+	leaf := n.Children[0].Children[0].Children[0].Children[0].Children[0]
+	a.True(leaf.Leaf())
+	a.Equal("variable", leaf.Symbol())
+
+	for _, s := range []string{"", "variable", "(S", "(S (C variable)) )", "(S ())", "(S)"} {
+		_, err := ParseTreebankTree(s)
+		a.Error(err, s)
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	a := assert.New(t)
+
+	treebank := `# y x x x
+(S (A (B and) (A or)) (B (C or) (C or)))
+(S (A (B and) (A or)) (B (C or) (C or)))
+
+(S (B and) (C (A or) (B (C or) (C or))))
+`
+	rules := LoadRules(production.TestRules)
+	estimated, err := rules.Estimate(strings.NewReader(treebank), 1)
+	a.NoError(err)
+	a.Contains(estimated.String(), "S -> A B [0.6] | B C [0.4]\n")
+	// The probabilities of A are normalized over its nonterminal and terminal rules.
+	a.Contains(estimated.String(), "A -> B A [0.4286]\n")
+	a.Contains(estimated.String(), "A -> or [0.5714]\n")
+
+	// The estimated rules are valid grammar text.
+	_, err = ParseRules(estimated.String())
+	a.NoError(err)
+
+	_, err = rules.Estimate(strings.NewReader(treebank), 0)
+	a.Error(err)
+
+	_, err = rules.Estimate(strings.NewReader("(S (A (B and) (A or)) (B (C or) (C or)))\n(S (C or) (C or))\n"), 1)
+	a.EqualError(err, "line 2: rule not in the grammar: S -> C C")
+
+	_, err = rules.Estimate(strings.NewReader("(A (B and) (A or))\n"), 1)
+	a.EqualError(err, `line 1: tree is not rooted at "S": (A (B and) (A or))`)
+}
+
+func TestEstimateCriterionTreebank(t *testing.T) {
+	a := assert.New(t)
+
+	g, err := LoadCFGrammar("../../resources/grammar/criterion.txt")
+	a.NoError(err)
+	treebank := `(S (C (R (V (V1 variable:bmi)) (A (B (T comparison) (L (N number)))))))
+(S (C (C (R (V (V1 variable)) (A (B (T comparison) (L (N number)))))) (X (O or) (R (V (V1 variable)) (A (B (T comparison) (L (N number) (U unit))))))))
+`
+	rules, err := g.Rules().Estimate(strings.NewReader(treebank), 0.5)
+	a.NoError(err)
+	a.Contains(rules.String(), "V1 -> variable [0.875] | unknown [0.125]\n")
+}
@@ -0,0 +1,205 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package relation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+
+	"github.com/golang/glog"
+)
+
+// Reference defines the limit of a laboratory reference range to which
+// a relative limit, such as '≤ 2.5 x ULN', refers.
+type Reference string
+
+const (
+	NoReference Reference = ""
+	ULN         Reference = "uln" // Upper limit of normal
+	LLN         Reference = "lln" // Lower limit of normal
+)
+
+// ParseReference returns the reference of the unit name, such as 'uln',
+// or NoReference if the unit is not a reference limit.
+func ParseReference(unit string) Reference {
+	switch Reference(unit) {
+	case ULN:
+		return ULN
+	case LLN:
+		return LLN
+	default:
+		return NoReference
+	}
+}
+
+// ReferenceRange defines the normal range of a laboratory variable.
+type ReferenceRange struct {
+	Lower float64 `json:"lower"`          // Lower limit of normal
+	Upper float64 `json:"upper"`          // Upper limit of normal
+	Unit  string  `json:"unit,omitempty"` // Unit of the limits
+}
+
+// NewReferenceRange creates a new reference range.
+func NewReferenceRange(lower, upper float64, unit string) ReferenceRange {
+	return ReferenceRange{Lower: lower, Upper: upper, Unit: unit}
+}
+
+// Limit returns the reference range limit that ref refers to.
+func (rr ReferenceRange) Limit(ref Reference) (float64, bool) {
+	switch ref {
+	case ULN:
+		return rr.Upper, true
+	case LLN:
+		return rr.Lower, true
+	default:
+		return 0, false
+	}
+}
+
+// ReferenceRanges maps variable names to the reference ranges of a laboratory.
+type ReferenceRanges map[string]ReferenceRange
+
+// LoadReferenceRanges loads the reference ranges of a laboratory from a file.
+// The columns are variable name, lower limit, upper limit, and unit.
+func LoadReferenceRanges(fname string) (ReferenceRanges, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ranges := make(ReferenceRanges)
+	r := csv.NewReader(f)
+	r.Comment = rune(param.Comment)
+
+	for {
+		line, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fname, err)
+		}
+		if len(line) < 4 {
+			return nil, fmt.Errorf("%s: too few columns, at least 4 needed: %v", fname, line)
+		}
+		lower, err := strconv.ParseFloat(line[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad lower limit: %v", fname, line)
+		}
+		upper, err := strconv.ParseFloat(line[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad upper limit: %v", fname, line)
+		}
+		ranges[line[0]] = NewReferenceRange(lower, upper, line[3])
+	}
+	glog.Infof("Number of reference ranges loaded: %d\n", len(ranges))
+
+	return ranges, nil
+}
+
+// Relative returns true if a limit of the numerical relation is relative to a reference limit.
+func (r *Relation) Relative() bool {
+	return (r.Lower != nil && r.Lower.Reference != NoReference) ||
+		(r.Upper != nil && r.Upper.Reference != NoReference)
+}
+
+// SetReference sets the reference of the limits that have no reference.
+func (r *Relation) SetReference(ref Reference) {
+	for _, l := range []*Limit{r.Lower, r.Upper} {
+		if l != nil && l.Reference == NoReference {
+			l.Reference = ref
+		}
+	}
+}
+
+// Resolve returns a copy of the relation where the relative limits are resolved
+// to absolute limits with the reference range rr. The absolute limits are expressed
+// in the unit of the relation, or if it is missing, in the default unit of the variable
+// when the conversion is known and otherwise in the unit of the reference range.
+// The relative limits are kept in the Original field.
+func (r *Relation) Resolve(rr ReferenceRange) (*Relation, error) {
+	if !r.Relative() {
+		return nil, fmt.Errorf("relation has no relative limits: %s", r.Name)
+	}
+	unit := r.Unit
+	if len(unit) == 0 {
+		unit = rr.Unit
+		if v := variables.Get().Variable(r.ID); v != nil && len(v.UnitName) > 0 {
+			if _, ok := units.Get().Conversion(rr.Unit, v.UnitName, r.Name); ok {
+				unit = v.UnitName
+			}
+		}
+	}
+	c, ok := units.Get().Conversion(rr.Unit, unit, r.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown conversion from %q to %q: %s", rr.Unit, unit, r.Name)
+	}
+	lower, err := resolveLimit(c, rr, r.Lower)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := resolveLimit(c, rr, r.Upper)
+	if err != nil {
+		return nil, err
+	}
+	q := *r
+	q.Value = append([]string(nil), r.Value...)
+	q.Original = &Quantity{Unit: r.Unit, Lower: copyLimit(r.Lower), Upper: copyLimit(r.Upper)}
+	q.Temporal = r.Temporal.copy()
+	q.Unit = unit
+	q.Lower = lower
+	q.Upper = upper
+	return &q, nil
+}
+
+// resolveLimit resolves the relative limit l to the absolute limit with the reference range rr
+// and converts it to another unit with the conversion c. An absolute limit is copied as is.
+func resolveLimit(c units.Conversion, rr ReferenceRange, l *Limit) (*Limit, error) {
+	if l == nil || l.Reference == NoReference {
+		return copyLimit(l), nil
+	}
+	multiplier, err := strconv.ParseFloat(l.Value, 64)
+	if err != nil {
+		return nil, err
+	}
+	limit, ok := rr.Limit(l.Reference)
+	if !ok {
+		return nil, fmt.Errorf("unknown reference: %q", l.Reference)
+	}
+	return &Limit{Incl: l.Incl, Value: formatValue(c.Apply(multiplier * limit))}, nil
+}
+
+// copyLimit returns a copy of the limit.
+func copyLimit(l *Limit) *Limit {
+	if l == nil {
+		return nil
+	}
+	c := *l
+	return &c
+}
+
+// Resolve returns the relations where the relative relations are resolved with the reference ranges
+// of their variables. Relations without relative limits or a known reference range are returned as is.
+func (rs Relations) Resolve(ranges ReferenceRanges) Relations {
+	rels := make(Relations, 0, len(rs))
+	for _, r := range rs {
+		if rr, ok := ranges[r.Name]; ok && r.Relative() {
+			if q, err := r.Resolve(rr); err == nil {
+				rels = append(rels, q)
+				continue
+			} else {
+				glog.Warningf("Failed to resolve relation: %v\n", err)
+			}
+		}
+		rels = append(rels, r)
+	}
+	return rels
+}
@@ -0,0 +1,62 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package relation
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	a := assert.New(t)
+
+	r := &Relation{ID: "411", Name: "ast", Lower: &Limit{Incl: true, Value: "1", Reference: LLN}, Upper: &Limit{Incl: true, Value: "2.5", Reference: ULN}, VariableType: variables.Numerical}
+	expected := &Relation{ID: "411", Name: "ast", Unit: "u/l", Lower: &Limit{Incl: true, Value: "10"}, Upper: &Limit{Incl: true, Value: "100"}, VariableType: variables.Numerical,
+		Original: &Quantity{Lower: &Limit{Incl: true, Value: "1", Reference: LLN}, Upper: &Limit{Incl: true, Value: "2.5", Reference: ULN}}}
+	actual, err := r.Resolve(NewReferenceRange(10, 40, "u/l"))
+	a.NoError(err)
+	a.Equal(expected, actual)
+	a.True(r.Relative())
+	a.False(actual.Relative())
+}
+
+func TestResolveMixedUnits(t *testing.T) {
+	a := assert.New(t)
+
+	r := &Relation{ID: "403", Name: "hb_count", Unit: "g/dl", Lower: &Limit{Incl: true, Value: "9"}, Upper: &Limit{Incl: true, Value: "1", Reference: ULN}, VariableType: variables.Numerical}
+	expected := &Relation{ID: "403", Name: "hb_count", Unit: "g/dl", Lower: &Limit{Incl: true, Value: "9"}, Upper: &Limit{Incl: true, Value: "1.6"}, VariableType: variables.Numerical,
+		Original: &Quantity{Unit: "g/dl", Lower: &Limit{Incl: true, Value: "9"}, Upper: &Limit{Incl: true, Value: "1", Reference: ULN}}}
+	actual, err := r.Resolve(NewReferenceRange(1200, 1600, "mg/dl"))
+	a.NoError(err)
+	a.Equal(expected, actual)
+}
+
+func TestResolveUnknownConversion(t *testing.T) {
+	a := assert.New(t)
+
+	r := &Relation{ID: "403", Name: "hb_count", Unit: "g/dl", Upper: &Limit{Incl: true, Value: "1", Reference: ULN}, VariableType: variables.Numerical}
+	_, err := r.Resolve(NewReferenceRange(7.5, 10, "mmol/l"))
+	a.Error(err)
+}
+
+func TestResolveRelations(t *testing.T) {
+	a := assert.New(t)
+
+	ast := &Relation{ID: "411", Name: "ast", Upper: &Limit{Incl: true, Value: "3", Reference: ULN}, VariableType: variables.Numerical}
+	alt := &Relation{ID: "412", Name: "alt", Upper: &Limit{Incl: true, Value: "3", Reference: ULN}, VariableType: variables.Numerical}
+	ranges := ReferenceRanges{"ast": NewReferenceRange(10, 40, "u/l")}
+	actual := Relations{ast, alt}.Resolve(ranges)
+	a.Len(actual, 2)
+	a.Equal(&Limit{Incl: true, Value: "120"}, actual[0].Upper)
+	a.Equal(alt, actual[1])
+}
+
+func TestHumanReadableReference(t *testing.T) {
+	a := assert.New(t)
+
+	r := &Relation{ID: "411", Name: "ast", DisplayName: "AST", Upper: &Limit{Incl: true, Value: "2.5", Reference: ULN}, VariableType: variables.Numerical}
+	a.Equal("AST ≤ 2.5 × ULN", r.HumanReadable())
+}
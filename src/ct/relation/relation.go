@@ -14,6 +14,7 @@ import (
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/slice"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/text"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
 
 	"github.com/golang/glog"
@@ -25,23 +26,48 @@ var (
 	reTimes       = regexp.MustCompile(`\s*(x|×)\s*`)
 )
 
-// Limit defines a lower or upper bound of a numerical relation.
+// significantDigits is the precision of limit values converted to another unit.
+const significantDigits = 6
+
+// Limit defines a lower or upper bound of a numerical relation. If the limit
+// refers to a reference limit, such as '2.5 x ULN', the value is the multiplier
+// of the reference limit.
 type Limit struct {
-	Incl  bool   `json:"incl"`  // True if limit is inclusive
-	Value string `json:"value"` // Value of limit bound
+	Incl      bool      `json:"incl"`                // True if limit is inclusive
+	Value     string    `json:"value"`               // Value of limit bound
+	Reference Reference `json:"reference,omitempty"` // Reference limit to which the value is relative
+}
+
+// Span defines the byte offsets [Begin, End) of the text from which a relation is parsed.
+type Span struct {
+	Begin int `json:"begin"` // Starting offset of the text
+	End   int `json:"end"`   // Ending offset (exclusive) of the text
+}
+
+// Quantity defines the limits and unit of a numerical relation as they were parsed,
+// before the relation was converted to the default unit of its variable.
+type Quantity struct {
+	Unit  string `json:"unit,omitempty"`  // Parsed unit
+	Lower *Limit `json:"lower,omitempty"` // Parsed lower bound
+	Upper *Limit `json:"upper,omitempty"` // Parsed upper bound
 }
 
 // Relation defines a boolean, nominal, ordinal, or numerical criterion.
 type Relation struct {
 	ID           variables.ID   `json:"id,omitempty"`
-	Name         string         `json:"name"`            // Relation name, typically the variable name
-	DisplayName  string         `json:"-"`               // Variable display name
-	Unit         string         `json:"unit,omitempty"`  // Variable unit
-	Value        []string       `json:"value,omitempty"` // Valid values of categorical relation
-	Lower        *Limit         `json:"lower,omitempty"` // Lower bound of numerical relation condition
-	Upper        *Limit         `json:"upper,omitempty"` // Upper bound of numerical relation condition
-	VariableType variables.Type `json:"variableType"`    // Type of relation
-	Score        float64        `json:"score"`           // Confidence estimate of the relation representation being correct
+	Name         string         `json:"name"`                   // Relation name, typically the variable name
+	DisplayName  string         `json:"-"`                      // Variable display name
+	Unit         string         `json:"unit,omitempty"`         // Variable unit
+	UnitInferred bool           `json:"unitInferred,omitempty"` // True if the unit is the default unit of the variable and not parsed
+	Value        []string       `json:"value,omitempty"`        // Valid values of categorical relation
+	Lower        *Limit         `json:"lower,omitempty"`        // Lower bound of numerical relation condition
+	Upper        *Limit         `json:"upper,omitempty"`        // Upper bound of numerical relation condition
+	Original     *Quantity      `json:"original,omitempty"`     // Parsed limits and unit of a converted numerical relation
+	Temporal     *Temporal      `json:"temporal,omitempty"`     // Time window of the criterion, such as 'within 6 months'
+	Span         *Span          `json:"span,omitempty"`         // Offsets of the parsed text in the criterion
+	TextSpan     *Span          `json:"textSpan,omitempty"`     // Offsets of the parsed text in the eligibility criteria
+	VariableType variables.Type `json:"variableType"`           // Type of relation
+	Score        float64        `json:"score"`                  // Confidence estimate of the relation representation being correct
 }
 
 // Relations defines a slice of relations.
@@ -90,7 +116,7 @@ func (r *Relation) HumanReadable() string {
 			} else {
 				s += " > "
 			}
-			s += r.Lower.Value
+			s += r.Lower.value()
 		}
 		if r.Upper != nil {
 			if r.Lower != nil {
@@ -107,14 +133,30 @@ func (r *Relation) HumanReadable() string {
 			} else {
 				s += " < "
 			}
-			s += r.Upper.Value
+			s += r.Upper.value()
 		}
 		if r.Unit != "" {
 			s += " " + r.Unit
 		}
-		return s
+		return s + r.temporal()
 	}
-	return text.Join(text.Titles(r.Value), ", ", " or ")
+	return text.Join(text.Titles(r.Value), ", ", " or ") + r.temporal()
+}
+
+// temporal returns the human readable time window of the relation with a leading space.
+func (r *Relation) temporal() string {
+	if r.Temporal == nil {
+		return ""
+	}
+	return " " + r.Temporal.String()
+}
+
+// value returns the human readable value of the limit.
+func (l *Limit) value() string {
+	if l.Reference != NoReference {
+		return l.Value + " × " + strings.ToUpper(string(l.Reference))
+	}
+	return l.Value
 }
 
 // SetScore sets the confidence score that the relation is parsed correctly.
@@ -129,8 +171,26 @@ func (r *Relation) SetVariableFields(v *variables.Variable) {
 }
 
 // SetUnitField sets the variable unit to the default value if the unit missing.
+// The unit is marked as inferred. Relative limits, such as '1.5 x uln', limits with
+// a scientific multiplier, such as '100x10^9', and limits outside the valid range of
+// the variable, such as 'temperature > 100.4', get no unit because the default unit
+// of the variable would misrepresent them.
 func (r *Relation) SetUnitField(v *variables.Variable) {
+	if len(r.Unit) > 0 || len(v.UnitName) == 0 || r.Relative() || r.scientific() || r.checkRange(v) != nil {
+		return
+	}
 	r.Unit = v.UnitName
+	r.UnitInferred = true
+}
+
+// scientific returns true if a limit value has a multiplier, such as '100x109' or '1.5 × 10^9'.
+func (r *Relation) scientific() bool {
+	for _, l := range []*Limit{r.Lower, r.Upper} {
+		if l != nil && reTimes.MatchString(l.Value) {
+			return true
+		}
+	}
+	return false
 }
 
 // Normalize normalizes the relation by making the relation content
@@ -233,7 +293,29 @@ func (r *Relation) Negate(valueRange []string) {
 	}
 }
 
+// Negated returns a negated copy of the relation. The limits and values are copied,
+// so the relation is not changed.
+func (r *Relation) Negated() *Relation {
+	n := *r
+	if r.Lower != nil {
+		l := *r.Lower
+		n.Lower = &l
+	}
+	if r.Upper != nil {
+		u := *r.Upper
+		n.Upper = &u
+	}
+	n.Value = append([]string(nil), r.Value...)
+	var valueRange []string
+	if v := variables.Get().Variable(r.ID); v != nil {
+		valueRange = v.Range
+	}
+	n.Negate(valueRange)
+	return &n
+}
+
 // Transform transforms criteria relations by converting parsed values to strings of valid literals.
+// Numerical limits are converted to the default unit of the variable when the conversion is known.
 // If a valid literal cannot be inferred, the confidence score of the relation is set to zero.
 // Indifferent nominal relations are removed by setting the confidence score to zero.
 func (r *Relation) Transform() {
@@ -245,20 +327,30 @@ func (r *Relation) Transform() {
 			r.Score = 0
 		}
 	case variables.Numerical:
+		valid := true
 		if r.Lower != nil {
 			if s, err := transform(v, r.Lower.Value); err == nil {
 				r.Lower.Value = s
 			} else {
-				r.Score = 0
+				valid = false
 			}
 		}
 		if r.Upper != nil {
 			if s, err := transform(v, r.Upper.Value); err == nil {
 				r.Upper.Value = s
 			} else {
-				r.Score = 0
+				valid = false
+			}
+		}
+		if valid {
+			r.Convert(v)
+			if err := r.checkRange(v); err != nil {
+				valid = false
 			}
 		}
+		if !valid {
+			r.Score = 0
+		}
 	}
 }
 
@@ -278,13 +370,81 @@ func transform(v *variables.Variable, s string) (string, error) {
 	if len(values) == 2 {
 		s = values[0] + text.NormalizeScientificMultiplier(values[1])
 	}
-	val, err := strconv.ParseFloat(s, 64)
-	if err == nil && !v.InRange(val) {
-		err = fmt.Errorf("value %q not in valid range of variable: %s", s, v.Name)
-	}
+	_, err := strconv.ParseFloat(s, 64)
 	return s, err
 }
 
+// checkRange returns non-nil error if a limit is not in the valid range of the variable.
+func (r *Relation) checkRange(v *variables.Variable) error {
+	for _, l := range []*Limit{r.Lower, r.Upper} {
+		if l == nil || l.Reference != NoReference {
+			continue
+		}
+		if val, err := strconv.ParseFloat(l.Value, 64); err == nil && !v.InRange(val) {
+			return fmt.Errorf("value %q not in valid range of variable: %s", l.Value, v.Name)
+		}
+	}
+	return nil
+}
+
+// Convert converts the limits of the numerical relation to the default unit of the variable v.
+// The parsed limits and unit are kept in the Original field. It returns false if the relation
+// is already in the default unit or the conversion is not known.
+func (r *Relation) Convert(v *variables.Variable) bool {
+	if r.VariableType != variables.Numerical || v == nil {
+		return false
+	}
+	if len(r.Unit) == 0 || len(v.UnitName) == 0 || r.Unit == v.UnitName {
+		return false
+	}
+	c, ok := units.Get().Conversion(r.Unit, v.UnitName, v.Name)
+	if !ok {
+		return false
+	}
+	lower, err := convertLimit(c, r.Lower)
+	if err != nil {
+		return false
+	}
+	upper, err := convertLimit(c, r.Upper)
+	if err != nil {
+		return false
+	}
+	// An affine conversion with a negative factor would swap the limits.
+	if c.Factor < 0 {
+		lower, upper = upper, lower
+	}
+	r.Original = &Quantity{Unit: r.Unit, Lower: r.Lower, Upper: r.Upper}
+	r.Unit = v.UnitName
+	r.Lower = lower
+	r.Upper = upper
+	return true
+}
+
+// convertLimit converts the limit value with the conversion c.
+// A limit relative to a reference limit has no unit and is copied as is.
+func convertLimit(c units.Conversion, l *Limit) (*Limit, error) {
+	if l == nil {
+		return nil, nil
+	}
+	if l.Reference != NoReference {
+		return copyLimit(l), nil
+	}
+	val, err := strconv.ParseFloat(l.Value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &Limit{Incl: l.Incl, Value: formatValue(c.Apply(val))}, nil
+}
+
+// formatValue formats the converted value with a fixed number of significant digits.
+func formatValue(val float64) string {
+	s := strconv.FormatFloat(val, 'g', significantDigits, 64)
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return s
+}
+
 // Split splits the combination relation into individual relations. Because such relations may not have
 // a valid ID, the split operation needs to be done before validation.
 func (r *Relation) Split() Relations {
@@ -299,18 +459,18 @@ func (r *Relation) Split() Relations {
 	if !(ok0 && ok1) {
 		return Relations{r}
 	}
-	r0 := &Relation{ID: id0, Name: names[0], Unit: r.Unit, VariableType: r.VariableType, Score: r.Score}
-	r1 := &Relation{ID: id1, Name: names[1], Unit: r.Unit, VariableType: r.VariableType, Score: r.Score}
+	r0 := &Relation{ID: id0, Name: names[0], Unit: r.Unit, Temporal: r.Temporal, Span: r.Span, TextSpan: r.TextSpan, VariableType: r.VariableType, Score: r.Score}
+	r1 := &Relation{ID: id1, Name: names[1], Unit: r.Unit, Temporal: r.Temporal.copy(), Span: r.Span, TextSpan: r.TextSpan, VariableType: r.VariableType, Score: r.Score}
 	if r.Lower != nil {
 		values := strings.Split(r.Lower.Value, "/")
 		slice.TrimSpace(values)
 		switch len(values) {
 		case 1:
-			r0.Lower = &Limit{Incl: r.Lower.Incl, Value: values[0]}
-			r1.Lower = &Limit{Incl: r.Lower.Incl, Value: values[0]}
+			r0.Lower = &Limit{Incl: r.Lower.Incl, Value: values[0], Reference: r.Lower.Reference}
+			r1.Lower = &Limit{Incl: r.Lower.Incl, Value: values[0], Reference: r.Lower.Reference}
 		case 2:
-			r0.Lower = &Limit{Incl: r.Lower.Incl, Value: values[0]}
-			r1.Lower = &Limit{Incl: r.Lower.Incl, Value: values[1]}
+			r0.Lower = &Limit{Incl: r.Lower.Incl, Value: values[0], Reference: r.Lower.Reference}
+			r1.Lower = &Limit{Incl: r.Lower.Incl, Value: values[1], Reference: r.Lower.Reference}
 		}
 	}
 	if r.Upper != nil {
@@ -318,11 +478,11 @@ func (r *Relation) Split() Relations {
 		slice.TrimSpace(values)
 		switch len(values) {
 		case 1:
-			r0.Upper = &Limit{Incl: r.Upper.Incl, Value: values[0]}
-			r1.Upper = &Limit{Incl: r.Upper.Incl, Value: values[0]}
+			r0.Upper = &Limit{Incl: r.Upper.Incl, Value: values[0], Reference: r.Upper.Reference}
+			r1.Upper = &Limit{Incl: r.Upper.Incl, Value: values[0], Reference: r.Upper.Reference}
 		case 2:
-			r0.Upper = &Limit{Incl: r.Upper.Incl, Value: values[0]}
-			r1.Upper = &Limit{Incl: r.Upper.Incl, Value: values[1]}
+			r0.Upper = &Limit{Incl: r.Upper.Incl, Value: values[0], Reference: r.Upper.Reference}
+			r1.Upper = &Limit{Incl: r.Upper.Incl, Value: values[1], Reference: r.Upper.Reference}
 		}
 	}
 	return Relations{r0, r1}
@@ -367,6 +527,25 @@ func (rs Relations) SetScore(score float64) {
 	}
 }
 
+// SetTextSpan sets the offsets of the relations in the eligibility criteria text.
+// The offsets maps the criterion offsets to the text offsets.
+func (rs Relations) SetTextSpan(offsets []int) {
+	for _, r := range rs {
+		if r.Span == nil || r.Span.End >= len(offsets) {
+			continue
+		}
+		r.TextSpan = &Span{Begin: offsets[r.Span.Begin], End: offsets[r.Span.End]}
+	}
+}
+
+// ClearSpans removes the text offsets from the relations.
+func (rs Relations) ClearSpans() {
+	for _, r := range rs {
+		r.Span = nil
+		r.TextSpan = nil
+	}
+}
+
 // MinScore returns the minimum score of the relations.
 func (rs Relations) MinScore() float64 {
 	if len(rs) == 0 {
@@ -410,9 +589,7 @@ func (rs Relations) setRelationFields() {
 	for _, r := range rs {
 		if v := variableCatalog.Variable(r.ID); v != nil {
 			r.SetVariableFields(v)
-			if r.Unit == "" {
-				r.SetUnitField(v)
-			}
+			r.SetUnitField(v)
 		}
 	}
 }
@@ -43,6 +43,16 @@ func TestNegate(t *testing.T) {
 	a.Equal(expected, actual)
 }
 
+func TestNegated(t *testing.T) {
+	a := assert.New(t)
+
+	r := &Relation{ID: variables.Zero, Lower: &Limit{Incl: true, Value: "18"}, VariableType: variables.Numerical}
+	expected := &Relation{ID: variables.Zero, Upper: &Limit{Incl: false, Value: "18"}, VariableType: variables.Numerical}
+	a.Equal(expected, r.Negated())
+	a.Equal(&Limit{Incl: true, Value: "18"}, r.Lower)
+	a.Nil(r.Upper)
+}
+
 func TestTransformGoodValue(t *testing.T) {
 	a := assert.New(t)
 
@@ -87,3 +97,56 @@ func TestTransformScientificFormValue(t *testing.T) {
 	actual.Transform()
 	a.Equal(expected, actual)
 }
+
+func TestConvert(t *testing.T) {
+	a := assert.New(t)
+
+	v := variables.NewVariable("202", variables.Numerical, "weight", "Weight", nil, nil, "kg")
+	actual := Relation{ID: "202", Name: "weight", Unit: "lb", Lower: &Limit{Incl: true, Value: "110"}, Upper: &Limit{Incl: false, Value: "330"}, VariableType: variables.Numerical}
+	expected := Relation{ID: "202", Name: "weight", Unit: "kg", Lower: &Limit{Incl: true, Value: "49.8952"}, Upper: &Limit{Incl: false, Value: "149.685"}, VariableType: variables.Numerical,
+		Original: &Quantity{Unit: "lb", Lower: &Limit{Incl: true, Value: "110"}, Upper: &Limit{Incl: false, Value: "330"}}}
+	a.True(actual.Convert(v))
+	a.Equal(expected, actual)
+}
+
+func TestConvertUnknownUnit(t *testing.T) {
+	a := assert.New(t)
+
+	v := variables.NewVariable("202", variables.Numerical, "weight", "Weight", nil, nil, "kg")
+	actual := Relation{ID: "202", Name: "weight", Unit: "%", Lower: &Limit{Incl: true, Value: "10"}, VariableType: variables.Numerical}
+	expected := Relation{ID: "202", Name: "weight", Unit: "%", Lower: &Limit{Incl: true, Value: "10"}, VariableType: variables.Numerical}
+	a.False(actual.Convert(v))
+	a.Equal(expected, actual)
+}
+
+func TestSetUnitField(t *testing.T) {
+	a := assert.New(t)
+
+	v := variables.NewVariable("405", variables.Numerical, "platelet_count", "Platelet count", nil, nil, "cells/ul")
+	actual := Relation{ID: "405", Lower: &Limit{Incl: true, Value: "100"}, Score: 1}
+	actual.SetUnitField(v)
+	a.Equal("cells/ul", actual.Unit)
+	a.True(actual.UnitInferred)
+	a.Equal(1.0, actual.Score)
+
+	actual = Relation{ID: "405", Lower: &Limit{Incl: true, Value: "100x109"}, Score: 1}
+	actual.SetUnitField(v)
+	a.Empty(actual.Unit)
+	a.Equal(1.0, actual.Score)
+
+	actual = Relation{ID: "405", Lower: &Limit{Incl: true, Value: "0.5", Reference: LLN}, Score: 1}
+	actual.SetUnitField(v)
+	a.Empty(actual.Unit)
+	a.False(actual.UnitInferred)
+
+	v = variables.NewVariable("207", variables.Numerical, "body_temperature", "Body temperature", nil, []float64{25, 45}, "c")
+	actual = Relation{ID: "207", Lower: &Limit{Incl: false, Value: "100.4"}, Score: 1}
+	actual.SetUnitField(v)
+	a.Empty(actual.Unit)
+	a.False(actual.UnitInferred)
+
+	actual = Relation{ID: "207", Lower: &Limit{Incl: false, Value: "38"}, Score: 1}
+	actual.SetUnitField(v)
+	a.Equal("c", actual.Unit)
+	a.True(actual.UnitInferred)
+}
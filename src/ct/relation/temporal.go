@@ -0,0 +1,185 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package relation
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/units"
+)
+
+// Direction defines whether the event of a criterion precedes or follows the anchor event.
+type Direction string
+
+const (
+	NoDirection Direction = ""
+	Before      Direction = "before"
+	After       Direction = "after"
+)
+
+// ParseDirection returns the direction of the temporal keyword, such as 'prior' or 'following',
+// or NoDirection if the keyword does not imply a direction.
+func ParseDirection(s string) Direction {
+	switch s {
+	case "before", "prior", "preceding", "ago", "within", "past", "last", "previous":
+		return Before
+	case "after", "following", "since":
+		return After
+	default:
+		return NoDirection
+	}
+}
+
+// Anchor defines the event from which a time window is measured.
+type Anchor string
+
+const (
+	NoAnchor      Anchor = "" // The time of the eligibility assessment
+	Screening     Anchor = "screening"
+	Enrollment    Anchor = "enrollment"
+	Randomization Anchor = "randomization"
+	Consent       Anchor = "consent"
+	FirstDose     Anchor = "first_dose"
+	LastDose      Anchor = "last_dose"
+	Diagnosis     Anchor = "diagnosis"
+)
+
+// ParseAnchor returns the anchor of the keyword, such as 'randomisation' or 'dose',
+// or NoAnchor if the keyword is not an anchor event. A dose is the first dose; see ParseLastAnchor.
+func ParseAnchor(s string) Anchor {
+	switch s {
+	case "screening", "baseline":
+		return Screening
+	case "enrollment", "enrolment", "entry", "registration", "inclusion":
+		return Enrollment
+	case "randomization", "randomisation":
+		return Randomization
+	case "consent":
+		return Consent
+	case "dose", "dosing", "drug", "treatment":
+		return FirstDose
+	case "diagnosis":
+		return Diagnosis
+	default:
+		return NoAnchor
+	}
+}
+
+// ParseLastAnchor returns the anchor of the keyword that is qualified by the word, such as 'last'
+// in 'since the last dose'. A dose qualified by 'last', 'final', or 'recent' is the last dose.
+func ParseLastAnchor(word, s string) Anchor {
+	a := ParseAnchor(s)
+	if a == FirstDose && IsLastWord(word) {
+		return LastDose
+	}
+	return a
+}
+
+// IsLastWord returns true if the word qualifies an anchor event as the last one, such as 'last'.
+func IsLastWord(s string) bool {
+	switch s {
+	case "last", "final", "recent":
+		return true
+	default:
+		return false
+	}
+}
+
+// Temporal defines the time window of a criterion, such as 'within the past 6 months' or
+// 'at least 4 weeks before the first dose'. The limits bound the time between the event
+// of the criterion and the anchor event, so a washout period is a lower limit.
+type Temporal struct {
+	Unit      string    `json:"unit"`                // Time unit of the limits
+	Lower     *Limit    `json:"lower,omitempty"`     // Minimum time between the event and the anchor
+	Upper     *Limit    `json:"upper,omitempty"`     // Maximum time between the event and the anchor
+	Direction Direction `json:"direction,omitempty"` // Direction of the event from the anchor
+	Anchor    Anchor    `json:"anchor,omitempty"`    // Anchor event, or the time of assessment if empty
+}
+
+// IsTimeUnit returns true if the unit is a time unit, which can be converted to days.
+func IsTimeUnit(unit string) bool {
+	if unit == "day" {
+		return true
+	}
+	_, ok := units.Get().Conversion(unit, "day", "")
+	return ok
+}
+
+// Valid returns true if the time window has a time unit and a limit.
+func (t *Temporal) Valid() bool {
+	return IsTimeUnit(t.Unit) && (t.Lower != nil || t.Upper != nil)
+}
+
+// Contains returns true if the elapsed time between the event and the anchor is in the window.
+// The second value is false if the elapsed time cannot be converted to the unit of the window.
+func (t *Temporal) Contains(elapsed float64, unit string) (bool, bool) {
+	if unit != t.Unit {
+		var ok bool
+		if elapsed, ok = units.Get().Convert(elapsed, unit, t.Unit, ""); !ok {
+			return false, false
+		}
+	}
+	if t.Lower != nil {
+		x, err := strconv.ParseFloat(t.Lower.Value, 64)
+		if err != nil {
+			return false, false
+		}
+		if elapsed < x || (elapsed == x && !t.Lower.Incl) {
+			return false, true
+		}
+	}
+	if t.Upper != nil {
+		x, err := strconv.ParseFloat(t.Upper.Value, 64)
+		if err != nil {
+			return false, false
+		}
+		if elapsed > x || (elapsed == x && !t.Upper.Incl) {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// String returns the human readable form of the time window.
+func (t *Temporal) String() string {
+	var s []string
+	if t.Lower != nil {
+		if t.Lower.Incl {
+			s = append(s, "at least "+t.Lower.Value)
+		} else {
+			s = append(s, "more than "+t.Lower.Value)
+		}
+	}
+	if t.Upper != nil {
+		if t.Upper.Incl {
+			s = append(s, "within "+t.Upper.Value)
+		} else {
+			s = append(s, "less than "+t.Upper.Value)
+		}
+	}
+	str := strings.Join(s, " and ") + " " + t.Unit
+	anchor := strings.Replace(string(t.Anchor), "_", " ", -1)
+	switch {
+	case t.Anchor != NoAnchor && t.Direction != NoDirection:
+		str += " " + string(t.Direction) + " " + anchor
+	case t.Anchor != NoAnchor:
+		str += " of " + anchor
+	case t.Direction == After:
+		str += " from now"
+	case t.Direction == Before && t.Upper == nil:
+		str += " ago"
+	}
+	return str
+}
+
+// copy returns a copy of the time window.
+func (t *Temporal) copy() *Temporal {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	c.Lower = copyLimit(t.Lower)
+	c.Upper = copyLimit(t.Upper)
+	return &c
+}
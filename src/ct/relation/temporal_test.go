@@ -0,0 +1,77 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package relation
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTemporalKeywords(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(Before, ParseDirection("prior"))
+	a.Equal(After, ParseDirection("following"))
+	a.Equal(NoDirection, ParseDirection("of"))
+	a.Equal(Randomization, ParseAnchor("randomisation"))
+	a.Equal(FirstDose, ParseAnchor("dose"))
+	a.Equal(NoAnchor, ParseAnchor("visit"))
+	a.Equal(After, ParseDirection("since"))
+	a.Equal(LastDose, ParseLastAnchor("last", "dose"))
+	a.Equal(LastDose, ParseLastAnchor("final", "treatment"))
+	a.Equal(FirstDose, ParseLastAnchor("first", "dose"))
+	a.Equal(Screening, ParseLastAnchor("last", "screening"))
+}
+
+func TestTemporalContains(t *testing.T) {
+	a := assert.New(t)
+
+	washout := &Temporal{Unit: "week", Lower: &Limit{Incl: true, Value: "4"}, Direction: Before, Anchor: FirstDose}
+	a.True(washout.Valid())
+
+	ok, known := washout.Contains(28, "day")
+	a.True(known)
+	a.True(ok)
+
+	ok, known = washout.Contains(20, "day")
+	a.True(known)
+	a.False(ok)
+
+	_, known = washout.Contains(1, "kg")
+	a.False(known)
+
+	window := &Temporal{Unit: "month", Upper: &Limit{Incl: true, Value: "6"}, Direction: Before}
+	ok, _ = window.Contains(1, "year")
+	a.False(ok)
+	ok, _ = window.Contains(90, "day")
+	a.True(ok)
+
+	a.False((&Temporal{Unit: "kg", Upper: &Limit{Incl: true, Value: "6"}}).Valid())
+}
+
+func TestHumanReadableTemporal(t *testing.T) {
+	a := assert.New(t)
+
+	r := &Relation{ID: "400", Name: "a1c", DisplayName: "A1C", Unit: "%", Lower: &Limit{Incl: false, Value: "7"}, VariableType: variables.Numerical,
+		Temporal: &Temporal{Unit: "month", Upper: &Limit{Incl: true, Value: "3"}, Direction: Before, Anchor: Screening}}
+	a.Equal("A1C > 7 % within 3 month before screening", r.HumanReadable())
+
+	temporal := &Temporal{Unit: "month", Lower: &Limit{Incl: false, Value: "6"}, Direction: Before}
+	a.Equal("more than 6 month ago", temporal.String())
+}
+
+func TestSplitTemporal(t *testing.T) {
+	a := assert.New(t)
+
+	r := &Relation{Name: "sbp/dbp", Upper: &Limit{Incl: false, Value: "140/90"}, VariableType: variables.Numerical,
+		Temporal: &Temporal{Unit: "week", Upper: &Limit{Incl: true, Value: "2"}}}
+	rs := r.Split()
+	a.Len(rs, 2)
+	for _, q := range rs {
+		a.Equal(r.Temporal, q.Temporal)
+	}
+	a.False(rs[0].Temporal == rs[1].Temporal)
+}
@@ -0,0 +1,191 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package studies
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// AACT is the input format of the pipe-delimited flat-file export of the AACT database.
+const AACT = "aact"
+
+// AACTFilter defines the filters of the studies read from the AACT flat files.
+// Empty fields match all studies.
+type AACTFilter struct {
+	StudyType         string         // Study type, such as 'Interventional'
+	OverallStatus     string         // Overall status, such as 'Recruiting'
+	Conditions        *regexp.Regexp // Pattern matched to the lowercase conditions joined by '|'
+	ExcludeConditions bool           // Keep the studies whose conditions do not match the pattern
+}
+
+// NewAACTFilter creates a filter of the study type, the overall status, and the pattern of the
+// lowercase conditions. An empty pattern matches all conditions.
+func NewAACTFilter(studyType, overallStatus, conditions string, excludeConditions bool) (AACTFilter, error) {
+	f := AACTFilter{StudyType: studyType, OverallStatus: overallStatus, ExcludeConditions: excludeConditions}
+	if len(conditions) > 0 {
+		re, err := regexp.Compile(conditions)
+		if err != nil {
+			return f, err
+		}
+		f.Conditions = re
+	}
+	return f, nil
+}
+
+// match returns true if the study type and the overall status match the filter.
+func (f AACTFilter) match(studyType, overallStatus string) bool {
+	return (len(f.StudyType) == 0 || f.StudyType == studyType) &&
+		(len(f.OverallStatus) == 0 || f.OverallStatus == overallStatus)
+}
+
+// matchConditions returns true if the conditions match the filter.
+func (f AACTFilter) matchConditions(conditions []string) bool {
+	if f.Conditions == nil {
+		return true
+	}
+	return f.Conditions.MatchString(strings.ToLower(strings.Join(conditions, "|"))) != f.ExcludeConditions
+}
+
+// aactStudy defines the fields of a study that are joined from the AACT tables.
+type aactStudy struct {
+	title      string
+	conditions []string
+	criteria   string
+	calculated bool
+	eligible   bool
+}
+
+// ReadAACT reads the studies from the AACT flat-file directory dir, which contains studies.txt,
+// calculated_values.txt, conditions.txt, and eligibilities.txt. The tables are joined by nct_id
+// like in script/ingest.sh: a study must have a row in every table, and its conditions are sorted
+// by name. The studies are filtered by f and sorted by nct_id in descending order.
+func ReadAACT(dir string, f AACTFilter) (Studies, error) {
+	records := make(map[string]*aactStudy)
+	err := readAACTTable(dir, "studies.txt", []string{"nct_id", "brief_title", "study_type", "overall_status"}, func(v []string) {
+		if f.match(v[2], v[3]) {
+			records[v[0]] = &aactStudy{title: strings.Clone(v[1])}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readAACTTable(dir, "calculated_values.txt", []string{"nct_id"}, func(v []string) {
+		if s, ok := records[v[0]]; ok {
+			s.calculated = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readAACTTable(dir, "conditions.txt", []string{"nct_id", "name"}, func(v []string) {
+		if s, ok := records[v[0]]; ok {
+			s.conditions = append(s.conditions, v[1])
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readAACTTable(dir, "eligibilities.txt", []string{"nct_id", "criteria"}, func(v []string) {
+		if s, ok := records[v[0]]; ok {
+			s.criteria = v[1]
+			s.eligible = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(records))
+	for id, s := range records {
+		if !s.calculated || !s.eligible || len(s.conditions) == 0 {
+			continue
+		}
+		sort.Strings(s.conditions)
+		if f.matchConditions(s.conditions) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+
+	ss := make(Studies, len(ids))
+	for i, id := range ids {
+		s := records[id]
+		ss[i] = NewStudy(id, s.title, s.conditions, s.criteria)
+	}
+	return ss, nil
+}
+
+// OpenWithFilter opens the study records at the path for the input format like Open.
+// AACT flat files are joined and filtered by f in memory. Other formats are not filtered.
+func OpenWithFilter(path, format string, f AACTFilter) (ReadCloser, error) {
+	if format != AACT {
+		return Open(path, format)
+	}
+	registry, err := ReadAACT(path, f)
+	if err != nil {
+		return nil, err
+	}
+	glog.Infof("Read AACT studies: %d\n", registry.Len())
+	return registry.Reader(), nil
+}
+
+// readAACTTable reads the columns of the pipe-delimited table name in dir and calls f
+// for every row with the values of the columns in the given order.
+func readAACTTable(dir, name string, columns []string, f func(values []string)) error {
+	fname := filepath.Join(dir, name)
+	file, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.Comma = '|'
+	r.LazyQuotes = true
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("%s: %v", fname, err)
+	}
+	indices := make([]int, len(columns))
+	for i, c := range columns {
+		indices[i] = -1
+		for j, h := range header {
+			if strings.TrimSpace(h) == c {
+				indices[i] = j
+				break
+			}
+		}
+		if indices[i] < 0 {
+			return fmt.Errorf("%s: missing column: %s", fname, c)
+		}
+	}
+
+	values := make([]string, len(columns))
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", fname, err)
+		}
+		for i, k := range indices {
+			if k >= len(row) {
+				return fmt.Errorf("%s: too few columns: %v", fname, row)
+			}
+			values[i] = row[k]
+		}
+		f(values)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package studies
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeAACTTables(t *testing.T) string {
+	dir := t.TempDir()
+	tables := map[string]string{
+		"studies.txt": `nct_id|nlm_download_date_description|brief_title|study_type|overall_status
+NCT00000001||Covid Study|Interventional|Recruiting
+NCT00000002||Asthma Study|Interventional|Recruiting
+NCT00000003||Observational Study|Observational|Recruiting
+NCT00000004||Completed Study|Interventional|Completed
+NCT00000005||Study without Criteria|Interventional|Recruiting
+`,
+		"calculated_values.txt": `id|nct_id|has_us_facility
+1|NCT00000001|t
+2|NCT00000002|f
+3|NCT00000003|t
+4|NCT00000004|t
+5|NCT00000005|t
+`,
+		"conditions.txt": `id|nct_id|name|downcase_name
+1|NCT00000001|COVID-19|covid-19
+2|NCT00000002|COPD|copd
+3|NCT00000002|Asthma|asthma
+4|NCT00000003|COVID-19|covid-19
+5|NCT00000004|Asthma|asthma
+6|NCT00000005|Asthma|asthma
+`,
+		"eligibilities.txt": `id|nct_id|gender|criteria
+1|NCT00000001|All|"
+        Inclusion Criteria:
+
+          -  Aged 18 to 59.
+"
+2|NCT00000002|All|"
+        Inclusion Criteria:
+
+          -  BMI > 25 kg/m2.
+"
+3|NCT00000003|All|
+4|NCT00000004|All|
+`,
+	}
+	for name, content := range tables {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadAACT(t *testing.T) {
+	a := assert.New(t)
+
+	dir := writeAACTTables(t)
+	filter := AACTFilter{StudyType: "Interventional", OverallStatus: "Recruiting"}
+	ss, err := ReadAACT(dir, filter)
+	a.NoError(err)
+	a.Len(ss, 2)
+	a.Equal("NCT00000002", ss[0].NCT())
+	a.Equal("Asthma Study", ss[0].Name())
+	a.Equal([]string{"Asthma", "COPD"}, ss[0].conditions)
+	inclusions, _ := ss[0].Criteria()
+	a.Equal([]string{"BMI > 25 kg/m2"}, inclusions)
+	a.Equal("NCT00000001", ss[1].NCT())
+
+	filter.Conditions = regexp.MustCompile(`([^a-z]cov[^a-z]|corona[ v]|covid)`)
+	ss, err = ReadAACT(dir, filter)
+	a.NoError(err)
+	a.Len(ss, 1)
+	a.Equal("NCT00000001", ss[0].NCT())
+
+	filter.ExcludeConditions = true
+	ss, err = ReadAACT(dir, filter)
+	a.NoError(err)
+	a.Len(ss, 1)
+	a.Equal("NCT00000002", ss[0].NCT())
+
+	ss, err = ReadAACT(dir, AACTFilter{})
+	a.NoError(err)
+	a.Len(ss, 4)
+
+	_, err = ReadAACT(t.TempDir(), filter)
+	a.Error(err)
+}
+
+func TestOpenWithFilter(t *testing.T) {
+	a := assert.New(t)
+
+	dir := writeAACTTables(t)
+	filter, err := NewAACTFilter("Interventional", "Recruiting", "covid", true)
+	a.NoError(err)
+	r, err := OpenWithFilter(dir, AACT, filter)
+	a.NoError(err)
+	ss, err := ReadAll(r)
+	a.NoError(err)
+	a.NoError(r.Close())
+	a.Len(ss, 1)
+	a.Equal("NCT00000002", ss[0].NCT())
+
+	_, err = NewAACTFilter("", "", "covid(", false)
+	a.Error(err)
+	_, err = OpenWithFilter(dir, "pdf", filter)
+	a.Error(err)
+}
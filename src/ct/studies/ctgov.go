@@ -0,0 +1,179 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package studies
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	reMarkdownEscape = regexp.MustCompile(`\\([[:punct:]])`)
+	reMarkdownBullet = regexp.MustCompile(`^\s*([*+-]|\d+\.)\s+`)
+)
+
+// ctgovStudy defines the fields of a ClinicalTrials.gov JSON study record that are ingested.
+type ctgovStudy struct {
+	ProtocolSection struct {
+		IdentificationModule struct {
+			NCTID      string `json:"nctId"`
+			BriefTitle string `json:"briefTitle"`
+		} `json:"identificationModule"`
+		ConditionsModule struct {
+			Conditions []string `json:"conditions"`
+		} `json:"conditionsModule"`
+		EligibilityModule struct {
+			EligibilityCriteria string `json:"eligibilityCriteria"`
+		} `json:"eligibilityModule"`
+	} `json:"protocolSection"`
+}
+
+// ctgovPage defines a JSON value that is either a study record or
+// a page of study records from the ClinicalTrials.gov API.
+type ctgovPage struct {
+	ctgovStudy
+	Studies []ctgovStudy `json:"studies"`
+}
+
+// study converts the JSON record to a study.
+func (c *ctgovStudy) study() (*Study, error) {
+	p := c.ProtocolSection
+	if len(p.IdentificationModule.NCTID) == 0 {
+		return nil, fmt.Errorf("study record has no nctId")
+	}
+	criteria := normalizeMarkdown(p.EligibilityModule.EligibilityCriteria)
+	return NewStudy(p.IdentificationModule.NCTID, p.IdentificationModule.BriefTitle, p.ConditionsModule.Conditions, criteria), nil
+}
+
+// normalizeMarkdown converts the markdown eligibility criteria of the modern API to the layout
+// of the legacy text blocks: escaped characters are unescaped, and bullets are separated by
+// empty lines, so that the criteria can be split like the legacy criteria.
+func normalizeMarkdown(s string) string {
+	s = reMarkdownEscape.ReplaceAllString(s, "$1")
+	lines := strings.Split(s, "\n")
+	normalized := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if reMarkdownBullet.MatchString(line) {
+			if n := len(normalized); n > 0 && len(strings.TrimSpace(normalized[n-1])) > 0 {
+				normalized = append(normalized, "")
+			}
+			if m := strings.TrimSpace(line); m[0] == '*' || m[0] == '+' {
+				line = strings.Replace(line, m[:1], "-", 1)
+			}
+		}
+		normalized = append(normalized, line)
+	}
+	return strings.Join(normalized, "\n")
+}
+
+// jsonReader reads studies from ClinicalTrials.gov JSON. The input is a sequence of
+// study records or API pages with a 'studies' array, or a JSON array of study records.
+type jsonReader struct {
+	r       *bufio.Reader
+	dec     *json.Decoder
+	inArray bool
+	queue   []ctgovStudy
+}
+
+// NewJSONReader creates a new study reader from the ClinicalTrials.gov JSON r.
+func NewJSONReader(r io.Reader) Reader {
+	return &jsonReader{r: bufio.NewReader(r)}
+}
+
+// Read reads the next study.
+func (r *jsonReader) Read() (*Study, error) {
+	if r.dec == nil {
+		if err := r.start(); err != nil {
+			return nil, err
+		}
+	}
+	for len(r.queue) == 0 {
+		if r.inArray && !r.dec.More() {
+			if _, err := r.dec.Token(); err != nil {
+				return nil, err
+			}
+			r.inArray = false
+			continue
+		}
+		var page ctgovPage
+		if err := r.dec.Decode(&page); err != nil {
+			return nil, err
+		}
+		if page.Studies != nil {
+			r.queue = page.Studies
+		} else {
+			r.queue = []ctgovStudy{page.ctgovStudy}
+		}
+	}
+	c := r.queue[0]
+	r.queue = r.queue[1:]
+	return c.study()
+}
+
+// start creates the decoder and enters the top-level array if the input is an array.
+func (r *jsonReader) start() error {
+	r.dec = json.NewDecoder(r.r)
+	for {
+		b, err := r.r.Peek(1)
+		if err != nil {
+			return err
+		}
+		switch b[0] {
+		case ' ', '\t', '\n', '\r':
+			r.r.ReadByte()
+			continue
+		case '[':
+			if _, err := r.dec.Token(); err != nil {
+				return err
+			}
+			r.inArray = true
+		}
+		return nil
+	}
+}
+
+// ctgovClinicalStudy defines the fields of a ClinicalTrials.gov legacy XML study record that are ingested.
+type ctgovClinicalStudy struct {
+	NCTID      string   `xml:"id_info>nct_id"`
+	BriefTitle string   `xml:"brief_title"`
+	Conditions []string `xml:"condition"`
+	Criteria   string   `xml:"eligibility>criteria>textblock"`
+}
+
+// xmlReader reads studies from ClinicalTrials.gov legacy XML. Every 'clinical_study'
+// element of the input is a study record.
+type xmlReader struct {
+	dec *xml.Decoder
+}
+
+// NewXMLReader creates a new study reader from the ClinicalTrials.gov legacy XML r.
+func NewXMLReader(r io.Reader) Reader {
+	return &xmlReader{dec: xml.NewDecoder(r)}
+}
+
+// Read reads the next study.
+func (r *xmlReader) Read() (*Study, error) {
+	for {
+		t, err := r.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		e, ok := t.(xml.StartElement)
+		if !ok || e.Name.Local != "clinical_study" {
+			continue
+		}
+		var c ctgovClinicalStudy
+		if err := r.dec.DecodeElement(&c, &e); err != nil {
+			return nil, err
+		}
+		if len(c.NCTID) == 0 {
+			return nil, fmt.Errorf("study record has no nct_id")
+		}
+		return NewStudy(c.NCTID, c.BriefTitle, c.Conditions, c.Criteria), nil
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package studies
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const ctgovJSON = `{
+  "protocolSection": {
+    "identificationModule": {"nctId": "NCT00000001", "briefTitle": "First Study"},
+    "conditionsModule": {"conditions": ["Asthma", "COPD"]},
+    "eligibilityModule": {"eligibilityCriteria": "Inclusion Criteria:\n\n* Aged 18 to 59.\n* BMI \\>= 25 kg/m2.\n\nExclusion Criteria:\n\n* ECOG 3-4."}
+  }
+}`
+
+const ctgovXML = `<?xml version="1.0" encoding="UTF-8"?>
+<clinical_study rank="1">
+  <id_info>
+    <nct_id>NCT00000002</nct_id>
+  </id_info>
+  <brief_title>Second Study</brief_title>
+  <condition>Obesity</condition>
+  <eligibility>
+    <criteria>
+      <textblock>
+        Inclusion Criteria:
+
+          -  BMI &gt; 40 kg/m2.
+      </textblock>
+    </criteria>
+  </eligibility>
+</clinical_study>`
+
+func TestJSONReader(t *testing.T) {
+	a := assert.New(t)
+
+	ss, err := ReadAll(NewJSONReader(strings.NewReader(ctgovJSON)))
+	a.NoError(err)
+	a.Len(ss, 1)
+	s := ss[0]
+	a.Equal("NCT00000001", s.NCT())
+	a.Equal("First Study", s.Name())
+	a.Equal([]string{"Asthma", "COPD"}, s.conditions)
+	inclusions, exclusions := s.Criteria()
+	a.Equal([]string{"Aged 18 to 59", "BMI >= 25 kg/m2"}, inclusions)
+	a.Equal([]string{"ECOG 3-4"}, exclusions)
+
+	page := `{"studies": [` + ctgovJSON + `,` + ctgovJSON + `], "nextPageToken": "abc"}`
+	ss, err = ReadAll(NewJSONReader(strings.NewReader(page + "\n" + ctgovJSON)))
+	a.NoError(err)
+	a.Len(ss, 3)
+
+	ss, err = ReadAll(NewJSONReader(strings.NewReader("\n[" + ctgovJSON + "," + ctgovJSON + "]")))
+	a.NoError(err)
+	a.Len(ss, 2)
+
+	_, err = ReadAll(NewJSONReader(strings.NewReader(`{"protocolSection": {}}`)))
+	a.Error(err)
+}
+
+func TestXMLReader(t *testing.T) {
+	a := assert.New(t)
+
+	ss, err := ReadAll(NewXMLReader(strings.NewReader(ctgovXML)))
+	a.NoError(err)
+	a.Len(ss, 1)
+	s := ss[0]
+	a.Equal("NCT00000002", s.NCT())
+	a.Equal("Second Study", s.Name())
+	a.Equal([]string{"Obesity"}, s.conditions)
+	inclusions, _ := s.Criteria()
+	a.Equal([]string{"BMI > 40 kg/m2"}, inclusions)
+}
+
+func TestOpen(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	a.NoError(os.MkdirAll(filepath.Join(dir, "NCT0000xxxx"), 0755))
+	a.NoError(os.WriteFile(filepath.Join(dir, "NCT0000xxxx", "NCT00000001.json"), []byte(ctgovJSON), 0644))
+	a.NoError(os.WriteFile(filepath.Join(dir, "NCT0000xxxx", "NCT00000002.xml"), []byte(ctgovXML), 0644))
+	a.NoError(os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a study"), 0644))
+
+	r, err := Open(dir, JSON)
+	a.NoError(err)
+	ss, err := ReadAll(r)
+	a.NoError(err)
+	a.NoError(r.Close())
+	a.Len(ss, 1)
+	a.Equal("NCT00000001", ss[0].NCT())
+
+	zipFname := filepath.Join(dir, "studies.zip")
+	f, err := os.Create(zipFname)
+	a.NoError(err)
+	w := zip.NewWriter(f)
+	for _, name := range []string{"b/NCT00000002.xml", "a/NCT00000002.xml", "a/NCT00000001.json"} {
+		e, err := w.Create(name)
+		a.NoError(err)
+		_, err = e.Write([]byte(ctgovXML))
+		a.NoError(err)
+	}
+	a.NoError(w.Close())
+	a.NoError(f.Close())
+
+	r, err = Open(zipFname, XML)
+	a.NoError(err)
+	ss, err = ReadAll(r)
+	a.NoError(err)
+	a.NoError(r.Close())
+	a.Len(ss, 2)
+
+	_, err = Open(dir, "yaml")
+	a.Error(err)
+	_, err = Open(filepath.Join(dir, "missing.json"), JSON)
+	a.Error(err)
+}
@@ -0,0 +1,158 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package studies
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ReadCloser reads studies from files that are closed by Close.
+type ReadCloser interface {
+	Reader
+	io.Closer
+}
+
+// fileEntry defines a file of study records in a directory or a zip archive.
+type fileEntry struct {
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+// filesReader reads studies from a sequence of files, one file at a time.
+type filesReader struct {
+	format  string
+	entries []fileEntry
+	name    string // Name of the open file
+	reader  Reader
+	file    io.ReadCloser
+	archive io.Closer
+}
+
+// Open opens the study records at the path for the input format. The path is a file,
+// a directory, or a zip archive. The files of a directory, including subdirectories,
+// and of a zip archive are read in the lexical order of their names. Only files with
+// the extension of the format, such as '.json', are read, so bulk snapshots can be
+// read without unpacking them.
+func Open(path, format string) (ReadCloser, error) {
+	if _, err := NewReader(strings.NewReader(""), format); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &filesReader{format: format}
+	switch {
+	case info.IsDir():
+		err = r.addDir(path)
+	case strings.EqualFold(filepath.Ext(path), ".zip"):
+		err = r.addZip(path)
+	default:
+		r.entries = []fileEntry{{name: path, open: func() (io.ReadCloser, error) { return os.Open(path) }}}
+	}
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// hasFormat returns true if the file name has the extension of the input format.
+func (r *filesReader) hasFormat(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), "."+r.format)
+}
+
+// addDir adds the files of the directory.
+func (r *filesReader) addDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !r.hasFormat(path) {
+			return nil
+		}
+		r.entries = append(r.entries, fileEntry{name: path, open: func() (io.ReadCloser, error) { return os.Open(path) }})
+		return nil
+	})
+}
+
+// addZip adds the files of the zip archive.
+func (r *filesReader) addZip(path string) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	r.archive = archive
+	files := make([]*zip.File, 0, len(archive.File))
+	for _, f := range archive.File {
+		if !f.FileInfo().IsDir() && r.hasFormat(f.Name) {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	for _, f := range files {
+		r.entries = append(r.entries, fileEntry{name: path + ":" + f.Name, open: f.Open})
+	}
+	return nil
+}
+
+// Read reads the next study.
+func (r *filesReader) Read() (*Study, error) {
+	for {
+		if r.reader == nil {
+			if len(r.entries) == 0 {
+				return nil, io.EOF
+			}
+			if err := r.next(); err != nil {
+				return nil, err
+			}
+		}
+		s, err := r.reader.Read()
+		if err == io.EOF {
+			r.file.Close()
+			r.reader, r.file = nil, nil
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", r.name, err)
+		}
+		return s, nil
+	}
+}
+
+// next opens the next file.
+func (r *filesReader) next() error {
+	e := r.entries[0]
+	r.entries = r.entries[1:]
+	f, err := e.open()
+	if err != nil {
+		return err
+	}
+	reader, err := NewReader(f, r.format)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.name, r.reader, r.file = e.name, reader, f
+	return nil
+}
+
+// Close closes the open file and the archive.
+func (r *filesReader) Close() error {
+	if r.file != nil {
+		r.file.Close()
+		r.reader, r.file = nil, nil
+	}
+	r.entries = nil
+	if r.archive != nil {
+		return r.archive.Close()
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package studies
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
+)
+
+// Input formats of study records.
+const (
+	CSV  = "csv"  // Five-column csv file from script/ingest.sh
+	JSON = "json" // ClinicalTrials.gov per-study JSON of the modern API
+	XML  = "xml"  // ClinicalTrials.gov legacy per-study XML
+)
+
+// Reader reads studies one at a time.
+type Reader interface {
+	// Read reads the next study. It returns io.EOF when there are no more studies.
+	Read() (*Study, error)
+}
+
+// NewReader creates a new study reader from r for the input format.
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case CSV:
+		return NewCSVReader(r), nil
+	case JSON:
+		return NewJSONReader(r), nil
+	case XML:
+		return NewXMLReader(r), nil
+	default:
+		return nil, fmt.Errorf("unknown input format: %q", format)
+	}
+}
+
+// ReadAll reads all remaining studies from r.
+func ReadAll(r Reader) (Studies, error) {
+	ss := New()
+	for {
+		s, err := r.Read()
+		if err == io.EOF {
+			return ss, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ss.Add(s)
+	}
+}
+
+// csvReader reads studies from a csv file with the columns
+// nct_id, title, has_us_facility, conditions, and eligibility_criteria.
+type csvReader struct {
+	r *csv.Reader
+}
+
+// NewCSVReader creates a new study reader from the csv file r.
+func NewCSVReader(r io.Reader) Reader {
+	cr := csv.NewReader(r)
+	cr.Comment = rune(param.Comment)
+	cr.ReuseRecord = true
+	return &csvReader{r: cr}
+}
+
+// Read reads the next study.
+func (r *csvReader) Read() (*Study, error) {
+	line, err := r.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 5 {
+		return nil, fmt.Errorf("too few columns, at least 5 needed: %v", line)
+	}
+	nctID := line[0]
+	title := line[1]
+	// Skip line[2]: has_us_facility
+	conditions := strings.Split(line[3], param.FieldSep)
+	eligibilityCriteria := line[4]
+
+	return NewStudy(nctID, title, conditions, eligibilityCriteria), nil
+}
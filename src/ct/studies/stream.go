@@ -0,0 +1,84 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package studies
+
+import (
+	"io"
+	"sync"
+)
+
+// streamJob defines a study in the stream with the result of processing it.
+type streamJob struct {
+	study  *Study
+	result interface{}
+	err    error
+	done   chan struct{}
+}
+
+// Stream reads studies from r, processes them with the number of workers, and writes
+// them in the input order. The study and the result of process are passed to write
+// and then discarded, so only about 2*workers studies are held in memory at a time.
+// process is called concurrently, write sequentially. Stream returns the first read
+// or write error.
+func Stream(r Reader, workers int, process func(s *Study) interface{}, write func(s *Study, result interface{}) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan *streamJob)
+	pending := make(chan *streamJob, 2*workers)
+	quit := make(chan struct{})
+
+	wait := &sync.WaitGroup{}
+	defer wait.Wait()
+	defer close(quit)
+
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		for {
+			s, err := r.Read()
+			if err == io.EOF {
+				return
+			}
+			j := &streamJob{study: s, err: err, done: make(chan struct{})}
+			if err != nil {
+				close(j.done)
+			}
+			select {
+			case pending <- j:
+			case <-quit:
+				return
+			}
+			if err != nil {
+				return
+			}
+			select {
+			case jobs <- j:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	wait.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wait.Done()
+			for j := range jobs {
+				j.result = process(j.study)
+				close(j.done)
+			}
+		}()
+	}
+
+	for j := range pending {
+		<-j.done
+		if j.err != nil {
+			return j.err
+		}
+		if err := write(j.study, j.result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
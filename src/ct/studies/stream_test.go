@@ -0,0 +1,86 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package studies
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReader(t *testing.T) {
+	a := assert.New(t)
+
+	input := `#nct_id,title,has_us_facility,conditions,eligibility_criteria
+NCT00000001,First Study,true,Asthma|COPD,"Inclusion Criteria:
+
+            Aged 18 to 59."
+NCT00000002,Second Study,false,Obesity,"Exclusion Criteria:
+
+            BMI > 40 kg/m2."
+`
+	ss, err := ReadAll(NewCSVReader(strings.NewReader(input)))
+	a.NoError(err)
+	a.Len(ss, 2)
+	a.Equal("NCT00000001", ss[0].NCT())
+	a.Equal("First Study", ss[0].Name())
+	a.Equal([]string{"Asthma", "COPD"}, ss[0].conditions)
+	a.Equal("NCT00000002", ss[1].NCT())
+
+	_, err = ReadAll(NewCSVReader(strings.NewReader("NCT00000001,First Study,true\n")))
+	a.Error(err)
+}
+
+func TestStream(t *testing.T) {
+	a := assert.New(t)
+
+	var b strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, "NCT%08d,Study %d,false,Asthma,\"Inclusion Criteria:\n\n            BMI > %d kg/m2.\"\n", i, i, 20+i%10)
+	}
+	input := b.String()
+
+	sequential, err := ReadAll(NewCSVReader(strings.NewReader(input)))
+	a.NoError(err)
+	sequential.Parse()
+	var expected strings.Builder
+	for _, s := range sequential {
+		a.NoError(s.WriteRelations(&expected))
+	}
+
+	var actual strings.Builder
+	parse := func(s *Study) interface{} {
+		s.Parse()
+		return s.NCT()
+	}
+	write := func(s *Study, result interface{}) error {
+		a.Equal(s.NCT(), result)
+		return s.WriteRelations(&actual)
+	}
+	a.NoError(Stream(NewCSVReader(strings.NewReader(input)), 8, parse, write))
+	a.NotEmpty(actual.String())
+	a.Equal(expected.String(), actual.String())
+
+	// Writing stops at the first error.
+	cnt := 0
+	write = func(s *Study, _ interface{}) error {
+		cnt++
+		if cnt == 10 {
+			return fmt.Errorf("write failed")
+		}
+		return nil
+	}
+	a.EqualError(Stream(NewCSVReader(strings.NewReader(input)), 4, parse, write), "write failed")
+	a.Equal(10, cnt)
+
+	// Read errors are returned after the preceding studies are written.
+	cnt = 0
+	write = func(s *Study, _ interface{}) error {
+		cnt++
+		return nil
+	}
+	a.Error(Stream(NewCSVReader(strings.NewReader(input+"NCT99999999,Short\n")), 4, parse, write))
+	a.Equal(100, cnt)
+}
@@ -2,6 +2,10 @@
 
 package studies
 
+import (
+	"io"
+)
+
 // Studies defines a collection of clinical study records.
 type Studies []*Study
 
@@ -26,3 +30,29 @@ func (ss Studies) Parse() {
 		s.Parse()
 	}
 }
+
+// Reader returns a study reader that reads the studies ss.
+func (ss Studies) Reader() ReadCloser {
+	return &studiesReader{ss: ss}
+}
+
+// studiesReader reads studies from a slice.
+type studiesReader struct {
+	ss Studies
+}
+
+// Read reads the next study.
+func (r *studiesReader) Read() (*Study, error) {
+	if len(r.ss) == 0 {
+		return nil, io.EOF
+	}
+	s := r.ss[0]
+	r.ss = r.ss[1:]
+	return s, nil
+}
+
+// Close releases the remaining studies.
+func (r *studiesReader) Close() error {
+	r.ss = nil
+	return nil
+}
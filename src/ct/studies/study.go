@@ -3,11 +3,14 @@
 package studies
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/slice"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/text"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/criteria"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/parser"
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
@@ -41,6 +44,11 @@ func (s *Study) Name() string {
 	return s.name
 }
 
+// Conditions returns the conditions of the study.
+func (s *Study) Conditions() []string {
+	return s.conditions
+}
+
 // InclusionCriteria returns the inclusion criteria for the study.
 func (s *Study) InclusionCriteria() criteria.Criteria {
 	return s.inclusionCriteria
@@ -60,56 +68,91 @@ func (s *Study) Parse() *Study {
 
 	// Parse inclusion criteria:
 	inclusionCriteria := criteria.NewCriteria()
-	for _, inclusion := range inclusions {
-		lowercase := strings.ToLower(inclusion)
-		orRelations, andRelations := interpreter.Interpret(lowercase)
-
-		orRelations.Process()
-		andRelations.Process()
-
-		if !orRelations.Empty() {
-			criterion := criteria.NewCriterion(inclusion, orRelations.MinScore(), orRelations)
-			inclusionCriteria = append(inclusionCriteria, criterion)
-		}
-		if !andRelations.Empty() {
-			for _, r := range andRelations {
-				rs := relation.Relations{r}
-				criterion := criteria.NewCriterion(inclusion, rs.MinScore(), rs)
-				inclusionCriteria = append(inclusionCriteria, criterion)
-			}
-		}
+	cursor := 0
+	for i, inclusion := range inclusions {
+		offsets := s.align(inclusion, &cursor)
+		inclusionCriteria = append(inclusionCriteria, parseCriterion(interpreter, inclusion, i, offsets, false)...)
 	}
 	s.inclusionCriteria = inclusionCriteria
 
-	// Parse exclusion criteria:
+	// Parse exclusion criteria. They follow the inclusion criteria in the text, so an exclusion
+	// that repeats the text of an inclusion is aligned after it.
 	exclusionCriteria := criteria.NewCriteria()
-	for _, exclusion := range exclusions {
-		lowercase := strings.ToLower(exclusion)
-		orRelations, andRelations := interpreter.Interpret(lowercase)
-		orRelations.Process()
-		andRelations.Process()
-		orRelations.Negate()
-		andRelations.Negate()
+	for i, exclusion := range exclusions {
+		offsets := s.align(exclusion, &cursor)
+		exclusionCriteria = append(exclusionCriteria, parseCriterion(interpreter, exclusion, len(inclusions)+i, offsets, true)...)
+	}
+	s.exclusionCriteria = exclusionCriteria
 
-		if !andRelations.Empty() {
-			criterion := criteria.NewCriterion(exclusion, andRelations.MinScore(), andRelations)
-			exclusionCriteria = append(exclusionCriteria, criterion)
-		}
-		if !orRelations.Empty() {
-			for _, r := range orRelations {
-				rs := relation.Relations{r}
-				criterion := criteria.NewCriterion(exclusion, rs.MinScore(), rs)
-				exclusionCriteria = append(exclusionCriteria, criterion)
-			}
+	// Number the criteria in the order of Relations, inclusions first.
+	cid := 0
+	for _, cs := range []criteria.Criteria{inclusionCriteria, exclusionCriteria} {
+		for _, c := range cs {
+			c.CID = cid
+			cid++
 		}
 	}
-
-	s.exclusionCriteria = exclusionCriteria
 	s.Transform()
 
 	return s
 }
 
+// ParseCriterion parses a single inclusion or exclusion criterion to relations. The relations
+// of an exclusion criterion are negated. Relations conjoined by 'or' are grouped in the same
+// criterion as in Parse. The text spans of the relations are not set, and the source of the
+// criteria is 0. The criterion ids count the returned criteria.
+func ParseCriterion(criterion string, exclusion bool) criteria.Criteria {
+	cs := parseCriterion(parser.Get(), criterion, 0, nil, exclusion)
+	cs.Relations().Transform()
+	return cs
+}
+
+// parseCriterion parses the criterion to relations. The source is the index of the criterion
+// in the study, and the offsets map the criterion to the eligibility criteria text.
+func parseCriterion(interpreter *parser.Interpreter, criterion string, source int, offsets []int, exclusion bool) criteria.Criteria {
+	lowercase := text.ToLowerSameWidth(criterion)
+	orRelations, andRelations := interpreter.Interpret(lowercase)
+	orRelations.SetTextSpan(offsets)
+	andRelations.SetTextSpan(offsets)
+	orRelations.Process()
+	andRelations.Process()
+
+	// Negation turns the 'and' relations of an exclusion criterion to alternatives and
+	// the 'or' relations to separate criteria.
+	alternatives, conjuncts := orRelations, andRelations
+	if exclusion {
+		orRelations.Negate()
+		andRelations.Negate()
+		alternatives, conjuncts = andRelations, orRelations
+	}
+
+	cs := criteria.NewCriteria()
+	if !alternatives.Empty() {
+		cs = append(cs, criteria.NewCriterion(criterion, alternatives.MinScore(), alternatives))
+	}
+	for _, r := range conjuncts {
+		rs := relation.Relations{r}
+		cs = append(cs, criteria.NewCriterion(criterion, rs.MinScore(), rs))
+	}
+	for i, c := range cs {
+		c.CID = i
+		c.Source = source
+	}
+	return cs
+}
+
+// align aligns the criterion with the eligibility criteria text. The search starts from
+// the cursor, which is advanced past the criterion if it is found. The search does not
+// restart before the cursor, where an earlier identical criterion would be found, so
+// the offsets are nil if the criterion is not found after the cursor.
+func (s *Study) align(criterion string, cursor *int) []int {
+	offsets := criteria.Align(s.eligibilityCriteria, criterion, *cursor)
+	if offsets != nil {
+		*cursor = offsets[len(offsets)-1]
+	}
+	return offsets
+}
+
 // Criteria extracts inclusion and exclusion criteria from the eligibility criteria string.
 func (s *Study) Criteria() ([]string, []string) {
 	eligibilityCriteria := criteria.Normalize(s.eligibilityCriteria)
@@ -150,28 +193,77 @@ func (s *Study) Transform() {
 
 // Relations returns the string representation of the parsed criteria.
 // Relations that are parsed from the same criterion and are conjoined
-// by 'or' have the same criterion id (cid).
+// by 'or' have the same criterion id (cid). The criteria split from one
+// criterion text have consecutive cids.
 func (s *Study) Relations() string {
+	var b strings.Builder
+	s.WriteRelations(&b)
+	return b.String()
+}
+
+// WriteRelations writes the parsed criteria to w, one relation per line,
+// in the format of Relations.
+func (s *Study) WriteRelations(w io.Writer) error {
 	variableCatalog := variables.Get()
-	relations := ""
-	cid := 0
 	for _, c := range s.inclusionCriteria {
 		for _, r := range c.Relations() {
 			q := variableCatalog.Question(r.ID)
-			relations += fmt.Sprintf("%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
-				s.nct, "inclusion", r.VariableType.String(), cid, c.String(), q, r.JSON())
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				s.nct, "inclusion", r.VariableType.String(), c.CID, c.String(), q, r.JSON()); err != nil {
+				return err
+			}
 		}
-		cid++
 	}
 	for _, c := range s.exclusionCriteria {
 		for _, r := range c.Relations() {
 			q := variableCatalog.Question(r.ID)
-			relations += fmt.Sprintf("%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
-				s.nct, "exclusion", r.VariableType.String(), cid, c.String(), q, r.JSON())
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				s.nct, "exclusion", r.VariableType.String(), c.CID, c.String(), q, r.JSON()); err != nil {
+				return err
+			}
 		}
-		cid++
 	}
-	return relations
+	return nil
+}
+
+// studyJSON defines the JSON representation of a parsed study.
+type studyJSON struct {
+	NCT        string            `json:"nct_id"`
+	Title      string            `json:"title"`
+	Conditions []string          `json:"conditions"`
+	Inclusions criteria.Criteria `json:"inclusion"`
+	Exclusions criteria.Criteria `json:"exclusion"`
+}
+
+// JSON returns the JSON representation of the study with the parsed criteria. Relations that
+// are parsed from the same criterion and are conjoined by 'or' are grouped in the same criterion.
+// Each criterion has the cid of its relations in Relations and the source index of its text,
+// so the criteria parsed from one text by splitting at 'and' have the same source.
+func (s *Study) JSON() string {
+	var b strings.Builder
+	s.WriteJSON(&b)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// WriteJSON writes the JSON representation of the study to w as a single line.
+func (s *Study) WriteJSON(w io.Writer) error {
+	v := studyJSON{
+		NCT:        s.nct,
+		Title:      s.name,
+		Conditions: s.conditions,
+		Inclusions: s.inclusionCriteria,
+		Exclusions: s.exclusionCriteria,
+	}
+	if v.Conditions == nil {
+		v.Conditions = []string{}
+	}
+	if v.Inclusions == nil {
+		v.Inclusions = criteria.NewCriteria()
+	}
+	if v.Exclusions == nil {
+		v.Exclusions = criteria.NewCriteria()
+	}
+	return json.NewEncoder(w).Encode(v)
 }
 
 // CriteriaCount returns the number of criteria.
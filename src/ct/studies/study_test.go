@@ -3,6 +3,8 @@
 package studies
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/ct/relation"
@@ -50,6 +52,7 @@ func TestAndCriteriaParse(t *testing.T) {
 	for i, criterion := range actualInclusionCriteria {
 		actualInclusions := criterion.Relations()
 		actualInclusions.SetScore(0)
+		actualInclusions.ClearSpans()
 		a.Equal(expectedInclusions[i], actualInclusions)
 	}
 
@@ -57,6 +60,7 @@ func TestAndCriteriaParse(t *testing.T) {
 	a.Len(actualExclusionCriteria, 1)
 	actualExclusions := actualExclusionCriteria.Relations()
 	actualExclusions.SetScore(0)
+	actualExclusions.ClearSpans()
 	a.Equal(expectedExclusions, actualExclusions)
 }
 
@@ -102,6 +106,7 @@ func TestOrCriteriaParse(t *testing.T) {
 	for i, criterion := range actualInclusionCriteria {
 		actualInclusions := criterion.Relations()
 		actualInclusions.SetScore(0)
+		actualInclusions.ClearSpans()
 		a.Equal(expectedInclusions[i], actualInclusions)
 	}
 
@@ -109,6 +114,7 @@ func TestOrCriteriaParse(t *testing.T) {
 	a.Len(actualExclusionCriteria, 1)
 	actualExclusions := actualExclusionCriteria[0].Relations()
 	actualExclusions.SetScore(0)
+	actualExclusions.ClearSpans()
 	a.Equal(expectedExclusions, actualExclusions)
 }
 
@@ -153,6 +159,7 @@ func TestOrdinalNegationCriteriaParse(t *testing.T) {
 	for i, criterion := range actualInclusionCriteria {
 		actualInclusions := criterion.Relations()
 		actualInclusions.SetScore(0)
+		actualInclusions.ClearSpans()
 		a.Equal(expectedInclusions[i], actualInclusions)
 	}
 
@@ -160,5 +167,142 @@ func TestOrdinalNegationCriteriaParse(t *testing.T) {
 	a.Len(actualExclusionCriteria, 1)
 	actualExclusions := actualExclusionCriteria[0].Relations()
 	actualExclusions.SetScore(0)
+	actualExclusions.ClearSpans()
 	a.Equal(expectedExclusions, actualExclusions)
 }
+
+func TestTextSpanParse(t *testing.T) {
+	a := assert.New(t)
+
+	input := `Inclusion Criteria:
+
+            Uncontrolled hypertension, defined as
+            Blood Pressure (bp) between 200 and 300 mm/Hg.
+
+            Exclusion Criteria:
+
+            Weigh more than 180 pounds.`
+
+	study := NewStudy("ID012345", "Better Health for Everybody", nil, input)
+	study.Parse()
+
+	inclusions := study.InclusionCriteria().Relations()
+	a.Len(inclusions, 2)
+	for _, r := range inclusions {
+		a.Equal("Blood Pressure (bp) between 200 and 300", input[r.TextSpan.Begin:r.TextSpan.End])
+	}
+
+	exclusions := study.ExclusionCriteria().Relations()
+	a.Len(exclusions, 1)
+	r := exclusions[0]
+	a.Equal("Weigh more than 180 pounds", input[r.TextSpan.Begin:r.TextSpan.End])
+}
+
+func TestRepeatedTextSpanParse(t *testing.T) {
+	a := assert.New(t)
+
+	input := `Inclusion Criteria:
+
+            Weigh more than 180 pounds.
+
+            Exclusion Criteria:
+
+            Weigh more than 180 pounds.`
+
+	study := NewStudy("ID012345", "Better Health for Everybody", nil, input)
+	study.Parse()
+
+	inclusions := study.InclusionCriteria().Relations()
+	a.Len(inclusions, 1)
+	exclusions := study.ExclusionCriteria().Relations()
+	a.Len(exclusions, 1)
+	a.Equal(input[inclusions[0].TextSpan.Begin:inclusions[0].TextSpan.End], input[exclusions[0].TextSpan.Begin:exclusions[0].TextSpan.End])
+	a.Less(inclusions[0].TextSpan.End, strings.Index(input, "Exclusion"))
+	a.Greater(exclusions[0].TextSpan.Begin, strings.Index(input, "Exclusion"))
+}
+
+func TestAlign(t *testing.T) {
+	a := assert.New(t)
+
+	input := "BMI < 30. Weigh more than 180 pounds. BMI < 30."
+	study := NewStudy("ID012345", "Better Health for Everybody", nil, input)
+
+	cursor := 0
+	offsets := study.align("BMI < 30", &cursor)
+	a.Equal(0, offsets[0])
+	offsets = study.align("BMI < 30", &cursor)
+	a.Equal(strings.LastIndex(input, "BMI"), offsets[0])
+	end := cursor
+
+	a.Nil(study.align("Weigh more than 180 pounds", &cursor))
+	a.Equal(end, cursor)
+}
+
+func TestStudyJSON(t *testing.T) {
+	a := assert.New(t)
+
+	input := `Inclusion Criteria:
+
+            Pre-diabetes: HbA1c >5.7% or BMI ≥ 25 kg/m2.
+
+            Informed consent.
+
+            Exclusion Criteria:
+
+            Weigh more than 180 pounds.
+
+            BMI > 40 or HbA1c > 10%.`
+
+	study := NewStudy("ID012345", "Better Health for Everybody", []string{"Obesity"}, input)
+	study.Parse()
+
+	var actual struct {
+		NCT        string   `json:"nct_id"`
+		Title      string   `json:"title"`
+		Conditions []string `json:"conditions"`
+		Inclusions []struct {
+			CID       int                `json:"cid"`
+			Source    int                `json:"source"`
+			Text      string             `json:"text"`
+			Score     float64            `json:"score"`
+			Relations relation.Relations `json:"relations"`
+		} `json:"inclusion"`
+		Exclusions []struct {
+			CID       int                `json:"cid"`
+			Source    int                `json:"source"`
+			Text      string             `json:"text"`
+			Relations relation.Relations `json:"relations"`
+		} `json:"exclusion"`
+	}
+	s := study.JSON()
+	a.NotContains(s, "\n")
+	a.NoError(json.Unmarshal([]byte(s), &actual))
+	a.Equal("ID012345", actual.NCT)
+	a.Equal("Better Health for Everybody", actual.Title)
+	a.Equal([]string{"Obesity"}, actual.Conditions)
+
+	a.Len(actual.Inclusions, 1)
+	a.Equal("Pre-diabetes: HbA1c >5.7% or BMI ≥ 25 kg/m2", actual.Inclusions[0].Text)
+	a.Len(actual.Inclusions[0].Relations, 2)
+	a.Equal(study.InclusionCriteria()[0].Score(), actual.Inclusions[0].Score)
+
+	a.Equal(0, actual.Inclusions[0].CID)
+	a.Equal(0, actual.Inclusions[0].Source)
+
+	// The negated 'or' relations of the last exclusion are separate criteria with the same source.
+	a.Len(actual.Exclusions, 3)
+	a.Equal("Weigh more than 180 pounds", actual.Exclusions[0].Text)
+	a.Equal("weight", actual.Exclusions[0].Relations[0].Name)
+	a.Equal([]int{1, 2, 3}, []int{actual.Exclusions[0].CID, actual.Exclusions[1].CID, actual.Exclusions[2].CID})
+	a.Equal([]int{2, 3, 3}, []int{actual.Exclusions[0].Source, actual.Exclusions[1].Source, actual.Exclusions[2].Source})
+	a.Equal(actual.Exclusions[1].Text, actual.Exclusions[2].Text)
+
+	// The cids match the output of Relations.
+	lines := strings.Split(strings.TrimSpace(study.Relations()), "\n")
+	a.Len(lines, 5)
+	a.Equal("2", strings.Split(lines[3], "\t")[3])
+	a.Equal("3", strings.Split(lines[4], "\t")[3])
+
+	empty := NewStudy("ID012346", "", nil, "")
+	a.Equal(`{"nct_id":"ID012346","title":"","conditions":[],"inclusion":[],"exclusion":[]}`, empty.Parse().JSON())
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package units
+
+// Conversion defines an affine conversion 'to = Factor * from + Offset' from one unit to another.
+// If VName is set, the conversion is valid only for the variable, such as an analyte
+// specific conversion from mg/dl to mmol/l.
+type Conversion struct {
+	From   string  // unit name converted from
+	To     string  // unit name converted to
+	Factor float64 // multiplicative factor
+	Offset float64 // additive offset
+	VName  string  // variable to which the conversion is restricted
+}
+
+// NewConversion creates a new conversion.
+func NewConversion(from, to string, factor, offset float64, vname string) Conversion {
+	return Conversion{From: from, To: to, Factor: factor, Offset: offset, VName: vname}
+}
+
+// identityConversion returns the conversion from the unit to itself.
+func identityConversion(name string) Conversion {
+	return NewConversion(name, name, 1, 0, "")
+}
+
+// Apply converts the value x.
+func (c Conversion) Apply(x float64) float64 {
+	return c.Factor*x + c.Offset
+}
+
+// Inverse returns the conversion in the opposite direction.
+func (c Conversion) Inverse() Conversion {
+	return NewConversion(c.To, c.From, 1/c.Factor, -c.Offset/c.Factor, c.VName)
+}
+
+// Then returns the conversion that first applies c and then d.
+func (c Conversion) Then(d Conversion) Conversion {
+	vname := c.VName
+	if len(vname) == 0 {
+		vname = d.VName
+	}
+	return NewConversion(c.From, d.To, c.Factor*d.Factor, c.Offset*d.Factor+d.Offset, vname)
+}
+
+// Valid returns true if the conversion applies to the variable vname.
+func (c Conversion) Valid(vname string) bool {
+	return len(c.VName) == 0 || c.VName == vname
+}
+
+// Conversions defines a slice of conversions.
+type Conversions []Conversion
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/param"
@@ -31,18 +32,20 @@ func Get() *Units {
 }
 
 type Units struct {
-	ids        map[string]ID // map from unit name to unit id.
-	units      map[ID]*Unit
-	variables  map[string]string
-	dictionary *trie.Trie
+	ids         map[string]ID // map from unit name to unit id.
+	units       map[ID]*Unit
+	variables   map[string]string
+	conversions map[string]Conversions // map from unit name to conversions from the unit.
+	dictionary  *trie.Trie
 }
 
 func New() *Units {
 	return &Units{
-		ids:        make(map[string]ID),
-		units:      make(map[ID]*Unit),
-		variables:  make(map[string]string),
-		dictionary: trie.New(),
+		ids:         make(map[string]ID),
+		units:       make(map[ID]*Unit),
+		variables:   make(map[string]string),
+		conversions: make(map[string]Conversions),
+		dictionary:  trie.New(),
 	}
 }
 
@@ -99,6 +102,110 @@ func (us *Units) Add(id ID, name string, display string, aliases []string, vname
 	return nil
 }
 
+// AddConversion adds the conversion 'to = factor * from + offset' and its inverse to the units.
+// If vname is not empty, the conversion is used only for the variable vname.
+func (us *Units) AddConversion(from, to string, factor, offset float64, vname string) error {
+	if _, ok := us.ids[from]; !ok {
+		return fmt.Errorf("unknown unit name: %s", from)
+	}
+	if _, ok := us.ids[to]; !ok {
+		return fmt.Errorf("unknown unit name: %s", to)
+	}
+	if factor == 0 {
+		return fmt.Errorf("zero conversion factor: %s -> %s", from, to)
+	}
+	c := NewConversion(from, to, factor, offset, vname)
+	us.conversions[from] = append(us.conversions[from], c)
+	us.conversions[to] = append(us.conversions[to], c.Inverse())
+	return nil
+}
+
+// Conversion finds the conversion from the unit 'from' to the unit 'to' for the variable vname.
+// Conversions are chained if no direct conversion exists. Variable specific conversions
+// are preferred over the general ones.
+func (us *Units) Conversion(from, to, vname string) (Conversion, bool) {
+	if from == to {
+		return identityConversion(from), true
+	}
+	visited := map[string]bool{from: true}
+	queue := Conversions{identityConversion(from)}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, specific := range []bool{true, false} {
+			for _, d := range us.conversions[c.To] {
+				if visited[d.To] || !d.Valid(vname) || specific == (len(d.VName) == 0) {
+					continue
+				}
+				e := c.Then(d)
+				if e.To == to {
+					return e, true
+				}
+				visited[e.To] = true
+				queue = append(queue, e)
+			}
+		}
+	}
+	return Conversion{}, false
+}
+
+// Convert converts the value from the unit 'from' to the unit 'to' for the variable vname.
+// It returns false if no conversion is found.
+func (us *Units) Convert(value float64, from, to, vname string) (float64, bool) {
+	c, ok := us.Conversion(from, to, vname)
+	if !ok {
+		return value, false
+	}
+	return c.Apply(value), true
+}
+
+// LoadConversions loads unit conversions from a file. The units of
+// the conversions must already be in the catalog.
+func (us *Units) LoadConversions(fname string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = rune(param.Comment)
+
+	cnt := 0
+	for {
+		line, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", fname, err)
+		}
+		if len(line) < 5 {
+			return fmt.Errorf("%s: too few columns, at least 5 needed: %v", fname, line)
+		}
+		from := line[0]
+		to := line[1]
+		factor, err := strconv.ParseFloat(line[2], 64)
+		if err != nil {
+			return fmt.Errorf("%s: bad conversion factor: %v", fname, line)
+		}
+		offset := 0.0
+		if len(line[3]) > 0 {
+			if offset, err = strconv.ParseFloat(line[3], 64); err != nil {
+				return fmt.Errorf("%s: bad conversion offset: %v", fname, line)
+			}
+		}
+		vname := line[4]
+		if err := us.AddConversion(from, to, factor, offset, vname); err != nil {
+			return fmt.Errorf("%s: %v", fname, err)
+		}
+		cnt++
+	}
+	glog.Infof("Number of unit conversions loaded: %d\n", cnt)
+
+	return nil
+}
+
 // Load loads units from a file.​
 func Load(fname string) (*Units, error) {
 	f, err := os.Open(fname)
@@ -162,8 +269,8 @@ func DefaultCatalog() *Units {
 	aliases = []string{"week*"}
 	catalog.Add("304", "week", "week", aliases, "")
 
-	aliases = []string{"month"}
-	catalog.Add("305", "month", "month*", aliases, "")
+	aliases = []string{"month*"}
+	catalog.Add("305", "month", "month", aliases, "")
 
 	aliases = []string{"year*"}
 	catalog.Add("306", "year", "year", aliases, "")
@@ -213,5 +320,15 @@ func DefaultCatalog() *Units {
 	aliases = []string{"lln", "lower limit of normal", "lower limits of normal"}
 	catalog.Add("604", "lln", "lln", aliases, "")
 
+	catalog.AddConversion("g", "kg", 0.001, 0, "")
+	catalog.AddConversion("mg", "g", 0.001, 0, "")
+	catalog.AddConversion("lb", "kg", 0.45359237, 0, "")
+	catalog.AddConversion("m", "cm", 100, 0, "")
+	catalog.AddConversion("week", "day", 7, 0, "")
+	catalog.AddConversion("month", "day", 30.436875, 0, "")
+	catalog.AddConversion("year", "month", 12, 0, "")
+	catalog.AddConversion("g/dl", "mg/dl", 1000, 0, "")
+	catalog.AddConversion("cells/l", "cells/ul", 1e-6, 0, "")
+
 	return catalog
 }
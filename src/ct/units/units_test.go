@@ -0,0 +1,74 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCatalog() *Units {
+	catalog := New()
+	catalog.Add("1", "mg/dl", "mg/dl", []string{"mg/dl"}, "")
+	catalog.Add("2", "mmol/l", "mmol/l", []string{"mmol/l"}, "")
+	catalog.Add("3", "c", "C", []string{"c"}, "")
+	catalog.Add("4", "f", "F", []string{"f"}, "")
+	catalog.Add("5", "g/dl", "g/dl", []string{"g/dl"}, "")
+	catalog.Add("6", "g/l", "g/l", []string{"g/l"}, "")
+	catalog.AddConversion("f", "c", 5.0/9.0, -160.0/9.0, "")
+	catalog.AddConversion("g/l", "g/dl", 0.1, 0, "")
+	catalog.AddConversion("g/dl", "mg/dl", 1000, 0, "")
+	catalog.AddConversion("mmol/l", "mg/dl", 18.016, 0, "fasting_blood_sugar_level")
+	catalog.AddConversion("mmol/l", "mg/dl", 38.67, 0, "total_cholesterol")
+	return catalog
+}
+
+func TestAffineConversion(t *testing.T) {
+	a := assert.New(t)
+	catalog := testCatalog()
+
+	actual, ok := catalog.Convert(100.4, "f", "c", "body_temperature")
+	a.True(ok)
+	a.InDelta(38.0, actual, 1e-9)
+
+	actual, ok = catalog.Convert(38.0, "c", "f", "body_temperature")
+	a.True(ok)
+	a.InDelta(100.4, actual, 1e-9)
+}
+
+func TestChainedConversion(t *testing.T) {
+	a := assert.New(t)
+	catalog := testCatalog()
+
+	actual, ok := catalog.Convert(1.5, "g/l", "mg/dl", "")
+	a.True(ok)
+	a.InDelta(150.0, actual, 1e-9)
+}
+
+func TestVariableConversion(t *testing.T) {
+	a := assert.New(t)
+	catalog := testCatalog()
+
+	actual, ok := catalog.Convert(7.0, "mmol/l", "mg/dl", "fasting_blood_sugar_level")
+	a.True(ok)
+	a.InDelta(126.112, actual, 1e-9)
+
+	actual, ok = catalog.Convert(5.0, "mmol/l", "mg/dl", "total_cholesterol")
+	a.True(ok)
+	a.InDelta(193.35, actual, 1e-9)
+
+	_, ok = catalog.Convert(5.0, "mmol/l", "mg/dl", "potassium_level")
+	a.False(ok)
+}
+
+func TestUnknownConversion(t *testing.T) {
+	a := assert.New(t)
+	catalog := testCatalog()
+
+	a.Error(catalog.AddConversion("mg/dl", "xyz", 1, 0, ""))
+	a.Error(catalog.AddConversion("mg/dl", "mmol/l", 0, 0, ""))
+
+	_, ok := catalog.Convert(1.0, "c", "mg/dl", "")
+	a.False(ok)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package vocabularies
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/conf"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/util/fio"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/mesh"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/umls"
+
+	"github.com/golang/glog"
+)
+
+// sources defines the source files and options of a vocabulary.
+type sources struct {
+	source             Source
+	vocabularyFname    string
+	customFnames       []string
+	supplementaryFname string
+	umlsOptions        umls.Options
+}
+
+// newSources reads the sources of a vocabulary from the config with the keys vocabulary_source,
+// vocabulary_file, custom_vocabulary_file, supplementary_file (MeSH), and umls_* (UMLS).
+// The function path returns the path of a file key.
+func newSources(parameters conf.Config, path func(string) string) (*sources, error) {
+	s := &sources{
+		source:          ParseSource(parameters.Get("vocabulary_source")),
+		vocabularyFname: path("vocabulary_file"),
+	}
+	if parameters.Exists("custom_vocabulary_file") {
+		s.customFnames = fio.ReadFnames(path("custom_vocabulary_file"))
+	}
+	if parameters.Exists("supplementary_file") {
+		s.supplementaryFname = path("supplementary_file")
+	}
+	var err error
+	if s.umlsOptions, err = umls.NewOptions(parameters, path); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// build loads the vocabulary from its source files.
+func (s *sources) build() (*taxonomy.Taxonomy, error) {
+	var vocabulary *taxonomy.Taxonomy
+	switch s.source {
+	case MESH:
+		glog.Info("Loading MeSH ...")
+		vocabulary = mesh.Load(s.vocabularyFname, s.customFnames...)
+		if len(s.supplementaryFname) > 0 {
+			mesh.LoadSupplementary(vocabulary, s.supplementaryFname)
+		}
+	case UMLS:
+		glog.Info("Loading UMLS ...")
+		vocabulary = umls.LoadWithOptions(s.vocabularyFname, s.umlsOptions)
+	default:
+		return nil, fmt.Errorf("unknown vocabulary source")
+	}
+	return vocabulary, nil
+}
+
+// checksum returns the checksum of the source files and the parameters of the vocabulary.
+func (s *sources) checksum(parameters ...string) (string, error) {
+	fnames := append([]string{s.vocabularyFname}, s.customFnames...)
+	parameters = append([]string{s.source.String()}, parameters...)
+	switch s.source {
+	case MESH:
+		if len(s.supplementaryFname) > 0 {
+			fnames = append(fnames, s.supplementaryFname)
+		}
+	case UMLS:
+		fnames = append(fnames, s.umlsOptions.Fnames()...)
+		parameters = append(parameters, s.umlsOptions.String())
+	}
+	return taxonomy.Checksum(fnames, parameters...)
+}
+
+// Build loads the vocabulary of vocabulary_source from the source files set by the config,
+// without normalizing or indexing it. The function path returns the path of a file key,
+// such as conf.Config.GetDataPath.
+func Build(parameters conf.Config, path func(string) string) (*taxonomy.Taxonomy, error) {
+	s, err := newSources(parameters, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.build()
+}
+
+// Load loads the vocabulary like Build, normalizes it, and indexes it for matching with the LSH
+// parameters lsh_rows and lsh_bands. If snapshot_file is set, the vocabulary is loaded from the
+// snapshot when it is up to date with the sources and the parameters, and otherwise it is built
+// and saved to the snapshot.
+func Load(parameters conf.Config, path func(string) string) (*taxonomy.Taxonomy, error) {
+	s, err := newSources(parameters, path)
+	if err != nil {
+		return nil, err
+	}
+	rows := parameters.GetInt("lsh_rows")
+	bands := parameters.GetInt("lsh_bands")
+
+	build := func() (*taxonomy.Taxonomy, error) {
+		vocabulary, err := s.build()
+		if err != nil {
+			return nil, err
+		}
+		if err := vocabulary.NormalizeByID(mesh.NormalizerID); err != nil {
+			return nil, err
+		}
+		vocabulary.SetHashIndex(rows, bands)
+		return vocabulary, nil
+	}
+
+	if parameters.Exists("snapshot_file") {
+		checksum, err := s.checksum(mesh.NormalizerID, strconv.Itoa(rows), strconv.Itoa(bands))
+		if err != nil {
+			return nil, err
+		}
+		return taxonomy.LoadOrBuild(path("snapshot_file"), checksum, build)
+	}
+	return build()
+}
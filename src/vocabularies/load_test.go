@@ -0,0 +1,74 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package vocabularies
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/conf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const descriptorXML = `<?xml version="1.0"?>
+<DescriptorRecordSet LanguageCode = "eng">
+<DescriptorRecord DescriptorClass = "1">
+  <DescriptorUI>D001932</DescriptorUI>
+  <DescriptorName><String>Brain Neoplasms</String></DescriptorName>
+  <TreeNumberList>
+    <TreeNumber>C04.588.614.250.195</TreeNumber>
+  </TreeNumberList>
+  <ConceptList>
+    <Concept PreferredConceptYN="Y">
+      <ConceptUI>M0002930</ConceptUI>
+      <ConceptName><String>Brain Neoplasms</String></ConceptName>
+      <TermList>
+        <Term ConceptPreferredTermYN="Y" LexicalTag="NON" RecordPreferredTermYN="Y">
+          <TermUI>T005370</TermUI>
+          <String>Brain Neoplasms</String>
+        </Term>
+        <Term ConceptPreferredTermYN="N" LexicalTag="NON" RecordPreferredTermYN="N">
+          <TermUI>T005371</TermUI>
+          <String>Brain Cancer</String>
+        </Term>
+      </TermList>
+    </Concept>
+  </ConceptList>
+</DescriptorRecord>
+</DescriptorRecordSet>`
+
+func TestLoad(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	vocabularyFname := filepath.Join(dir, "desc.xml")
+	a.NoError(os.WriteFile(vocabularyFname, []byte(descriptorXML), 0644))
+	snapshotFname := filepath.Join(dir, "taxonomy.snapshot")
+
+	parameters := conf.New()
+	parameters.Put("vocabulary_source", "mesh")
+	parameters.Put("vocabulary_file", vocabularyFname)
+	parameters.Put("lsh_rows", "3")
+	parameters.Put("lsh_bands", "16")
+	parameters.Put("snapshot_file", snapshotFname)
+
+	built, err := Load(parameters, parameters.Get)
+	a.NoError(err)
+	a.FileExists(snapshotFname)
+	loaded, err := Load(parameters, parameters.Get)
+	a.NoError(err)
+
+	filter := set.New()
+	expected := built.Match("brain cancer", 0.1, filter)
+	a.Equal("Brain Neoplasms", expected.MaxKey())
+	a.Equal(expected.String(), loaded.Match("brain cancer", 0.1, filter).String())
+
+	parameters.Put("vocabulary_source", "none")
+	_, err = Build(parameters, parameters.Get)
+	a.Error(err)
+	_, err = Load(parameters, parameters.Get)
+	a.Error(err)
+}
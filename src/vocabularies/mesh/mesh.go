@@ -3,9 +3,11 @@
 package mesh
 
 import (
+	"bufio"
 	"encoding/xml"
-	"io/ioutil"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
 
@@ -21,11 +23,46 @@ type Descriptors struct {
 // Descriptor defines the xml struct for Descriptor.
 type Descriptor struct {
 	XMLName     xml.Name       `xml:"DescriptorRecord"`
+	ID          string         `xml:"DescriptorUI"`
 	Name        DescriptorName `xml:"DescriptorName"`
 	Concepts    Concepts       `xml:"ConceptList"`
 	TreeNumbers TreeNumbers    `xml:"TreeNumberList"`
 }
 
+// SupplementalRecord defines the xml struct for a supplementary concept record (SCR).
+type SupplementalRecord struct {
+	XMLName  xml.Name               `xml:"SupplementalRecord"`
+	ID       string                 `xml:"SupplementalRecordUI"`
+	Name     SupplementalRecordName `xml:"SupplementalRecordName"`
+	Headings HeadingMappedToList    `xml:"HeadingMappedToList"`
+	Concepts Concepts               `xml:"ConceptList"`
+}
+
+// HeadingMappedToList defines the xml struct for the headings that a SCR is mapped to.
+type HeadingMappedToList struct {
+	XMLName  xml.Name          `xml:"HeadingMappedToList"`
+	Headings []HeadingMappedTo `xml:"HeadingMappedTo"`
+}
+
+// HeadingMappedTo defines the xml struct for HeadingMappedTo.
+type HeadingMappedTo struct {
+	XMLName    xml.Name             `xml:"HeadingMappedTo"`
+	Descriptor DescriptorReferredTo `xml:"DescriptorReferredTo"`
+}
+
+// DescriptorReferredTo defines the xml struct for DescriptorReferredTo.
+type DescriptorReferredTo struct {
+	XMLName xml.Name       `xml:"DescriptorReferredTo"`
+	ID      string         `xml:"DescriptorUI"`
+	Name    DescriptorName `xml:"DescriptorName"`
+}
+
+// DescriptorID returns the UI of the referred descriptor without the asterisk
+// that marks a major heading.
+func (d DescriptorReferredTo) DescriptorID() string {
+	return strings.TrimPrefix(d.ID, "*")
+}
+
 // Concepts defines the xml struct for Concepts.
 type Concepts struct {
 	XMLName  xml.Name  `xml:"ConceptList"`
@@ -34,9 +71,16 @@ type Concepts struct {
 
 // Concept defines the xml struct for Concept.
 type Concept struct {
-	XMLName xml.Name    `xml:"Concept"`
-	Name    ConceptName `xml:"ConceptName"`
-	Terms   Terms       `xml:"TermList"`
+	XMLName   xml.Name    `xml:"Concept"`
+	Preferred string      `xml:"PreferredConceptYN,attr"`
+	ID        string      `xml:"ConceptUI"`
+	Name      ConceptName `xml:"ConceptName"`
+	Terms     Terms       `xml:"TermList"`
+}
+
+// IsPreferred indicates whether the concept is the preferred concept of a record.
+func (c Concept) IsPreferred() bool {
+	return c.Preferred == "Y"
 }
 
 // TreeNumbers defines the xml struct for TreeNumbers.
@@ -53,10 +97,11 @@ type Terms struct {
 
 // Term defines the xml struct for Term.
 type Term struct {
-	XMLName   xml.Name `xml:"Term"`
-	Preferred string   `xml:"ConceptPreferredTermYN,attr"`
-	Name      string   `xml:"String"`
-	ID        string   `xml:"TermUI"`
+	XMLName    xml.Name `xml:"Term"`
+	Preferred  string   `xml:"ConceptPreferredTermYN,attr"`
+	LexicalTag string   `xml:"LexicalTag,attr"`
+	Name       string   `xml:"String"`
+	ID         string   `xml:"TermUI"`
 }
 
 // IsPreferred indicates whether the term is a preferred term for a concept.
@@ -64,6 +109,16 @@ func (t Term) IsPreferred() bool {
 	return t.Preferred == "Y"
 }
 
+// IsAbbreviation indicates whether the term is tagged as an abbreviation or an acronym.
+func (t Term) IsAbbreviation() bool {
+	switch t.LexicalTag {
+	case "ABB", "ABX", "ACR", "ACX":
+		return true
+	default:
+		return false
+	}
+}
+
 // DescriptorName defines the xml struct for DescriptorName.
 type DescriptorName struct {
 	XMLName xml.Name `xml:"DescriptorName"`
@@ -76,7 +131,14 @@ type ConceptName struct {
 	Value   string   `xml:"String"`
 }
 
-// Load loads a MeSH taxonomy from files.
+// SupplementalRecordName defines the xml struct for SupplementalRecordName.
+type SupplementalRecordName struct {
+	XMLName xml.Name `xml:"SupplementalRecordName"`
+	Value   string   `xml:"String"`
+}
+
+// Load loads a MeSH taxonomy from files. The descriptor and concept nodes
+// have their MeSH UIs as ids.
 func Load(xmlFname string, customFnames ...string) *taxonomy.Taxonomy {
 	t := loadTaxonomy(xmlFname)
 	if len(customFnames) > 0 {
@@ -84,12 +146,27 @@ func Load(xmlFname string, customFnames ...string) *taxonomy.Taxonomy {
 		cnt := t.AddNodes(nodes)
 		glog.Infof("%v: Nodes read: %d, New nodes: %d\n", customFnames, nodes.Len(), cnt)
 	}
-
 	t.SetBaseIndex()
-
 	return t
 }
 
+// LoadSupplementary loads supplementary concept records (SCRs) from an xml dump, such as supp2022.xml,
+// and attaches them as concepts to the descriptors of t that they are mapped to. Most drug names are SCRs.
+// The hash index of t must be set after loading.
+func LoadSupplementary(t *taxonomy.Taxonomy, fname string) {
+	file, err := os.Open(fname)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	defer file.Close()
+
+	recordCnt, attachedCnt, err := addSupplementary(t, file)
+	if err != nil {
+		glog.Fatalf("%s: %v", fname, err)
+	}
+	glog.Infof("%s: Supplementary records read: %d, Attached: %d\n", fname, recordCnt, attachedCnt)
+}
+
 // loadTaxonomy loads a MeSH vocabulary from an xml dump.
 func loadTaxonomy(fname string) *taxonomy.Taxonomy {
 	file, err := os.Open(fname)
@@ -98,38 +175,132 @@ func loadTaxonomy(fname string) *taxonomy.Taxonomy {
 	}
 	defer file.Close()
 
-	byteValue, _ := ioutil.ReadAll(file)
+	t, err := loadDescriptors(file)
+	if err != nil {
+		glog.Fatalf("%s: %v", fname, err)
+	}
+	return t
+}
 
-	var descriptors Descriptors
-	if err := xml.Unmarshal(byteValue, &descriptors); err != nil {
-		glog.Fatal(err)
+// decodeRecords decodes the xml stream one record at a time, so that the whole dump is not
+// held in memory. The function f decodes the record that starts with the element.
+func decodeRecords(r io.Reader, name string, f func(d *xml.Decoder, start *xml.StartElement) error) error {
+	d := xml.NewDecoder(bufio.NewReader(r))
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == name {
+			if err := f(d, &start); err != nil {
+				return err
+			}
+		}
 	}
+}
 
+// loadDescriptors loads MeSH descriptors from an xml stream. Animal and non-clinical
+// descriptors are skipped.
+func loadDescriptors(r io.Reader) (*taxonomy.Taxonomy, error) {
 	root := taxonomy.NewNode("root")
-
-	for _, d := range descriptors.Descriptors {
+	err := decodeRecords(r, "DescriptorRecord", func(dec *xml.Decoder, start *xml.StartElement) error {
+		var d Descriptor
+		if err := dec.DecodeElement(&d, start); err != nil {
+			return err
+		}
 		treeNumbers := d.TreeNumbers.TreeNumbers
 		if HasAnimalCode(treeNumbers) {
-			continue
+			return nil
 		}
 		treeNumbers = Trim(treeNumbers)
 		if len(treeNumbers) == 0 {
-			continue
+			return nil
 		}
+
 		de := taxonomy.NewNode(d.Name.Value)
+		de.SetID(d.ID)
 		for _, c := range d.Concepts.Concepts {
-			if !isAnimalConcept(c.Name.Value) {
-				ce := taxonomy.NewNode(c.Name.Value)
-				for _, t := range c.Terms.Terms {
-					ce.AddSynonym(t.Name)
-				}
-				ce.AddSynonym(c.Name.Value)
+			if ce := newConceptNode(c); ce != nil {
 				ce.AddTreeNumber(treeNumbers...)
 				de.AddChild(ce)
 			}
 		}
 		root.AddChild(de)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return taxonomy.New(root), nil
+}
+
+// addSupplementary attaches the supplementary concept records of the xml stream to the descriptors
+// of t that they are mapped to. A record is a concept node with the tree numbers of the descriptor.
+// It returns the number of records read and attached.
+func addSupplementary(t *taxonomy.Taxonomy, r io.Reader) (int, int, error) {
+	descriptors := make(map[string]*taxonomy.Node)
+	for _, n := range t.Nodes() {
+		if len(n.ID()) > 0 {
+			descriptors[n.ID()] = n
+		}
 	}
 
-	return taxonomy.New(root)
+	recordCnt := 0
+	attachedCnt := 0
+	err := decodeRecords(r, "SupplementalRecord", func(dec *xml.Decoder, start *xml.StartElement) error {
+		var s SupplementalRecord
+		if err := dec.DecodeElement(&s, start); err != nil {
+			return err
+		}
+		recordCnt++
+		attached := false
+		for _, h := range s.Headings.Headings {
+			de, ok := descriptors[h.Descriptor.DescriptorID()]
+			if !ok {
+				continue
+			}
+			se := taxonomy.NewNode(s.Name.Value)
+			se.SetID(s.ID)
+			for _, c := range s.Concepts.Concepts {
+				if ce := newConceptNode(c); ce != nil {
+					se.AddSynonym(ce.Synonyms().Slice()...)
+					se.AddAbbreviation(ce.Abbreviations().Slice()...)
+				}
+			}
+			if se.Synonyms().Empty() {
+				continue
+			}
+			se.AddTreeNumber(de.TreeNumbers().Slice()...)
+			de.AddChild(se)
+			attached = true
+		}
+		if attached {
+			attachedCnt++
+		}
+		return nil
+	})
+	return recordCnt, attachedCnt, err
+}
+
+// newConceptNode creates a concept node that has the concept name and its terms as synonyms.
+// Terms with an abbreviation or acronym lexical tag are added as abbreviations.
+// It returns nil for an animal concept.
+func newConceptNode(c Concept) *taxonomy.Node {
+	if isAnimalConcept(c.Name.Value) {
+		return nil
+	}
+	n := taxonomy.NewNode(c.Name.Value)
+	n.SetID(c.ID)
+	for _, t := range c.Terms.Terms {
+		if t.IsAbbreviation() {
+			n.AddAbbreviation(t.Name)
+		} else {
+			n.AddSynonym(t.Name)
+		}
+	}
+	n.AddSynonym(c.Name.Value)
+	return n
 }
@@ -0,0 +1,171 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package mesh
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const descriptorXML = `<?xml version="1.0"?>
+<!DOCTYPE DescriptorRecordSet SYSTEM "https://www.nlm.nih.gov/databases/dtd/nlmdescriptorrecordset_20220101.dtd">
+<DescriptorRecordSet LanguageCode = "eng">
+<DescriptorRecord DescriptorClass = "1">
+  <DescriptorUI>D001932</DescriptorUI>
+  <DescriptorName><String>Brain Neoplasms</String></DescriptorName>
+  <TreeNumberList>
+    <TreeNumber>C04.588.614.250.195</TreeNumber>
+    <TreeNumber>C10.228.140.211</TreeNumber>
+  </TreeNumberList>
+  <ConceptList>
+    <Concept PreferredConceptYN="Y">
+      <ConceptUI>M0002930</ConceptUI>
+      <ConceptName><String>Brain Neoplasms</String></ConceptName>
+      <TermList>
+        <Term ConceptPreferredTermYN="Y" LexicalTag="NON" RecordPreferredTermYN="Y">
+          <TermUI>T005370</TermUI>
+          <String>Brain Neoplasms</String>
+        </Term>
+        <Term ConceptPreferredTermYN="N" LexicalTag="NON" RecordPreferredTermYN="N">
+          <TermUI>T005371</TermUI>
+          <String>Brain Cancer</String>
+        </Term>
+        <Term ConceptPreferredTermYN="N" LexicalTag="ACR" RecordPreferredTermYN="N">
+          <TermUI>T005372</TermUI>
+          <String>BT</String>
+        </Term>
+      </TermList>
+    </Concept>
+  </ConceptList>
+</DescriptorRecord>
+<DescriptorRecord DescriptorClass = "1">
+  <DescriptorUI>D001688</DescriptorUI>
+  <DescriptorName><String>Antineoplastic Agents</String></DescriptorName>
+  <TreeNumberList>
+    <TreeNumber>D27.505.954.248</TreeNumber>
+  </TreeNumberList>
+  <ConceptList>
+    <Concept PreferredConceptYN="Y">
+      <ConceptUI>M0001567</ConceptUI>
+      <ConceptName><String>Antineoplastic Agents</String></ConceptName>
+      <TermList>
+        <Term ConceptPreferredTermYN="Y" LexicalTag="NON" RecordPreferredTermYN="Y">
+          <TermUI>T002994</TermUI>
+          <String>Antineoplastic Agents</String>
+        </Term>
+      </TermList>
+    </Concept>
+  </ConceptList>
+</DescriptorRecord>
+<DescriptorRecord DescriptorClass = "1">
+  <DescriptorUI>D004283</DescriptorUI>
+  <DescriptorName><String>Dog Diseases</String></DescriptorName>
+  <TreeNumberList>
+    <TreeNumber>C22.268</TreeNumber>
+  </TreeNumberList>
+  <ConceptList>
+    <Concept PreferredConceptYN="Y">
+      <ConceptUI>M0006569</ConceptUI>
+      <ConceptName><String>Dog Diseases</String></ConceptName>
+    </Concept>
+  </ConceptList>
+</DescriptorRecord>
+</DescriptorRecordSet>`
+
+const supplementaryXML = `<?xml version="1.0"?>
+<SupplementalRecordSet LanguageCode = "eng">
+<SupplementalRecord SCRClass = "1">
+  <SupplementalRecordUI>C000603933</SupplementalRecordUI>
+  <SupplementalRecordName><String>pembrolizumab</String></SupplementalRecordName>
+  <HeadingMappedToList>
+    <HeadingMappedTo>
+      <DescriptorReferredTo>
+        <DescriptorUI>*D001688</DescriptorUI>
+        <DescriptorName><String>Antineoplastic Agents</String></DescriptorName>
+      </DescriptorReferredTo>
+    </HeadingMappedTo>
+  </HeadingMappedToList>
+  <ConceptList>
+    <Concept PreferredConceptYN="Y">
+      <ConceptUI>M000618347</ConceptUI>
+      <ConceptName><String>pembrolizumab</String></ConceptName>
+      <TermList>
+        <Term ConceptPreferredTermYN="Y" LexicalTag="NON" RecordPreferredTermYN="Y">
+          <TermUI>T000854213</TermUI>
+          <String>pembrolizumab</String>
+        </Term>
+        <Term ConceptPreferredTermYN="N" LexicalTag="TRD" RecordPreferredTermYN="N">
+          <TermUI>T000854215</TermUI>
+          <String>Keytruda</String>
+        </Term>
+      </TermList>
+    </Concept>
+  </ConceptList>
+</SupplementalRecord>
+<SupplementalRecord SCRClass = "1">
+  <SupplementalRecordUI>C000000001</SupplementalRecordUI>
+  <SupplementalRecordName><String>unmapped compound</String></SupplementalRecordName>
+  <HeadingMappedToList>
+    <HeadingMappedTo>
+      <DescriptorReferredTo>
+        <DescriptorUI>*D999999</DescriptorUI>
+        <DescriptorName><String>Unknown</String></DescriptorName>
+      </DescriptorReferredTo>
+    </HeadingMappedTo>
+  </HeadingMappedToList>
+</SupplementalRecord>
+</SupplementalRecordSet>`
+
+func TestLoadDescriptors(t *testing.T) {
+	a := assert.New(t)
+
+	tx, err := loadDescriptors(strings.NewReader(descriptorXML))
+	a.NoError(err)
+	nodes := tx.Nodes()
+	a.Equal(2, nodes.Len())
+	a.Equal("Brain Neoplasms", nodes[0].Name())
+	a.Equal("D001932", nodes[0].ID())
+	a.True(nodes[0].Synonyms().Contains("Brain Cancer"))
+	a.True(nodes[0].Synonyms().Contains("BT"))
+	a.Equal([]string{"BT"}, nodes[0].Abbreviations().Slice())
+	a.Equal([]string{"C04.588.614.250.195", "C10.228.140.211"}, nodes[0].TreeNumbers().Slice())
+
+	tx.Normalize(Normalize)
+	tx.SetBaseIndex()
+	terms := tx.Match("brain cancer", 0.1, set.New())
+	a.Equal("Brain Neoplasms", terms.MaxKey())
+	a.Equal([]string{"M0002930"}, terms.IDs())
+
+	_, err = loadDescriptors(strings.NewReader("<DescriptorRecordSet><DescriptorRecord>"))
+	a.Error(err)
+}
+
+func TestAddSupplementary(t *testing.T) {
+	a := assert.New(t)
+
+	tx, err := loadDescriptors(strings.NewReader(descriptorXML))
+	a.NoError(err)
+	recordCnt, attachedCnt, err := addSupplementary(tx, strings.NewReader(supplementaryXML))
+	a.NoError(err)
+	a.Equal(2, recordCnt)
+	a.Equal(1, attachedCnt)
+
+	tx.Normalize(Normalize)
+	tx.SetBaseIndex()
+	terms := tx.Match("keytruda", 0.1, set.New())
+	a.Equal("pembrolizumab", terms.MaxKey())
+	a.Equal([]string{"C000603933"}, terms.IDs())
+	a.Equal([]string{"D27.505.954.248"}, terms.TreeNumbers())
+}
+
+func TestTerm(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(Term{LexicalTag: "ACR"}.IsAbbreviation())
+	a.False(Term{LexicalTag: "NON"}.IsAbbreviation())
+	a.Equal("D001688", DescriptorReferredTo{ID: "*D001688"}.DescriptorID())
+}
@@ -32,6 +32,13 @@ var (
 	reMRI         = regexp.MustCompile(`\bmri\b`)
 )
 
+// NormalizerID is the id of the registered MeSH normalizer.
+const NormalizerID = "mesh"
+
+func init() {
+	taxonomy.RegisterNormalizer(NormalizerID, Normalize)
+}
+
 // Normalize defines a normalizer function for MeSH terms.
 // normalizedTerm replaces the extracted NER term.
 // normalizedMatch is used to match terms to concepts.
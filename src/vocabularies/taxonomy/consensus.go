@@ -0,0 +1,38 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package taxonomy
+
+// Consensus returns the term with the highest average value over the matches of a group of strings,
+// such as a cluster of similar terms. A string without the term counts as 0 in the average, so terms
+// shared by the group are preferred. The returned term has the average as its value. Ties are broken
+// by key. It returns an empty slice if no string has a match with a positive value.
+func Consensus(matches []Terms) Terms {
+	sums := make(map[string]float64)
+	best := make(map[string]Term)
+	for _, ts := range matches {
+		values := make(map[string]float64)
+		for _, t := range ts {
+			if t.Value > values[t.Key] {
+				values[t.Key] = t.Value
+			}
+			if b, ok := best[t.Key]; t.Value > 0 && (!ok || t.Value > b.Value) {
+				best[t.Key] = t
+			}
+		}
+		for k, v := range values {
+			sums[k] += v
+		}
+	}
+	key := ""
+	for k, s := range sums {
+		if len(key) == 0 || s > sums[key] || (s == sums[key] && k < key) {
+			key = k
+		}
+	}
+	if len(key) == 0 {
+		return Terms{}
+	}
+	t := best[key]
+	t.Value = sums[key] / float64(len(matches))
+	return Terms{t}
+}
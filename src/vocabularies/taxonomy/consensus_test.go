@@ -0,0 +1,30 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package taxonomy
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsensus(t *testing.T) {
+	a := assert.New(t)
+
+	heart := NewTerm("Heart Failure", 0.6, set.New("C"), set.New("C14.280.434"))
+	kidney := NewTerm("Renal Insufficiency", 0.7, set.New("C"), set.New("C12.777.419.780"))
+	matches := []Terms{
+		{kidney, heart},
+		{NewTerm("Heart Failure", 0.5, set.New("C"), set.New("C14.280.434"))},
+		Default("cardiac insufficiency", "cardiac insufficiency"),
+	}
+	consensus := Consensus(matches)
+	a.Len(consensus, 1)
+	a.Equal("Heart Failure", consensus[0].Key)
+	a.InDelta(1.1/3, consensus[0].Value, 1e-9)
+	a.Equal([]string{"C14.280.434"}, consensus.TreeNumbers())
+
+	a.Empty(Consensus([]Terms{Default("a", "a")}))
+}
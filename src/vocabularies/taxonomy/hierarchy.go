@@ -0,0 +1,190 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package taxonomy
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+)
+
+// treeIndex defines the index of node names by tree number, such as 'C04.588.180' for MeSH.
+// A tree number is the path from the top of the hierarchy, so its ancestors are its prefixes.
+type treeIndex struct {
+	names       map[string]set.Set // Map from tree number to node names
+	treeNumbers map[string]set.Set // Map from node name to tree numbers
+	sorted      []string           // Sorted tree numbers for prefix search
+}
+
+// SetTreeIndex indexes the nodes of the taxonomy by their tree numbers for the hierarchy queries.
+// Adding nodes to the taxonomy clears the index.
+func (t *Taxonomy) SetTreeIndex() {
+	index := &treeIndex{names: make(map[string]set.Set), treeNumbers: make(map[string]set.Set)}
+	var add func(n *Node)
+	add = func(n *Node) {
+		for tn := range n.TreeNumbers() {
+			if _, ok := index.names[tn]; !ok {
+				index.names[tn] = set.New()
+				index.sorted = append(index.sorted, tn)
+			}
+			index.names[tn].Add(n.name)
+			if _, ok := index.treeNumbers[n.name]; !ok {
+				index.treeNumbers[n.name] = set.New()
+			}
+			index.treeNumbers[n.name].Add(tn)
+		}
+		for _, m := range n.children {
+			add(m)
+		}
+	}
+	for _, n := range t.root.children {
+		add(n)
+	}
+	sort.Strings(index.sorted)
+	t.treeIndex = index
+}
+
+// parent returns the parent tree number, or false if the tree number is at the top.
+func parent(tn string) (string, bool) {
+	i := strings.LastIndex(tn, ".")
+	if i < 0 {
+		return "", false
+	}
+	return tn[:i], true
+}
+
+// isPrefix returns true if the tree number a is an ancestor of or equal to the tree number b.
+func isPrefix(a, b string) bool {
+	return a == b || strings.HasPrefix(b, a+".")
+}
+
+// TreeNumbers returns the sorted tree numbers of the named node.
+func (t *Taxonomy) TreeNumbers(name string) []string {
+	tns := t.index().treeNumbers[name].Slice()
+	sort.Strings(tns)
+	return tns
+}
+
+// Ancestors returns the sorted names of the nodes above the named node in the hierarchy.
+func (t *Taxonomy) Ancestors(name string) []string {
+	index := t.index()
+	ancestors := set.New()
+	for tn := range index.treeNumbers[name] {
+		for p, ok := parent(tn); ok; p, ok = parent(p) {
+			ancestors.AddSet(index.names[p])
+		}
+	}
+	ancestors.Remove(name)
+	return sorted(ancestors)
+}
+
+// Descendants returns the sorted names of the nodes below the named node in the hierarchy.
+func (t *Taxonomy) Descendants(name string) []string {
+	index := t.index()
+	descendants := set.New()
+	for tn := range index.treeNumbers[name] {
+		prefix := tn + "."
+		for i := sort.SearchStrings(index.sorted, prefix); i < len(index.sorted) && strings.HasPrefix(index.sorted[i], prefix); i++ {
+			descendants.AddSet(index.names[index.sorted[i]])
+		}
+	}
+	descendants.Remove(name)
+	return sorted(descendants)
+}
+
+// IsAncestor returns true if the node named a is above the node named b in the hierarchy.
+func (t *Taxonomy) IsAncestor(a, b string) bool {
+	if a == b {
+		return false
+	}
+	index := t.index()
+	for ta := range index.treeNumbers[a] {
+		for tb := range index.treeNumbers[b] {
+			if ta != tb && isPrefix(ta, tb) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LowestCommonAncestor returns the sorted names of the deepest nodes that are above or equal to
+// both named nodes, and their tree number. It returns false if the nodes have no common ancestor,
+// such as nodes in different top-level trees.
+func (t *Taxonomy) LowestCommonAncestor(a, b string) ([]string, string, bool) {
+	index := t.index()
+	lca := ""
+	for ta := range index.treeNumbers[a] {
+		for tb := range index.treeNumbers[b] {
+			for p, ok := ta, true; ok; p, ok = parent(p) {
+				if isPrefix(p, tb) {
+					if _, indexed := index.names[p]; indexed && (len(p) > len(lca) || (len(p) == len(lca) && p < lca)) {
+						lca = p
+					}
+					break
+				}
+			}
+		}
+	}
+	if len(lca) == 0 {
+		return nil, "", false
+	}
+	return sorted(index.names[lca]), lca, true
+}
+
+// PathsToRoot returns the paths from the named node to the top of the hierarchy, one per tree number.
+// A path lists the tree numbers from the node's tree number up to the top-level tree number.
+func (t *Taxonomy) PathsToRoot(name string) [][]string {
+	var paths [][]string
+	for _, tn := range t.TreeNumbers(name) {
+		path := []string{tn}
+		for p, ok := parent(tn); ok; p, ok = parent(p) {
+			path = append(path, p)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Names returns the sorted names of the nodes with the tree number.
+func (t *Taxonomy) Names(treeNumber string) []string {
+	return sorted(t.index().names[treeNumber])
+}
+
+// Generalizes returns true if each of the general names is an ancestor of some of the specific names,
+// such as 'Neoplasms' for 'Brain Neoplasms'. It returns false if there are no general names.
+func (t *Taxonomy) Generalizes(general, specific []string) bool {
+	if len(general) == 0 {
+		return false
+	}
+	for _, g := range general {
+		found := false
+		for _, s := range specific {
+			if t.IsAncestor(g, s) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// index returns the tree index, which is built on first use if not set.
+// SetTreeIndex should be called before the taxonomy is queried concurrently.
+func (t *Taxonomy) index() *treeIndex {
+	if t.treeIndex == nil {
+		t.SetTreeIndex()
+	}
+	return t.treeIndex
+}
+
+// sorted returns the sorted elements of the set.
+func sorted(s set.Set) []string {
+	v := s.Slice()
+	sort.Strings(v)
+	return v
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package taxonomy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newHierarchy() *Taxonomy {
+	descriptors := []struct {
+		name        string
+		treeNumbers []string
+	}{
+		{"Neoplasms", []string{"C04"}},
+		{"Neoplasms by Site", []string{"C04.588"}},
+		{"Central Nervous System Neoplasms", []string{"C04.588.614.250", "C10.551.240"}},
+		{"Brain Neoplasms", []string{"C04.588.614.250.195", "C10.228.140.211", "C10.551.240.250"}},
+		{"Nervous System Diseases", []string{"C10"}},
+		{"Heart Failure", []string{"C14.280.434"}},
+	}
+	root := NewNode("root")
+	for _, d := range descriptors {
+		de := NewNode(d.name)
+		ce := NewNode(d.name)
+		ce.AddTreeNumber(d.treeNumbers...)
+		de.AddChild(ce)
+		root.AddChild(de)
+	}
+	return New(root)
+}
+
+func TestHierarchy(t *testing.T) {
+	a := assert.New(t)
+
+	tx := newHierarchy()
+	tx.SetTreeIndex()
+
+	a.Equal([]string{"C04"}, tx.TreeNumbers("Neoplasms"))
+	a.Equal([]string{"Brain Neoplasms"}, tx.Names("C10.228.140.211"))
+	a.Equal([]string{"Central Nervous System Neoplasms", "Neoplasms", "Neoplasms by Site", "Nervous System Diseases"}, tx.Ancestors("Brain Neoplasms"))
+	a.Equal([]string{"Brain Neoplasms", "Central Nervous System Neoplasms", "Neoplasms by Site"}, tx.Descendants("Neoplasms"))
+	a.Empty(tx.Descendants("Brain Neoplasms"))
+	a.Empty(tx.Ancestors("Unknown"))
+
+	a.True(tx.IsAncestor("Neoplasms", "Brain Neoplasms"))
+	a.False(tx.IsAncestor("Brain Neoplasms", "Neoplasms"))
+	a.False(tx.IsAncestor("Neoplasms", "Neoplasms"))
+	a.False(tx.IsAncestor("Neoplasms", "Heart Failure"))
+
+	names, tn, ok := tx.LowestCommonAncestor("Brain Neoplasms", "Central Nervous System Neoplasms")
+	a.True(ok)
+	a.Equal("C04.588.614.250", tn)
+	a.Equal([]string{"Central Nervous System Neoplasms"}, names)
+	_, _, ok = tx.LowestCommonAncestor("Brain Neoplasms", "Heart Failure")
+	a.False(ok)
+
+	a.Equal([][]string{{"C14.280.434", "C14.280", "C14"}}, tx.PathsToRoot("Heart Failure"))
+	a.Len(tx.PathsToRoot("Brain Neoplasms"), 3)
+}
+
+func TestGeneralizes(t *testing.T) {
+	a := assert.New(t)
+
+	tx := newHierarchy()
+
+	a.True(tx.Generalizes([]string{"Neoplasms"}, []string{"Heart Failure", "Brain Neoplasms"}))
+	a.True(tx.Generalizes([]string{"Neoplasms", "Nervous System Diseases"}, []string{"Brain Neoplasms"}))
+	a.False(tx.Generalizes([]string{"Neoplasms", "Heart Failure"}, []string{"Brain Neoplasms"}))
+	a.False(tx.Generalizes([]string{"Brain Neoplasms"}, []string{"Neoplasms"}))
+	a.False(tx.Generalizes(nil, []string{"Neoplasms"}))
+}
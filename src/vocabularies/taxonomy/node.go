@@ -18,15 +18,18 @@ import (
 
 // Node defines a node in a taxonomy.
 type Node struct {
-	name        string
-	children    Nodes
-	synonyms    set.Set
-	treeNumbers set.Set
+	name          string
+	id            string
+	children      Nodes
+	synonyms      set.Set
+	abbreviations set.Set
+	treeNumbers   set.Set
+	categories    set.Set
 }
 
 // NewNode creates a new node.
 func NewNode(s string) *Node {
-	return &Node{name: s, synonyms: set.New(), treeNumbers: set.New()}
+	return &Node{name: s, synonyms: set.New(), abbreviations: set.New(), treeNumbers: set.New(), categories: set.New()}
 }
 
 // Name returns the node's name.
@@ -34,6 +37,16 @@ func (n *Node) Name() string {
 	return n.name
 }
 
+// ID returns the node's vocabulary id, such as a MeSH UI, or an empty string if it has none.
+func (n *Node) ID() string {
+	return n.id
+}
+
+// SetID sets the node's vocabulary id.
+func (n *Node) SetID(id string) {
+	n.id = id
+}
+
 // Synonyms returns all synonyms of the node and its child nodes.
 func (n *Node) Synonyms() set.Set {
 	set := set.New()
@@ -44,6 +57,17 @@ func (n *Node) Synonyms() set.Set {
 	return set
 }
 
+// Abbreviations returns all synonyms of the node and its child nodes that are abbreviations
+// or acronyms, such as 'ALL', in their original case.
+func (n *Node) Abbreviations() set.Set {
+	set := set.New()
+	set.AddSet(n.abbreviations)
+	for _, m := range n.children {
+		set.AddSet(m.Abbreviations())
+	}
+	return set
+}
+
 // TreeNumbers returns all tree numbers of the node and its child nodes.
 func (n *Node) TreeNumbers() set.Set {
 	set := set.New()
@@ -54,15 +78,20 @@ func (n *Node) TreeNumbers() set.Set {
 	return set
 }
 
-// Categories returns all categories of the node and its child nodes.
+// Categories returns all categories of the node and its child nodes. The categories
+// are the letter prefixes of the tree numbers, such as 'C' for MeSH, and the categories
+// added to the nodes, such as UMLS semantic types.
 func (n *Node) Categories() set.Set {
 	categories := set.New()
 	for _, m := range n.children {
 		categories.AddSet(m.Categories())
 	}
 	for a := range n.treeNumbers {
-		categories.Add(text.LetterPrefix(a))
+		if c := text.LetterPrefix(a); len(c) > 0 {
+			categories.Add(c)
+		}
 	}
+	categories.AddSet(n.categories)
 	return categories
 }
 
@@ -103,11 +132,23 @@ func (n *Node) AddSynonym(ss ...string) {
 	n.synonyms.Add(ss...)
 }
 
+// AddAbbreviation adds a slice of synonyms that are abbreviations or acronyms to the node.
+// The abbreviations are kept in their original case when the synonyms are normalized.
+func (n *Node) AddAbbreviation(ss ...string) {
+	n.synonyms.Add(ss...)
+	n.abbreviations.Add(ss...)
+}
+
 // AddTreeNumber add a slice of tree numbers to the node.
 func (n *Node) AddTreeNumber(tn ...string) {
 	n.treeNumbers.Add(tn...)
 }
 
+// AddCategory add a slice of categories to the node.
+func (n *Node) AddCategory(cs ...string) {
+	n.categories.Add(cs...)
+}
+
 func equals(s1, s2 string) bool {
 	return strings.ToLower(s1) == strings.ToLower(s2)
 }
@@ -116,6 +157,7 @@ func equals(s1, s2 string) bool {
 func (n *Node) Update(m *Node) bool {
 	if n.children == nil && equals(n.name, m.name) {
 		n.synonyms.AddSet(m.synonyms)
+		n.abbreviations.AddSet(m.abbreviations)
 		n.treeNumbers.AddSet(m.treeNumbers)
 		return true
 	}
@@ -161,7 +203,9 @@ func (n *Node) walk(s string, indices []int, q chan<- Term, minHash lsh.MinHash,
 func (n *Node) match(s string, q chan<- Term, minHash lsh.MinHash, minScore float64) {
 	for syn := range n.synonyms {
 		if score := minHash.Similarity(s, syn); score >= minScore {
-			q <- NewTerm(n.name, score, n.Categories(), n.TreeNumbers().Copy())
+			t := NewTerm(n.name, score, n.Categories(), n.TreeNumbers().Copy())
+			t.ID = n.id
+			q <- t
 		}
 	}
 	for _, m := range n.children {
@@ -2,7 +2,35 @@
 
 package taxonomy
 
+import (
+	"sync"
+)
+
 // Normalizer defines a normalizer function to normalize node synonyms.
 type Normalizer func(string) (string, string)
 
+// IdentityID is the id of the normalizer that keeps the synonyms as is.
+const IdentityID = "identity"
+
 var identity Normalizer = func(s string) (string, string) { return s, s }
+
+var (
+	normalizers = map[string]Normalizer{IdentityID: identity}
+	mu          sync.RWMutex
+)
+
+// RegisterNormalizer registers the normalizer by its id, so that a taxonomy snapshot
+// can refer to the normalizer of its synonyms.
+func RegisterNormalizer(id string, f Normalizer) {
+	mu.Lock()
+	defer mu.Unlock()
+	normalizers[id] = f
+}
+
+// GetNormalizer returns the registered normalizer with the id.
+func GetNormalizer(id string) (Normalizer, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	f, ok := normalizers[id]
+	return f, ok
+}
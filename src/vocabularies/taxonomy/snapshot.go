@@ -0,0 +1,221 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package taxonomy
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/lsh"
+
+	"github.com/golang/glog"
+)
+
+// SnapshotVersion is the version of the snapshot format. Snapshots of other versions are stale.
+const SnapshotVersion = 4
+
+// ErrStaleSnapshot is returned when a snapshot has another version or checksum than expected.
+var ErrStaleSnapshot = errors.New("stale snapshot")
+
+// snapshotNode defines the encoding of a node in a snapshot.
+type snapshotNode struct {
+	Name          string
+	ID            string
+	Synonyms      []string
+	Abbreviations []string
+	TreeNumbers   []string
+	Categories    []string
+	Children      []snapshotNode
+}
+
+// snapshot defines the encoding of a normalized and indexed taxonomy.
+type snapshot struct {
+	Version      int
+	Checksum     string
+	NormalizerID string
+	MinHash      lsh.MinHash
+	HashIndex    map[string][]int
+	Nodes        []snapshotNode
+}
+
+// encodeNode converts the node and its child nodes to the snapshot encoding.
+func encodeNode(n *Node) snapshotNode {
+	s := snapshotNode{
+		Name:          n.name,
+		ID:            n.id,
+		Synonyms:      n.synonyms.Slice(),
+		Abbreviations: n.abbreviations.Slice(),
+		TreeNumbers:   n.treeNumbers.Slice(),
+		Categories:    n.categories.Slice(),
+	}
+	for _, m := range n.children {
+		s.Children = append(s.Children, encodeNode(m))
+	}
+	return s
+}
+
+// decodeNode converts the snapshot encoding to the node and its child nodes.
+func decodeNode(s snapshotNode) *Node {
+	n := NewNode(s.Name)
+	n.SetID(s.ID)
+	n.AddSynonym(s.Synonyms...)
+	n.abbreviations.Add(s.Abbreviations...)
+	n.AddTreeNumber(s.TreeNumbers...)
+	n.AddCategory(s.Categories...)
+	for _, c := range s.Children {
+		n.AddChild(decodeNode(c))
+	}
+	return n
+}
+
+// Checksum returns the checksum of the source files of a taxonomy and the parameters,
+// such as the normalizer id and the LSH parameters, that are used to build it.
+// The files are hashed by their contents, so the spelling of their paths does not matter.
+func Checksum(fnames []string, parameters ...string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "version:%d\n", SnapshotVersion)
+	for _, fname := range fnames {
+		f, err := os.Open(fname)
+		if err != nil {
+			return "", err
+		}
+		fh := sha256.New()
+		_, err = io.Copy(fh, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", fname, err)
+		}
+		fmt.Fprintf(h, "file:%x\n", fh.Sum(nil))
+	}
+	for _, p := range parameters {
+		fmt.Fprintf(h, "parameter:%s\n", p)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Save saves the normalized and indexed taxonomy to a gzipped binary snapshot with the checksum
+// of its sources. The taxonomy must be normalized by a registered normalizer. The snapshot is
+// written to a temporary file that replaces the file, so a concurrent load never reads a partial snapshot.
+func (t *Taxonomy) Save(fname, checksum string) error {
+	if len(t.normalizerID) == 0 {
+		return fmt.Errorf("%s: taxonomy normalized by an unregistered normalizer", fname)
+	}
+	s := snapshot{
+		Version:      SnapshotVersion,
+		Checksum:     checksum,
+		NormalizerID: t.normalizerID,
+		MinHash:      t.minHash,
+		HashIndex:    t.hashIndex,
+		Nodes:        encodeNode(t.root).Children,
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(fname), filepath.Base(fname)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpFname := file.Name()
+	fail := func(err error) error {
+		file.Close()
+		os.Remove(tmpFname)
+		return fmt.Errorf("%s: %v", fname, err)
+	}
+	writer := bufio.NewWriter(file)
+	zw := gzip.NewWriter(writer)
+	if err := gob.NewEncoder(zw).Encode(s); err != nil {
+		return fail(err)
+	}
+	if err := zw.Close(); err != nil {
+		return fail(err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fail(err)
+	}
+	// The temporary file is only readable by its owner, unlike the snapshot.
+	if err := file.Chmod(0644); err != nil {
+		return fail(err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpFname)
+		return err
+	}
+	if err := os.Rename(tmpFname, fname); err != nil {
+		os.Remove(tmpFname)
+		return err
+	}
+	return nil
+}
+
+// LoadSnapshot loads a taxonomy from the snapshot file. It returns ErrStaleSnapshot
+// if the snapshot has another version or checksum, so that the taxonomy can be rebuilt.
+// The search index is set as it was when the snapshot was saved.
+func LoadSnapshot(fname, checksum string) (*Taxonomy, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	zr, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	var s snapshot
+	if err := gob.NewDecoder(zr).Decode(&s); err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	if s.Version != SnapshotVersion || s.Checksum != checksum {
+		return nil, fmt.Errorf("%s: %w", fname, ErrStaleSnapshot)
+	}
+	f, ok := GetNormalizer(s.NormalizerID)
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown normalizer: %q", fname, s.NormalizerID)
+	}
+
+	root := NewNode("root")
+	for _, c := range s.Nodes {
+		root.AddChild(decodeNode(c))
+	}
+	t := New(root)
+	t.normalize = f
+	t.normalizerID = s.NormalizerID
+	t.SetBaseIndex()
+	if len(s.HashIndex) > 0 {
+		t.hashIndex = s.HashIndex
+		t.minHash = s.MinHash
+	}
+	return t, nil
+}
+
+// LoadOrBuild loads the taxonomy from the snapshot file if it is up to date with the checksum.
+// Otherwise the taxonomy is built and saved to the snapshot file, so that the next load is fast.
+// If the snapshot cannot be saved, such as in a read-only directory, the built taxonomy is returned.
+func LoadOrBuild(fname, checksum string, build func() (*Taxonomy, error)) (*Taxonomy, error) {
+	t, err := LoadSnapshot(fname, checksum)
+	switch {
+	case err == nil:
+		glog.Infof("Taxonomy loaded from snapshot: %s\n", fname)
+		return t, nil
+	case os.IsNotExist(err):
+		glog.Infof("No taxonomy snapshot, building: %s\n", fname)
+	default:
+		glog.Warningf("Rebuilding taxonomy snapshot: %v\n", err)
+	}
+
+	if t, err = build(); err != nil {
+		return nil, err
+	}
+	if err := t.Save(fname, checksum); err != nil {
+		glog.Warningf("Taxonomy snapshot not saved: %v\n", err)
+		return t, nil
+	}
+	glog.Infof("Taxonomy saved to snapshot: %s\n", fname)
+	return t, nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package taxonomy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "descriptor.tsv")
+	a.NoError(os.WriteFile(source, []byte("Brain Neoplasms\tbrain cancer\n"), 0644))
+	checksum, err := Checksum([]string{source}, IdentityID, "3", "16")
+	a.NoError(err)
+	respelled, err := Checksum([]string{filepath.Join(dir, ".", "descriptor.tsv")}, IdentityID, "3", "16")
+	a.NoError(err)
+	a.Equal(checksum, respelled)
+
+	tx := newHierarchy()
+	tx.root.children[3].AddSynonym("brain cancer", "brain tumor")
+	tx.root.children[3].AddAbbreviation("BT")
+	a.NoError(tx.NormalizeByID(IdentityID))
+	tx.SetHashIndex(3, 16)
+
+	fname := filepath.Join(dir, "taxonomy.snapshot")
+	a.NoError(tx.Save(fname, checksum))
+
+	tmpFnames, err := filepath.Glob(fname + ".*.tmp")
+	a.NoError(err)
+	a.Empty(tmpFnames)
+	info, err := os.Stat(fname)
+	a.NoError(err)
+	a.Equal(os.FileMode(0644), info.Mode().Perm())
+
+	loaded, err := LoadSnapshot(fname, checksum)
+	a.NoError(err)
+	a.Equal(tx.hashIndex, loaded.hashIndex)
+	a.Equal(tx.minHash, loaded.minHash)
+	a.Equal(tx.Nodes().Len(), loaded.Nodes().Len())
+	a.Equal([]string{"BT"}, loaded.Nodes()[3].Abbreviations().Slice())
+	loaded.SetTreeIndex()
+	a.Equal([]string{"C04.588.614.250.195", "C10.228.140.211", "C10.551.240.250"}, loaded.TreeNumbers("Brain Neoplasms"))
+
+	filter := set.New()
+	expected := tx.Match("brain tumor", 0.1, filter)
+	a.NotEmpty(expected)
+	a.Equal(expected.String(), loaded.Match("brain tumor", 0.1, filter).String())
+
+	stale, err := Checksum([]string{source}, IdentityID, "4", "16")
+	a.NoError(err)
+	_, err = LoadSnapshot(fname, stale)
+	a.True(errors.Is(err, ErrStaleSnapshot))
+
+	builds := 0
+	build := func() (*Taxonomy, error) {
+		builds++
+		return tx, nil
+	}
+	_, err = LoadOrBuild(fname, stale, build)
+	a.NoError(err)
+	_, err = LoadOrBuild(fname, stale, build)
+	a.NoError(err)
+	a.Equal(1, builds)
+
+	loaded, err = LoadOrBuild(filepath.Join(dir, "missing", "taxonomy.snapshot"), checksum, build)
+	a.NoError(err)
+	a.Equal(tx, loaded)
+	a.Equal(2, builds)
+
+	tx.Normalize(func(s string) (string, string) { return s, s })
+	a.Error(tx.Save(fname, checksum))
+}
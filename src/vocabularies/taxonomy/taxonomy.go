@@ -21,12 +21,14 @@ const (
 
 // Taxonomy defines a taxonomy for a vocabulary.
 type Taxonomy struct {
-	root      *Node
-	normalize Normalizer
+	root         *Node
+	normalize    Normalizer
+	normalizerID string
 
 	baseIndex []int
 	hashIndex map[string][]int
 	minHash   lsh.MinHash
+	treeIndex *treeIndex
 
 	capacity int
 	buffSize int
@@ -35,7 +37,7 @@ type Taxonomy struct {
 
 // New creates a new taxonomy.
 func New(r *Node) *Taxonomy {
-	return &Taxonomy{root: r, normalize: identity, capacity: capacity, buffSize: buffSize, minScore: minScore}
+	return &Taxonomy{root: r, normalize: identity, normalizerID: IdentityID, capacity: capacity, buffSize: buffSize, minScore: minScore}
 }
 
 // SetQueueCapacity sets the capacity of the search priority queue.
@@ -66,6 +68,7 @@ func (t *Taxonomy) AddNodes(ns Nodes) int {
 
 // AddNode adds a node to the taxonomy. Nodes with the same name are joined.
 func (t *Taxonomy) AddNode(n *Node) bool {
+	t.treeIndex = nil
 	if ok := t.root.Update(n); !ok {
 		t.root.AddChild(n)
 		return true
@@ -73,12 +76,30 @@ func (t *Taxonomy) AddNode(n *Node) bool {
 	return false
 }
 
-// Normalize normalizes the node synonyms.
+// Nodes returns the top-level nodes of the taxonomy, such as MeSH descriptors.
+func (t *Taxonomy) Nodes() Nodes {
+	return t.root.children
+}
+
+// Normalize normalizes the node synonyms. A taxonomy normalized by an unregistered
+// normalizer cannot be saved to a snapshot.
 func (t *Taxonomy) Normalize(f Normalizer) {
 	t.normalize = f
+	t.normalizerID = ""
 	t.root.normalize(f)
 }
 
+// NormalizeByID normalizes the node synonyms by the registered normalizer with the id.
+func (t *Taxonomy) NormalizeByID(id string) error {
+	f, ok := GetNormalizer(id)
+	if !ok {
+		return fmt.Errorf("unknown normalizer: %q", id)
+	}
+	t.Normalize(f)
+	t.normalizerID = id
+	return nil
+}
+
 // Info prints basic information about the taxonomy.
 func (t *Taxonomy) Info() {
 	fmt.Printf("Descriptors: %6d\n", t.root.Size(0, 1))
@@ -12,9 +12,10 @@ import (
 )
 
 // Term defines a vocabulary term or concept with a Key (e.g., name)
-// and Value (e.g., score).
+// and Value (e.g., score). ID is the vocabulary id of the concept, if any.
 type Term struct {
 	Key         string
+	ID          string
 	Normalized  string
 	Value       float64
 	Categories  set.Set
@@ -43,6 +44,7 @@ func (t Term) PassFilter(filter set.Set) bool {
 }
 
 // TrimCategories removes categories and tree numbers that are not in the categories set.
+// Tree numbers without a letter prefix have no category and are kept.
 func (t Term) TrimCategories(categories set.Set) Term {
 	if categories.Empty() {
 		return t
@@ -53,7 +55,7 @@ func (t Term) TrimCategories(categories set.Set) Term {
 		}
 	}
 	for tn := range t.TreeNumbers {
-		if c := text.LetterPrefix(tn); !categories[c] {
+		if c := text.LetterPrefix(tn); len(c) > 0 && !categories[c] {
 			delete(t.TreeNumbers, tn)
 		}
 	}
@@ -113,6 +115,17 @@ func (ts Terms) Keys() []string {
 	return keys.Slice()
 }
 
+// IDs returns the unique vocabulary ids of the terms. Terms without an id are skipped.
+func (ts Terms) IDs() []string {
+	ids := set.New()
+	for _, t := range ts {
+		if len(t.ID) > 0 {
+			ids.Add(t.ID)
+		}
+	}
+	return ids.Slice()
+}
+
 // Categories returns the unique categories of the terms.
 func (ts Terms) Categories() []string {
 	cat := set.New()
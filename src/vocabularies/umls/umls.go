@@ -5,7 +5,10 @@ package umls
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
@@ -14,19 +17,162 @@ import (
 	"github.com/golang/glog"
 )
 
-// Load loads a UMLS vocabulary from MRCONSO.RRF.
+// Options defines the UMLS atoms to load and the optional files of semantic types and relations.
+type Options struct {
+	Sources             set.Set // Source abbreviations (SABs), such as MSH and SNOMEDCT_US
+	Languages           set.Set // Languages, such as ENG
+	TermTypes           set.Set // Term types (TTYs), such as PT and SY; all if empty
+	SemanticTypesFname  string  // MRSTY.RRF; semantic types are set as categories
+	SemanticGroupsFname string  // SemGroups.txt; semantic groups of the semantic types are set as categories
+	RelationsFname      string  // MRREL.RRF; parent relations are set as tree numbers
+	MaxTreeNumbers      int     // Maximum number of tree numbers of a concept
+}
+
+// NewOptions creates the options from the parameters, such as a config, with the keys
+// umls_sources, umls_languages, umls_term_types, umls_semantic_types_file, umls_semantic_groups_file,
+// umls_relations_file, and umls_max_tree_numbers. The function path returns the path of a file parameter.
+// Missing keys have the defaults of Load: English atoms from MeSH and SNOMED CT.
+func NewOptions(parameters map[string]string, path func(string) string) (Options, error) {
+	o := Options{
+		Sources:        set.New("SNOMEDCT_US", "MSH"),
+		Languages:      set.New("ENG"),
+		TermTypes:      set.New(),
+		MaxTreeNumbers: 16,
+	}
+	list := func(key string) (set.Set, bool) {
+		value, ok := parameters[key]
+		if !ok {
+			return nil, false
+		}
+		s := set.New()
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); len(v) > 0 {
+				s.Add(v)
+			}
+		}
+		return s, true
+	}
+	if s, ok := list("umls_sources"); ok {
+		o.Sources = s
+	}
+	if s, ok := list("umls_languages"); ok {
+		o.Languages = s
+	}
+	if s, ok := list("umls_term_types"); ok {
+		o.TermTypes = s
+	}
+	if _, ok := parameters["umls_semantic_types_file"]; ok {
+		o.SemanticTypesFname = path("umls_semantic_types_file")
+	}
+	if _, ok := parameters["umls_semantic_groups_file"]; ok {
+		o.SemanticGroupsFname = path("umls_semantic_groups_file")
+	}
+	if _, ok := parameters["umls_relations_file"]; ok {
+		o.RelationsFname = path("umls_relations_file")
+	}
+	if value, ok := parameters["umls_max_tree_numbers"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return o, fmt.Errorf("umls_max_tree_numbers must be a positive integer: %q", value)
+		}
+		o.MaxTreeNumbers = n
+	}
+	return o, nil
+}
+
+// Fnames returns the names of the optional files.
+func (o Options) Fnames() []string {
+	var fnames []string
+	for _, fname := range []string{o.SemanticTypesFname, o.SemanticGroupsFname, o.RelationsFname} {
+		if len(fname) > 0 {
+			fnames = append(fnames, fname)
+		}
+	}
+	return fnames
+}
+
+// String returns a string representation of the atom selection and the tree number limit.
+func (o Options) String() string {
+	return fmt.Sprintf("sources:%s languages:%s term_types:%s max_tree_numbers:%d",
+		strings.Join(o.Sources.Slice(), ","), strings.Join(o.Languages.Slice(), ","),
+		strings.Join(o.TermTypes.Slice(), ","), o.MaxTreeNumbers)
+}
+
+// accept returns true if the atom with the language, source, and term type is selected.
+func (o Options) accept(lang, sab, tty string) bool {
+	return o.Languages[lang] && o.Sources[sab] && (o.TermTypes.Empty() || o.TermTypes[tty])
+}
+
+// Load loads a UMLS vocabulary of English atoms from MeSH and SNOMED CT from MRCONSO.RRF.
 func Load(fname string) *taxonomy.Taxonomy {
+	o, _ := NewOptions(nil, nil)
+	return LoadWithOptions(fname, o)
+}
+
+// LoadWithOptions loads a UMLS vocabulary from MRCONSO.RRF. A concept is a node with its CUI as the id
+// and the strings of its selected atoms as synonyms. If set, the semantic types and groups are the
+// categories of the concepts and the parent relations of the selected sources are their tree numbers.
+func LoadWithOptions(fname string, o Options) *taxonomy.Taxonomy {
 	file, err := os.Open(fname)
 	if err != nil {
 		glog.Fatal(err)
 	}
 	defer file.Close()
 
-	ids := set.New()
+	root, concepts, err := loadConcepts(file, o)
+	if err != nil {
+		glog.Fatalf("%s: %v", fname, err)
+	}
+	glog.Infof("%s: Concepts loaded: %d\n", fname, len(concepts))
+
+	if len(o.SemanticTypesFname) > 0 {
+		groups := make(map[string]string)
+		if len(o.SemanticGroupsFname) > 0 {
+			readFile(o.SemanticGroupsFname, func(r io.Reader) (err error) {
+				groups, err = loadSemanticGroups(r)
+				return err
+			})
+		}
+		readFile(o.SemanticTypesFname, func(r io.Reader) error {
+			cnt, err := addSemanticTypes(r, concepts, groups)
+			glog.Infof("%s: Semantic types added: %d\n", o.SemanticTypesFname, cnt)
+			return err
+		})
+	}
+	if len(o.RelationsFname) > 0 {
+		readFile(o.RelationsFname, func(r io.Reader) error {
+			cnt, err := addRelations(r, concepts, o)
+			glog.Infof("%s: Concepts in the hierarchy: %d\n", o.RelationsFname, cnt)
+			return err
+		})
+	}
+
+	t := taxonomy.New(root)
+	t.SetBaseIndex()
+
+	return t
+}
+
+// readFile opens the file and reads it with the function f.
+func readFile(fname string, f func(io.Reader) error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	defer file.Close()
+
+	if err := f(file); err != nil {
+		glog.Fatalf("%s: %v", fname, err)
+	}
+}
+
+// loadConcepts loads the selected atoms from MRCONSO.RRF and returns the root of the concept nodes
+// and the nodes by CUI.
+func loadConcepts(r io.Reader, o Options) (*taxonomy.Node, map[string]*taxonomy.Node, error) {
 	root := taxonomy.NewNode("root")
-	var de *taxonomy.Node
+	concepts := make(map[string]*taxonomy.Node)
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := scanner.Text()
 		line = strings.TrimSpace(line)
@@ -40,27 +186,167 @@ func Load(fname string) *taxonomy.Taxonomy {
 			continue
 		}
 		lang := strings.TrimSpace(values[1])
-		vocabularly := strings.TrimSpace(values[11])
-		if lang != "ENG" || vocabularly != "SNOMEDCT_US" && vocabularly != "MSH" {
+		vocabulary := strings.TrimSpace(values[11])
+		termType := strings.TrimSpace(values[12])
+		if !o.accept(lang, vocabulary, termType) {
 			continue
 		}
 
 		id := strings.TrimSpace(values[0])
 		name := strings.TrimSpace(values[14])
 
-		if ids[id] {
+		if de, ok := concepts[id]; ok {
 			de.AddSynonym(name)
 		} else {
-			ids.Add(id)
 			de = taxonomy.NewNode(name)
-			de.AddTreeNumber(id)
+			de.SetID(id)
 			de.AddSynonym(name)
 			root.AddChild(de)
+			concepts[id] = de
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return root, concepts, nil
+}
 
-	t := taxonomy.New(root)
-	t.SetBaseIndex()
+// loadSemanticGroups loads the map from semantic type ids (TUIs) to semantic groups, such as DISO,
+// from SemGroups.txt.
+func loadSemanticGroups(r io.Reader) (map[string]string, error) {
+	groups := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		values := strings.Split(line, "|")
+		if len(values) < 3 {
+			return nil, fmt.Errorf("too few columns: %s", line)
+		}
+		groups[strings.TrimSpace(values[2])] = strings.TrimSpace(values[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
 
-	return t
+// addSemanticTypes adds the semantic type ids (TUIs) of MRSTY.RRF, and their semantic groups,
+// to the categories of the concepts. It returns the number of semantic types added.
+func addSemanticTypes(r io.Reader, concepts map[string]*taxonomy.Node, groups map[string]string) (int, error) {
+	cnt := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		values := strings.Split(scanner.Text(), "|")
+		if len(values) < 2 {
+			continue
+		}
+		n, ok := concepts[strings.TrimSpace(values[0])]
+		if !ok {
+			continue
+		}
+		tui := strings.TrimSpace(values[1])
+		n.AddCategory(tui)
+		if g, ok := groups[tui]; ok {
+			n.AddCategory(g)
+		}
+		cnt++
+	}
+	return cnt, scanner.Err()
+}
+
+// addRelations sets the tree numbers of the concepts by the parent (PAR) relations of the selected
+// sources in MRREL.RRF. The concepts at the top of the hierarchy are numbered from 1, and their
+// descendants are numbered by their order among the children of each parent, such as '12.3.1'.
+// A concept has a tree number for each path from the top, up to the maximum number of tree numbers.
+// Concepts in a cycle of relations have no tree numbers. It returns the number of concepts with tree numbers.
+func addRelations(r io.Reader, concepts map[string]*taxonomy.Node, o Options) (int, error) {
+	parents := make(map[string]set.Set)
+	children := make(map[string]set.Set)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		values := strings.Split(scanner.Text(), "|")
+		if len(values) < 11 {
+			continue
+		}
+		// REL is the relation of the second concept to the first one.
+		child, rel, parent, sab := values[0], values[3], values[4], values[10]
+		if rel != "PAR" || child == parent || !o.Sources[sab] {
+			continue
+		}
+		if concepts[child] == nil || concepts[parent] == nil {
+			continue
+		}
+		if parents[child] == nil {
+			parents[child] = set.New()
+		}
+		parents[child].Add(parent)
+		if children[parent] == nil {
+			children[parent] = set.New()
+		}
+		children[parent].Add(child)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	var queue []string
+	for cui := range children {
+		if parents[cui].Empty() {
+			queue = append(queue, cui)
+		}
+	}
+	sort.Strings(queue)
+	treeNumbers := make(map[string][]string)
+	for i, cui := range queue {
+		treeNumbers[cui] = []string{strconv.Itoa(i + 1)}
+	}
+
+	indegree := make(map[string]int)
+	for cui, ps := range parents {
+		indegree[cui] = ps.Size()
+	}
+	for len(queue) > 0 {
+		cui := queue[0]
+		queue = queue[1:]
+		for i, child := range sorted(children[cui]) {
+			for _, tn := range treeNumbers[cui] {
+				if len(treeNumbers[child]) >= o.MaxTreeNumbers {
+					break
+				}
+				treeNumbers[child] = append(treeNumbers[child], tn+"."+strconv.Itoa(i+1))
+			}
+			if indegree[child]--; indegree[child] == 0 {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	for cui, tns := range treeNumbers {
+		concepts[cui].AddTreeNumber(tns...)
+	}
+	if cnt := hierarchySize(parents, children) - len(treeNumbers); cnt > 0 {
+		glog.Warningf("Concepts in a cycle without tree numbers: %d\n", cnt)
+	}
+	return len(treeNumbers), nil
+}
+
+// hierarchySize returns the number of concepts with a parent or a child.
+func hierarchySize(parents, children map[string]set.Set) int {
+	cnt := len(parents)
+	for cui := range children {
+		if _, ok := parents[cui]; !ok {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// sorted returns the sorted items of the set.
+func sorted(s set.Set) []string {
+	items := s.Slice()
+	sort.Strings(items)
+	return items
 }
@@ -0,0 +1,93 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package umls
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/common/col/set"
+	"github.com/facebookresearch/Clinical-Trial-Parser/src/vocabularies/taxonomy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const mrconso = `C0027651|ENG|P|L0027651|PF|S0061852|Y|A0089223||D009369|D009369|MSH|MH|D009369|Neoplasms|0|N||
+C0027651|ENG|S|L0006823|PF|S0017988|Y|A0033476||D009369|D009369|MSH|SY|D009369|Cancer|0|N||
+C0027651|GER|P|L1234567|PF|S1234567|Y|A1234567||D009369|D009369|MSHGER|MH|D009369|Neoplasmen|0|N||
+C0006118|ENG|P|L0006118|PF|S0017811|Y|A0031779||D001932|D001932|MSH|MH|D001932|Brain Neoplasms|0|N||
+C0006118|ENG|S|L0006826|PF|S0017819|Y|A0031791||D001932|D001932|MSH|ET|D001932|Brain Cancer|0|N||
+C0018801|ENG|P|L0018801|PF|S0046934|Y|A0066463||D006333|D006333|MSH|MH|D006333|Heart Failure|0|N||
+C0018801|ENG|P|L0018801|PF|S0046934|Y|A2881557||84114007||SNOMEDCT_US|PT|84114007|Heart failure|9|N|256|`
+
+const mrsty = `C0027651|T191|B2.2.1.2.1.2|Neoplastic Process|AT17683839|256|
+C0006118|T191|B2.2.1.2.1.2|Neoplastic Process|AT17587440|256|
+C0018801|T047|B2.2.1.2.1|Disease or Syndrome|AT17594440|256|`
+
+const semGroups = `DISO|Disorders|T047|Disease or Syndrome
+DISO|Disorders|T191|Neoplastic Process`
+
+const mrrel = `C0006118|A0031779|SCUI|PAR|C0027651|A0089223|SCUI||R01|R01|MSH|MSH||N|N||
+C0027651|A0089223|SCUI|CHD|C0006118|A0031779|SCUI||R02|R02|MSH|MSH||N|N||
+C0018801|A2881557|SCUI|PAR|C0027651|A0089223|SCUI||R03|R03|MTH|MTH||N|N||`
+
+func TestNewOptions(t *testing.T) {
+	a := assert.New(t)
+
+	o, err := NewOptions(nil, nil)
+	a.NoError(err)
+	a.True(o.Sources.Contains("MSH"))
+	a.Empty(o.Fnames())
+
+	parameters := map[string]string{"umls_sources": "MSH", "umls_term_types": "MH, SY", "umls_relations_file": "MRREL.RRF"}
+	o, err = NewOptions(parameters, func(k string) string { return "/data/" + parameters[k] })
+	a.NoError(err)
+	a.Equal("sources:MSH languages:ENG term_types:MH,SY max_tree_numbers:16", o.String())
+	a.Equal([]string{"/data/MRREL.RRF"}, o.Fnames())
+
+	_, err = NewOptions(map[string]string{"umls_max_tree_numbers": "0"}, nil)
+	a.Error(err)
+}
+
+func TestLoad(t *testing.T) {
+	a := assert.New(t)
+
+	o, _ := NewOptions(map[string]string{"umls_sources": "MSH", "umls_term_types": "MH,SY"}, nil)
+	root, concepts, err := loadConcepts(strings.NewReader(mrconso), o)
+	a.NoError(err)
+	a.Len(concepts, 3)
+	a.Equal("C0027651", concepts["C0027651"].ID())
+	a.True(concepts["C0027651"].Synonyms().Contains("Cancer"))
+	a.False(concepts["C0027651"].Synonyms().Contains("Neoplasmen"))
+	a.False(concepts["C0006118"].Synonyms().Contains("Brain Cancer"))
+	a.False(concepts["C0018801"].Synonyms().Contains("Heart failure"))
+
+	o, _ = NewOptions(map[string]string{"umls_sources": "MSH,SNOMEDCT_US"}, nil)
+	root, concepts, err = loadConcepts(strings.NewReader(mrconso), o)
+	a.NoError(err)
+	a.True(concepts["C0006118"].Synonyms().Contains("Brain Cancer"))
+	a.True(concepts["C0018801"].Synonyms().Contains("Heart failure"))
+
+	groups, err := loadSemanticGroups(strings.NewReader(semGroups))
+	a.NoError(err)
+	cnt, err := addSemanticTypes(strings.NewReader(mrsty), concepts, groups)
+	a.NoError(err)
+	a.Equal(3, cnt)
+	a.Equal([]string{"DISO", "T191"}, concepts["C0006118"].Categories().Slice())
+
+	cnt, err = addRelations(strings.NewReader(mrrel), concepts, o)
+	a.NoError(err)
+	a.Equal(2, cnt)
+	a.Equal([]string{"1.1"}, concepts["C0006118"].TreeNumbers().Slice())
+	a.True(concepts["C0018801"].TreeNumbers().Empty())
+
+	tx := taxonomy.New(root)
+	tx.Normalize(func(s string) (string, string) { return strings.ToLower(s), strings.ToLower(s) })
+	tx.SetBaseIndex()
+	terms := tx.Match("brain cancer", 0.1, set.New("T191"))
+	a.Equal("Brain Neoplasms", terms.MaxKey())
+	a.Equal([]string{"C0006118"}, terms.IDs()[:1])
+	a.NotEqual("Heart Failure", tx.Match("heart failure", 0.1, set.New("T191")).MaxKey())
+	a.Equal("Heart Failure", tx.Match("heart failure", 0.1, set.New("DISO")).MaxKey())
+	a.True(tx.Generalizes([]string{"Neoplasms"}, []string{"Brain Neoplasms"}))
+}